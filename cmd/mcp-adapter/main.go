@@ -1,8 +1,15 @@
 // mcp-adapter is a CLI tool for managing MCP (Model Context Protocol) servers.
 package main
 
-import "github.com/xenixo/mcp-adapter/internal/cli"
+import (
+	"github.com/xenixo/mcp-adapter/internal/cli"
+	"github.com/xenixo/mcp-adapter/internal/sandbox"
+)
 
 func main() {
+	// Sandboxed servers are started through this binary, which sets up
+	// the sandbox before running them.
+	sandbox.Init()
+
 	cli.Execute()
 }
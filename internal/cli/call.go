@@ -0,0 +1,284 @@
+package cli
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/xenixo/mcp-adapter/internal/launcher"
+	"github.com/xenixo/mcp-adapter/internal/mcp"
+)
+
+func newCallCmd(app *App) *cobra.Command {
+	var (
+		toolArgs   []string
+		jsonArgs   string
+		envVars    []string
+		saveDir    string
+		raw        bool
+		noProgress bool
+		timeout    time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "call <server> <tool> [-- server-args...]",
+		Short: "Invoke a single tool on a server",
+		Long: `Launch a server, call one of its tools and print the result.
+
+Arguments are given as --arg key=value pairs, converted to the types declared
+in the tool's input schema, and/or as a JSON object with --json (use "-" to
+read it from stdin). They are validated against the input schema before the
+call is made.
+
+Text content is printed; images and audio are saved to files. The command
+exits with a non-zero status if the tool reports an error. Progress the
+server reports for long-running tools is shown on stderr.
+
+Example:
+  mcp-adapter call filesystem list_directory --arg path=/tmp -- /tmp
+  mcp-adapter call github search_repositories --json '{"query": "mcp"}'`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, cmdArgs []string) error {
+			return runCall(app, cmdArgs[0], cmdArgs[1], cmdArgs[2:], envVars, toolArgs, jsonArgs, saveDir, raw, !noProgress, timeout)
+		},
+	}
+
+	cmd.Flags().StringArrayVarP(&toolArgs, "arg", "a", nil, "Tool argument (key=value, repeatable)")
+	cmd.Flags().StringVar(&jsonArgs, "json", "", "Tool arguments as a JSON object (\"-\" reads stdin)")
+	cmd.Flags().StringArrayVarP(&envVars, "env", "e", nil, "Environment variables (KEY=VALUE)")
+	cmd.Flags().StringVar(&saveDir, "save-dir", ".", "Directory to save image and audio content to")
+	cmd.Flags().BoolVar(&raw, "raw", false, "Print the raw tools/call result as JSON")
+	cmd.Flags().BoolVar(&noProgress, "no-progress", false, "Do not show the tool's progress")
+	cmd.Flags().DurationVar(&timeout, "timeout", mcp.DefaultRequestTimeout, "Time to wait for the tool to finish")
+
+	return cmd
+}
+
+func runCall(app *App, serverName, toolName string, serverArgs, envVars, toolArgs []string, jsonArgs, saveDir string, raw, showProgress bool, timeout time.Duration) error {
+	server, err := findInstalledServer(app, serverName)
+	if err != nil {
+		return err
+	}
+
+	chain, err := serverMiddleware(app, serverName)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	launcherInst := launcher.NewLauncher(app.Config, app.Logger)
+	defer launcherInst.StopAll(stopTimeout)
+
+	opts, err := buildLaunchOptions(app, serverName, serverArgs, envVars)
+	if err != nil {
+		return err
+	}
+	client, err := connectClient(ctx, app, launcherInst, server, opts, chain)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", serverName, err)
+	}
+	defer client.Close()
+
+	tool, err := findTool(ctx, client, toolName)
+	if err != nil {
+		return err
+	}
+
+	args, err := parseToolArgs(tool.InputSchema, jsonArgs, toolArgs, os.Stdin)
+	if err != nil {
+		return err
+	}
+	if err := mcp.ValidateArguments(tool.InputSchema, args); err != nil {
+		return err
+	}
+
+	callCtx, callCancel := context.WithTimeout(ctx, timeout)
+	defer callCancel()
+
+	var bar *progressBar
+	if showProgress {
+		bar = newProgressBar(os.Stderr, toolName)
+		callCtx = mcp.WithProgress(callCtx, bar.Update)
+	}
+
+	result, err := client.CallTool(callCtx, toolName, args)
+	if bar != nil {
+		bar.Done()
+	}
+	if err != nil {
+		return fmt.Errorf("call to %s failed: %w", toolName, err)
+	}
+
+	if raw {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(result); err != nil {
+			return err
+		}
+	} else if err := printContent(os.Stdout, result.Content, saveDir, toolName); err != nil {
+		return err
+	}
+
+	if result.IsError {
+		return fmt.Errorf("tool %s reported an error", toolName)
+	}
+	return nil
+}
+
+// findTool looks up a tool by name in the server's tool list.
+func findTool(ctx context.Context, client *mcp.Client, name string) (*mcp.Tool, error) {
+	tools, err := client.ListTools(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tools: %w", err)
+	}
+
+	names := make([]string, 0, len(tools))
+	for i := range tools {
+		if tools[i].Name == name {
+			return &tools[i], nil
+		}
+		names = append(names, tools[i].Name)
+	}
+	return nil, fmt.Errorf("tool %q not found (available: %s)", name, strings.Join(names, ", "))
+}
+
+// parseToolArgs merges a JSON argument object with key=value pairs, which
+// take precedence. Pair values are converted to the property type declared
+// in the schema; undeclared values are parsed as JSON if possible.
+func parseToolArgs(schema json.RawMessage, jsonArgs string, pairs []string, stdin io.Reader) (map[string]interface{}, error) {
+	args := make(map[string]interface{})
+
+	if jsonArgs != "" {
+		data := []byte(jsonArgs)
+		if jsonArgs == "-" {
+			var err error
+			if data, err = io.ReadAll(stdin); err != nil {
+				return nil, fmt.Errorf("failed to read arguments from stdin: %w", err)
+			}
+		}
+		if err := json.Unmarshal(data, &args); err != nil {
+			return nil, fmt.Errorf("--json must be a JSON object: %w", err)
+		}
+	}
+
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid argument %q (expected key=value)", pair)
+		}
+		v, err := convertArg(mcp.SchemaPropertyType(schema, key), value)
+		if err != nil {
+			return nil, fmt.Errorf("argument %s: %w", key, err)
+		}
+		args[key] = v
+	}
+
+	return args, nil
+}
+
+func convertArg(typ, value string) (interface{}, error) {
+	switch typ {
+	case "string":
+		return value, nil
+	case "integer", "number":
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", value)
+		}
+		return f, nil
+	case "boolean":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", value)
+		}
+		return b, nil
+	case "array", "object":
+		var v interface{}
+		if err := json.Unmarshal([]byte(value), &v); err != nil {
+			return nil, fmt.Errorf("expected JSON %s: %w", typ, err)
+		}
+		return v, nil
+	default:
+		var v interface{}
+		if err := json.Unmarshal([]byte(value), &v); err == nil {
+			return v, nil
+		}
+		return value, nil
+	}
+}
+
+// printContent prints text content blocks and saves binary ones to files
+// named after the tool in saveDir.
+func printContent(out io.Writer, blocks []mcp.Content, saveDir, name string) error {
+	for i, block := range blocks {
+		switch block.Type {
+		case "text":
+			fmt.Fprintln(out, block.Text)
+
+		case "image", "audio":
+			path, err := saveBlob(saveDir, fmt.Sprintf("%s-%d", name, i+1), block.MimeType, block.Data)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "[%s saved to %s (%s)]\n", block.Type, path, block.MimeType)
+
+		case "resource":
+			if block.Resource == nil {
+				continue
+			}
+			res := block.Resource
+			fmt.Fprintf(out, "[resource %s]\n", res.URI)
+			if res.Blob != "" {
+				path, err := saveBlob(saveDir, fmt.Sprintf("%s-%d", name, i+1), res.MimeType, res.Blob)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(out, "[resource saved to %s (%s)]\n", path, res.MimeType)
+			} else {
+				fmt.Fprintln(out, res.Text)
+			}
+
+		case "resource_link":
+			fmt.Fprintf(out, "[resource link %s]\n", block.URI)
+
+		default:
+			fmt.Fprintf(out, "[unsupported %s content]\n", block.Type)
+		}
+	}
+	return nil
+}
+
+// saveBlob decodes base64 data and writes it to dir/base with an extension
+// matching the MIME type.
+func saveBlob(dir, base, mimeType, data string) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode %s content: %w", mimeType, err)
+	}
+
+	ext := ".bin"
+	if exts, _ := mime.ExtensionsByType(mimeType); len(exts) > 0 {
+		ext = exts[0]
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	path := filepath.Join(dir, base+ext)
+	if err := os.WriteFile(path, decoded, 0644); err != nil {
+		return "", fmt.Errorf("failed to save content: %w", err)
+	}
+	return path, nil
+}
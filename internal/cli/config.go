@@ -6,15 +6,70 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
+
+	"github.com/xenixo/mcp-adapter/internal/launcher"
+	"github.com/xenixo/mcp-adapter/internal/sandbox"
 )
 
 // ServerConfig holds per-server configuration
 type ServerConfig struct {
 	Env  map[string]string `yaml:"env,omitempty"`
 	Args []string          `yaml:"args,omitempty"`
+
+	// Headers are sent with every request to remote servers.
+	Headers map[string]string `yaml:"headers,omitempty"`
+
+	// Tools restricts which of the server's tools clients can see and call.
+	Tools *ToolPolicy `yaml:"tools,omitempty"`
+
+	// Middleware names proxy middleware to run for the server, in order.
+	Middleware []string `yaml:"middleware,omitempty"`
+
+	// MaxMessageSize limits the size in bytes of one message read from a
+	// stdio server. Zero means the default (16 MiB).
+	MaxMessageSize int `yaml:"maxMessageSize,omitempty"`
+
+	// Timeouts sets deadlines for requests proxied to the server.
+	Timeouts *TimeoutConfig `yaml:"timeouts,omitempty"`
+
+	// Restart decides whether a server started with 'mcp-adapter start' is
+	// restarted when it exits.
+	Restart *launcher.RestartPolicy `yaml:"restart,omitempty"`
+
+	// Health configures readiness and liveness checks of the server.
+	Health *launcher.HealthOptions `yaml:"health,omitempty"`
+
+	// Limits caps the memory, CPU, file descriptors and processes of the
+	// server.
+	Limits *launcher.ResourceLimits `yaml:"limits,omitempty"`
+
+	// Sandbox overrides the sandbox policy from the server's manifest.
+	Sandbox *sandbox.Policy `yaml:"sandbox,omitempty"`
+
+	// Network overrides the network policy from the server's manifest.
+	Network *sandbox.NetworkPolicy `yaml:"network,omitempty"`
+
+	// Seccomp replaces the seccomp policy from the server's manifest.
+	Seccomp *sandbox.SeccompPolicy `yaml:"seccomp,omitempty"`
+}
+
+// TimeoutConfig sets request deadlines: a default, per method, and per tool
+// name pattern (shell globs) for tools/call.
+type TimeoutConfig struct {
+	Default time.Duration            `yaml:"default,omitempty"`
+	Methods map[string]time.Duration `yaml:"methods,omitempty"`
+	Tools   map[string]time.Duration `yaml:"tools,omitempty"`
+}
+
+// ToolPolicy lists tool name patterns (shell globs) to allow or deny.
+// An empty allow list allows every tool not denied.
+type ToolPolicy struct {
+	Allow []string `yaml:"allow,omitempty"`
+	Deny  []string `yaml:"deny,omitempty"`
 }
 
 // AppConfig holds the application configuration
@@ -42,23 +97,30 @@ for servers without passing them on the command line each time.`,
 }
 
 func newConfigSetCmd(app *App) *cobra.Command {
+	var header bool
+
 	cmd := &cobra.Command{
 		Use:   "set <server> <KEY=VALUE>...",
 		Short: "Set environment variables for a server",
 		Long: `Set environment variables for a server.
 
+With --header, the pairs are stored as HTTP headers sent to remote servers.
+
 Examples:
   mcp-adapter config set github GITHUB_PERSONAL_ACCESS_TOKEN=ghp_xxxxx
   mcp-adapter config set brave-search BRAVE_API_KEY=your-api-key
-  mcp-adapter config set sentry SENTRY_AUTH_TOKEN=xxx SENTRY_ORG=myorg`,
+  mcp-adapter config set sentry SENTRY_AUTH_TOKEN=xxx SENTRY_ORG=myorg
+  mcp-adapter config set my-remote --header "Authorization=Bearer xxx"`,
 		Args: cobra.MinimumNArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			serverName := args[0]
 			envPairs := args[1:]
-			return runConfigSet(app, serverName, envPairs)
+			return runConfigSet(app, serverName, envPairs, header)
 		},
 	}
 
+	cmd.Flags().BoolVarP(&header, "header", "H", false, "Set HTTP headers for a remote server instead of environment variables")
+
 	return cmd
 }
 
@@ -158,7 +220,7 @@ func saveAppConfig(app *App, config *AppConfig) error {
 	return nil
 }
 
-func runConfigSet(app *App, serverName string, envPairs []string) error {
+func runConfigSet(app *App, serverName string, envPairs []string, header bool) error {
 	config, err := loadAppConfig(app)
 	if err != nil {
 		return err
@@ -171,6 +233,14 @@ func runConfigSet(app *App, serverName string, envPairs []string) error {
 	if serverConfig.Env == nil {
 		serverConfig.Env = make(map[string]string)
 	}
+	if serverConfig.Headers == nil {
+		serverConfig.Headers = make(map[string]string)
+	}
+
+	target, kind := serverConfig.Env, ""
+	if header {
+		target, kind = serverConfig.Headers, "header "
+	}
 
 	for _, pair := range envPairs {
 		parts := strings.SplitN(pair, "=", 2)
@@ -186,8 +256,8 @@ func runConfigSet(app *App, serverName string, envPairs []string) error {
 			displayValue = value[:4] + "..." + value[len(value)-4:]
 		}
 
-		serverConfig.Env[key] = value
-		fmt.Printf("Set %s=%s for %s\n", key, displayValue, serverName)
+		target[key] = value
+		fmt.Printf("Set %s%s=%s for %s\n", kind, key, displayValue, serverName)
 	}
 
 	config.Servers[serverName] = serverConfig
@@ -226,6 +296,18 @@ func runConfigGet(app *App, serverName string) error {
 		}
 	}
 
+	if len(serverConfig.Headers) > 0 {
+		fmt.Println()
+		fmt.Println("HTTP headers:")
+		for key, value := range serverConfig.Headers {
+			displayValue := value
+			if isSensitiveKey(key) && len(value) > 8 {
+				displayValue = value[:4] + "..." + value[len(value)-4:]
+			}
+			fmt.Printf("  %s: %s\n", key, displayValue)
+		}
+	}
+
 	if len(serverConfig.Args) > 0 {
 		fmt.Println()
 		fmt.Println("Default arguments:")
@@ -234,6 +316,125 @@ func runConfigGet(app *App, serverName string) error {
 		}
 	}
 
+	if len(serverConfig.Middleware) > 0 {
+		fmt.Println()
+		fmt.Printf("Middleware: %s\n", strings.Join(serverConfig.Middleware, ", "))
+	}
+
+	if serverConfig.MaxMessageSize > 0 {
+		fmt.Println()
+		fmt.Printf("Max message size: %d bytes\n", serverConfig.MaxMessageSize)
+	}
+
+	if timeouts := serverConfig.Timeouts; timeouts != nil {
+		fmt.Println()
+		fmt.Println("Timeouts:")
+		if timeouts.Default > 0 {
+			fmt.Printf("  default: %s\n", timeouts.Default)
+		}
+		for method, d := range timeouts.Methods {
+			fmt.Printf("  %s: %s\n", method, d)
+		}
+		for tool, d := range timeouts.Tools {
+			fmt.Printf("  tool %s: %s\n", tool, d)
+		}
+	}
+
+	if restart := serverConfig.Restart; restart != nil {
+		fmt.Println()
+		fmt.Printf("Restart: %s\n", restart.Mode)
+		if restart.MaxRestarts > 0 {
+			fmt.Printf("  maxRestarts: %d\n", restart.MaxRestarts)
+		}
+		if restart.Window > 0 {
+			fmt.Printf("  window: %s\n", restart.Window)
+		}
+		if restart.InitialBackoff > 0 {
+			fmt.Printf("  backoff: %s\n", restart.InitialBackoff)
+		}
+		if restart.MaxBackoff > 0 {
+			fmt.Printf("  maxBackoff: %s\n", restart.MaxBackoff)
+		}
+	}
+
+	if health := serverConfig.Health; health != nil {
+		fmt.Println()
+		fmt.Println("Health checks:")
+		if health.StartupTimeout > 0 {
+			fmt.Printf("  startupTimeout: %s\n", health.StartupTimeout)
+		}
+		if health.Interval > 0 {
+			fmt.Printf("  interval: %s\n", health.Interval)
+		}
+		if health.Timeout > 0 {
+			fmt.Printf("  timeout: %s\n", health.Timeout)
+		}
+		if health.FailureThreshold > 0 {
+			fmt.Printf("  failureThreshold: %d\n", health.FailureThreshold)
+		}
+	}
+
+	if limits := serverConfig.Limits; !limits.IsZero() {
+		fmt.Println()
+		fmt.Println("Resource limits:")
+		if limits.Memory > 0 {
+			fmt.Printf("  memory: %s\n", limits.Memory)
+		}
+		if limits.CPU > 0 {
+			fmt.Printf("  cpu: %g\n", limits.CPU)
+		}
+		if limits.Files > 0 {
+			fmt.Printf("  files: %d\n", limits.Files)
+		}
+		if limits.Processes > 0 {
+			fmt.Printf("  processes: %d\n", limits.Processes)
+		}
+	}
+
+	if policy := serverConfig.Sandbox; policy != nil {
+		fmt.Println()
+		fmt.Println("Sandbox:")
+		if policy.Enabled != nil {
+			fmt.Printf("  enabled: %t\n", *policy.Enabled)
+		}
+		if len(policy.ReadOnly) > 0 {
+			fmt.Printf("  readOnly: %s\n", strings.Join(policy.ReadOnly, ", "))
+		}
+		if len(policy.ReadWrite) > 0 {
+			fmt.Printf("  readWrite: %s\n", strings.Join(policy.ReadWrite, ", "))
+		}
+	}
+
+	if network := serverConfig.Network; network != nil {
+		fmt.Println()
+		fmt.Printf("Network: %s\n", network.EffectiveMode())
+		if len(network.Allow) > 0 {
+			fmt.Printf("  allow: %s\n", strings.Join(network.Allow, ", "))
+		}
+	}
+
+	if seccomp := serverConfig.Seccomp; seccomp != nil {
+		fmt.Println()
+		fmt.Println("Seccomp:")
+		if seccomp.Profile != "" {
+			fmt.Printf("  profile: %s\n", seccomp.Profile)
+		}
+		if len(seccomp.Deny) > 0 {
+			fmt.Printf("  deny: %s\n", strings.Join(seccomp.Deny, ", "))
+		}
+	}
+
+	if policy := serverConfig.Tools; policy != nil {
+		fmt.Println()
+		fmt.Println("Tool policy:")
+		if len(policy.Allow) > 0 {
+			fmt.Printf("  allow: %s\n", strings.Join(policy.Allow, ", "))
+		}
+		if len(policy.Deny) > 0 {
+			fmt.Printf("  deny:  %s\n", strings.Join(policy.Deny, ", "))
+		}
+	}
+
 	return nil
 }
 
@@ -279,12 +480,47 @@ func runConfigEdit(app *App) error {
 #   github:
 #     env:
 #       GITHUB_PERSONAL_ACCESS_TOKEN: "ghp_xxxxxxxxxxxx"
+#     network:
+#       policy: allow
+#       allow: ["api.github.com:443"]
 #   brave-search:
 #     env:
 #       BRAVE_API_KEY: "your-api-key"
 #   filesystem:
 #     args:
 #       - "/path/to/allowed/directory"
+#     sandbox:
+#       enabled: true
+#       readWrite: ["/path/to/allowed/directory"]
+#     network:
+#       policy: none
+#     seccomp:
+#       profile: node-compatible
+#       deny: ["socket"]
+#     tools:
+#       deny: ["write_file", "move_file"]
+#     middleware: ["log", "redact"]
+#     maxMessageSize: 67108864
+#   puppeteer:
+#     timeouts:
+#       default: 2m
+#       tools:
+#         puppeteer_screenshot: 30s
+#     restart:
+#       policy: on-failure
+#       maxRestarts: 5
+#       window: 10m
+#     health:
+#       startupTimeout: 1m
+#       interval: 30s
+#     limits:
+#       memory: 2G
+#       cpu: 1.5
+#       files: 4096
+#       processes: 256
+#   my-remote:
+#     headers:
+#       Authorization: "Bearer xxxxxxxx"
 
 servers: {}
 `
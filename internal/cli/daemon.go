@@ -0,0 +1,238 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/xenixo/mcp-adapter/internal/launcher"
+	"github.com/xenixo/mcp-adapter/internal/mcp"
+	"github.com/xenixo/mcp-adapter/internal/supervisor"
+)
+
+// daemonStartTimeout bounds how long start waits for a spawned daemon to
+// accept connections.
+const daemonStartTimeout = 5 * time.Second
+
+func newDaemonCmd(app *App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Run the supervisor daemon in the foreground",
+		Long: `Run the supervisor daemon that keeps servers started with 'mcp-adapter start'
+running in the background and exposes each over Streamable HTTP.
+
+The daemon listens on a Unix socket in the configuration directory. It is
+started automatically by 'mcp-adapter start'; run it directly to keep it in
+the foreground, for example under systemd or launchd. SIGINT or SIGTERM, or
+'mcp-adapter stop --all', stops every server and the daemon.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDaemon(app)
+		},
+	}
+
+	return cmd
+}
+
+func runDaemon(app *App) error {
+	socketPath := supervisor.SocketPath(app.Config.BaseDir)
+	l, err := supervisor.Listen(socketPath)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(socketPath)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+	go func() {
+		select {
+		case sig := <-sigChan:
+			app.Logger.Info("received signal", zap.String("signal", sig.String()))
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	d := &daemon{app: app, launcher: launcher.NewLauncher(app.Config, app.Logger)}
+	defer d.launcher.StopAll(stopTimeout)
+
+	app.Logger.Info("supervisor daemon listening", zap.String("socket", socketPath), zap.Int("pid", os.Getpid()))
+	return supervisor.New(d.run, app.Logger).Serve(ctx, l)
+}
+
+// daemon runs supervised servers, each exposed over Streamable HTTP with
+// one process shared between all sessions.
+type daemon struct {
+	app      *App
+	launcher *launcher.Launcher
+}
+
+// run is the daemon's supervisor.RunFunc.
+func (d *daemon) run(ctx context.Context, spec *supervisor.Spec, started func(supervisor.Instance)) error {
+	app := d.app
+
+	server, err := findInstalledServer(app, spec.Server)
+	if err != nil {
+		return err
+	}
+	if server.IsRemote() {
+		return fmt.Errorf("server %q is a remote server and needs no supervision", spec.Server)
+	}
+	chain, err := serverMiddleware(app, spec.Server)
+	if err != nil {
+		return err
+	}
+
+	// Listen first so that an address in use fails before launching.
+	ln, err := net.Listen("tcp", spec.Addr)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+
+	// The process must outlive ctx long enough to be stopped gracefully.
+	// Server stderr goes only to the server's own log.
+	opts, err := buildLaunchOptions(app, spec.Server, spec.Args, spec.Env)
+	if err != nil {
+		return err
+	}
+	stdioOpts, err := stdioOptions(app, server)
+	if err != nil {
+		return err
+	}
+	opts.Stderr = io.Discard
+	backend, err := launchStdio(context.Background(), app, d.launcher, server, opts)
+	if err != nil {
+		return err
+	}
+	mux := mcp.NewMultiplexer(withMiddleware(backend, chain))
+
+	// The server counts as started once it completes the handshake.
+	healthCtx, stopHealth := context.WithCancel(ctx)
+	defer stopHealth()
+	if _, err := superviseHealth(healthCtx, app, d.launcher, spec.Server, backend.proc, mux); err != nil {
+		mux.Close()
+		<-backend.proc.Done()
+		return err
+	}
+
+	handler := mcp.NewHTTPHandlerWithOptions(func(context.Context) (mcp.Transport, error) {
+		select {
+		case <-mux.Done():
+			return nil, fmt.Errorf("server %q has exited", spec.Server)
+		default:
+			return mux.Session(), nil
+		}
+	}, &mcp.HTTPHandlerOptions{
+		AllowedHosts:   spec.AllowedHosts,
+		MaxMessageSize: stdioOpts.MaxMessageSize,
+	})
+	defer handler.Close()
+
+	routes := http.NewServeMux()
+	routes.Handle(spec.Path, handler)
+	httpServer := &http.Server{Handler: routes, ReadHeaderTimeout: 10 * time.Second}
+	go httpServer.Serve(ln)
+
+	app.Logger.Info("serving supervised server",
+		zap.String("server", spec.Server),
+		zap.String("url", "http://"+ln.Addr().String()+spec.Path),
+	)
+	started(supervisor.Instance{Process: backend.proc, Addr: ln.Addr().String()})
+
+	select {
+	case <-ctx.Done():
+	case <-mux.Done():
+	}
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+	httpServer.Shutdown(shutdownCtx)
+	mux.Close()
+	<-backend.proc.Done()
+
+	if ctx.Err() != nil {
+		return nil
+	}
+	if info := backend.proc.Info(); info.Error != nil {
+		return fmt.Errorf("server exited: %w", info.Error)
+	}
+	return errors.New("server exited")
+}
+
+// daemonClient returns a client for the supervisor daemon. With spawn set,
+// a daemon is started in the background if none is running.
+func daemonClient(ctx context.Context, app *App, spawn bool) (*supervisor.Client, error) {
+	socketPath := supervisor.SocketPath(app.Config.BaseDir)
+	client := supervisor.NewClient(socketPath)
+
+	_, err := client.List(ctx)
+	if !errors.Is(err, supervisor.ErrDaemonNotRunning) || !spawn {
+		return client, err
+	}
+
+	if err := spawnDaemon(app); err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(daemonStartTimeout)
+	for {
+		_, err := client.List(ctx)
+		if !errors.Is(err, supervisor.ErrDaemonNotRunning) {
+			return client, err
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("supervisor daemon did not start; see %s", daemonLogPath(app))
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// daemonLogPath is where a spawned daemon writes its log.
+func daemonLogPath(app *App) string {
+	return filepath.Join(app.Config.BaseDir, "daemon.log")
+}
+
+// spawnDaemon starts the daemon as a detached background process.
+func spawnDaemon(app *App) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate mcp-adapter executable: %w", err)
+	}
+
+	if err := os.MkdirAll(app.Config.BaseDir, 0755); err != nil {
+		return err
+	}
+	logFile, err := os.OpenFile(daemonLogPath(app), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open daemon log: %w", err)
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(exe, "daemon", "--config-dir", app.Config.BaseDir, "--log-level", app.LogLevel)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.Dir = app.Config.BaseDir
+	detach(cmd)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start supervisor daemon: %w", err)
+	}
+
+	app.Logger.Debug("spawned supervisor daemon", zap.Int("pid", cmd.Process.Pid))
+	return cmd.Process.Release()
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package cli
+
+import "os/exec"
+
+// detach does nothing on systems without Unix sessions, where the daemon
+// stays attached to the console it was started from.
+func detach(cmd *exec.Cmd) {}
@@ -9,6 +9,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/xenixo/mcp-adapter/internal/runtime"
+	"github.com/xenixo/mcp-adapter/internal/sandbox"
 )
 
 func newDoctorCmd(app *App) *cobra.Command {
@@ -83,6 +84,31 @@ func runDoctor(app *App) error {
 	w.Flush()
 	fmt.Println()
 
+	// Sandboxing is optional, so missing support is only a warning
+	fmt.Println("Sandbox:")
+	sys := sandbox.Check()
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if sys.Namespaces == nil {
+		fmt.Fprintf(w, "  ✓ namespaces\tuser, mount, PID and network\n")
+	} else {
+		fmt.Fprintf(w, "  ⚠ namespaces\tunavailable\t(%v)\n", sys.Namespaces)
+	}
+	if sys.Landlock == nil {
+		fmt.Fprintf(w, "  ✓ Landlock\tABI %d\n", sys.LandlockABI)
+	} else {
+		fmt.Fprintf(w, "  ⚠ Landlock\tunavailable\t(%v)\n", sys.Landlock)
+	}
+	switch {
+	case sys.Seccomp != nil:
+		fmt.Fprintf(w, "  ⚠ seccomp\tunavailable\t(%v)\n", sys.Seccomp)
+	case sys.SeccompNotify != nil:
+		fmt.Fprintf(w, "  ⚠ seccomp\tfilters only\t(denials are not reported: %v)\n", sys.SeccompNotify)
+	default:
+		fmt.Fprintf(w, "  ✓ seccomp\tfilters and user notification\n")
+	}
+	w.Flush()
+	fmt.Println()
+
 	// Check for installed servers
 	fmt.Println("Installed Servers:")
 	serversDir := app.Config.ServersDir
@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/xenixo/mcp-adapter/internal/gateway"
+	"github.com/xenixo/mcp-adapter/internal/launcher"
+	"github.com/xenixo/mcp-adapter/internal/mcp"
+)
+
+func newGatewayCmd(app *App) *cobra.Command {
+	var (
+		addr  string
+		path  string
+		hosts []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "gateway <server>...",
+		Short: "Serve several MCP servers behind one endpoint",
+		Long: `Launch several installed MCP servers and expose them as a single MCP server.
+
+Tools and prompts are prefixed with their server name ("filesystem__read_file")
+and calls are routed back to the server that owns them. Resources keep their
+URIs and are routed by URI.
+
+The gateway speaks stdio by default, or Streamable HTTP with --http.
+
+Example:
+  mcp-adapter gateway filesystem memory github
+  mcp-adapter gateway filesystem memory --http 127.0.0.1:8080`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGateway(app, args, addr, path, hosts)
+		},
+	}
+
+	cmd.Flags().StringVar(&addr, "http", "", "Serve Streamable HTTP on this address instead of stdio")
+	cmd.Flags().StringVar(&path, "path", "/mcp", "URL path of the MCP endpoint (with --http)")
+	cmd.Flags().StringArrayVar(&hosts, "allow-host", nil, "Host name besides loopback the endpoint may be reached by (with --http)")
+
+	return cmd
+}
+
+func runGateway(app *App, serverNames []string, addr, path string, hosts []string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	launcherInst := launcher.NewLauncher(app.Config, app.Logger)
+	defer launcherInst.StopAll(stopTimeout)
+
+	backends := make([]*gateway.Backend, 0, len(serverNames))
+	defer func() {
+		for _, b := range backends {
+			b.Client.Close()
+		}
+	}()
+
+	for _, name := range serverNames {
+		b, err := connectBackend(ctx, app, launcherInst, name)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		backends = append(backends, b)
+	}
+
+	gw, err := gateway.New(backends, &gateway.Options{
+		Info:   mcp.Implementation{Name: "mcp-adapter-gateway", Version: Version},
+		Logger: app.Logger,
+	})
+	if err != nil {
+		return err
+	}
+
+	app.Logger.Info("gateway ready", zap.Strings("servers", serverNames))
+
+	if addr != "" {
+		handler := mcp.NewHTTPHandlerWithOptions(func(context.Context) (mcp.Transport, error) {
+			clientEnd, serverEnd := mcp.NewPipe()
+			go gw.Serve(serverEnd)
+			return clientEnd, nil
+		}, &mcp.HTTPHandlerOptions{AllowedHosts: hosts})
+		defer handler.Close()
+
+		fmt.Fprintf(os.Stderr, "Serving gateway for %s at http://%s%s\n", strings.Join(serverNames, ", "), addr, path)
+		return serveHTTP(app, handler, addr, path)
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- gw.Serve(mcp.NewStdioTransport(os.Stdin, os.Stdout))
+	}()
+
+	select {
+	case sig := <-sigChan:
+		app.Logger.Info("received signal", zap.String("signal", sig.String()))
+		return nil
+	case err := <-errChan:
+		return err
+	}
+}
+
+// connectBackend connects to a server with its configured middleware.
+func connectBackend(ctx context.Context, app *App, l *launcher.Launcher, serverName string) (*gateway.Backend, error) {
+	server, err := findInstalledServer(app, serverName)
+	if err != nil {
+		return nil, err
+	}
+
+	chain, err := serverMiddleware(app, serverName)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := buildLaunchOptions(app, serverName, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	health, err := healthOptions(app, serverName)
+	if err != nil {
+		return nil, err
+	}
+	client, err := connectClient(ctx, app, l, server, opts, chain)
+	if err != nil {
+		return nil, err
+	}
+
+	// Remote servers have no process; their health is still tracked.
+	proc, _ := l.Get(serverName)
+	monitor := l.MonitorHealth(ctx, serverName, proc, client.Ping, health)
+
+	return &gateway.Backend{Name: serverName, Client: client, Health: monitor.Err}, nil
+}
@@ -0,0 +1,322 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/xenixo/mcp-adapter/internal/launcher"
+	"github.com/xenixo/mcp-adapter/internal/mcp"
+)
+
+// inspection is everything a server offers, as printed by inspect --json.
+type inspection struct {
+	ServerInfo        mcp.Implementation     `json:"serverInfo"`
+	ProtocolVersion   string                 `json:"protocolVersion"`
+	Capabilities      mcp.ServerCapabilities `json:"capabilities"`
+	Instructions      string                 `json:"instructions,omitempty"`
+	Tools             []mcp.Tool             `json:"tools"`
+	Resources         []mcp.Resource         `json:"resources"`
+	ResourceTemplates []mcp.ResourceTemplate `json:"resourceTemplates"`
+	Prompts           []mcp.Prompt           `json:"prompts"`
+	Errors            []string               `json:"errors,omitempty"`
+}
+
+func newInspectCmd(app *App) *cobra.Command {
+	var (
+		args       []string
+		envVars    []string
+		jsonOutput bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "inspect <server> [-- args...]",
+		Short: "Show the tools, resources and prompts a server offers",
+		Long: `Launch a server, perform the MCP handshake and print what it offers:
+server info, capabilities, tools with their input schemas, resources,
+resource templates and prompts.
+
+Example:
+  mcp-adapter inspect filesystem -- /tmp
+  mcp-adapter inspect github --json`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, cmdArgs []string) error {
+			if len(cmdArgs) > 1 {
+				args = append(args, cmdArgs[1:]...)
+			}
+			return runInspect(app, cmdArgs[0], args, envVars, jsonOutput)
+		},
+	}
+
+	cmd.Flags().StringArrayVarP(&args, "arg", "a", nil, "Additional arguments to pass to the server")
+	cmd.Flags().StringArrayVarP(&envVars, "env", "e", nil, "Environment variables (KEY=VALUE)")
+	cmd.Flags().BoolVarP(&jsonOutput, "json", "j", false, "Output in JSON format")
+
+	return cmd
+}
+
+func runInspect(app *App, serverName string, args, envVars []string, jsonOutput bool) error {
+	server, err := findInstalledServer(app, serverName)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	launcherInst := launcher.NewLauncher(app.Config, app.Logger)
+	defer launcherInst.StopAll(stopTimeout)
+
+	opts, err := buildLaunchOptions(app, serverName, args, envVars)
+	if err != nil {
+		return err
+	}
+	client, err := connectClient(ctx, app, launcherInst, server, opts, withLogCapture(app, serverName, nil))
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", serverName, err)
+	}
+	defer client.Close()
+
+	result := inspectServer(ctx, client)
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+
+	return printInspection(os.Stdout, result)
+}
+
+// inspectServer lists everything the server advertises. Listing failures
+// are collected rather than aborting the inspection.
+func inspectServer(ctx context.Context, client *mcp.Client) *inspection {
+	initResult := client.InitializeResult()
+	result := &inspection{
+		ServerInfo:        initResult.ServerInfo,
+		ProtocolVersion:   initResult.ProtocolVersion,
+		Capabilities:      initResult.Capabilities,
+		Instructions:      initResult.Instructions,
+		Tools:             []mcp.Tool{},
+		Resources:         []mcp.Resource{},
+		ResourceTemplates: []mcp.ResourceTemplate{},
+		Prompts:           []mcp.Prompt{},
+	}
+	caps := initResult.Capabilities
+
+	fail := func(what string, err error) {
+		result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", what, err))
+	}
+
+	if caps.Tools != nil {
+		if tools, err := client.ListTools(ctx); err != nil {
+			fail(mcp.MethodToolsList, err)
+		} else {
+			result.Tools = tools
+		}
+	}
+	if caps.Resources != nil {
+		if resources, err := client.ListResources(ctx); err != nil {
+			fail(mcp.MethodResourcesList, err)
+		} else {
+			result.Resources = resources
+		}
+		// Resource templates are optional even for servers with resources.
+		if templates, err := client.ListResourceTemplates(ctx); err == nil {
+			result.ResourceTemplates = templates
+		}
+	}
+	if caps.Prompts != nil {
+		if prompts, err := client.ListPrompts(ctx); err != nil {
+			fail(mcp.MethodPromptsList, err)
+		} else {
+			result.Prompts = prompts
+		}
+	}
+
+	return result
+}
+
+func printInspection(out io.Writer, in *inspection) error {
+	name := in.ServerInfo.Name
+	if in.ServerInfo.Title != "" {
+		name = fmt.Sprintf("%s (%s)", in.ServerInfo.Title, in.ServerInfo.Name)
+	}
+	fmt.Fprintf(out, "Server:       %s %s\n", name, in.ServerInfo.Version)
+	fmt.Fprintf(out, "Protocol:     %s\n", in.ProtocolVersion)
+	fmt.Fprintf(out, "Capabilities: %s\n", describeCapabilities(in.Capabilities))
+	if in.Instructions != "" {
+		fmt.Fprintf(out, "Instructions: %s\n", firstLine(in.Instructions, 100))
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "TOOLS (%d)\n", len(in.Tools))
+	if len(in.Tools) > 0 {
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tPARAMETERS\tDESCRIPTION")
+		for _, tool := range in.Tools {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", tool.Name, describeSchema(tool.InputSchema), firstLine(tool.Description, 60))
+		}
+		w.Flush()
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "RESOURCES (%d)\n", len(in.Resources))
+	if len(in.Resources) > 0 {
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "URI\tNAME\tMIME TYPE\tDESCRIPTION")
+		for _, r := range in.Resources {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.URI, r.Name, r.MimeType, firstLine(r.Description, 50))
+		}
+		w.Flush()
+	}
+
+	if len(in.ResourceTemplates) > 0 {
+		fmt.Fprintln(out)
+		fmt.Fprintf(out, "RESOURCE TEMPLATES (%d)\n", len(in.ResourceTemplates))
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "URI TEMPLATE\tNAME\tDESCRIPTION")
+		for _, t := range in.ResourceTemplates {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", t.URITemplate, t.Name, firstLine(t.Description, 50))
+		}
+		w.Flush()
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "PROMPTS (%d)\n", len(in.Prompts))
+	if len(in.Prompts) > 0 {
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tARGUMENTS\tDESCRIPTION")
+		for _, p := range in.Prompts {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", p.Name, describePromptArgs(p.Arguments), firstLine(p.Description, 60))
+		}
+		w.Flush()
+	}
+
+	if len(in.Errors) > 0 {
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Errors:")
+		for _, e := range in.Errors {
+			fmt.Fprintf(out, "  %s\n", e)
+		}
+	}
+
+	return nil
+}
+
+func describeCapabilities(caps mcp.ServerCapabilities) string {
+	var parts []string
+	if caps.Tools != nil {
+		parts = append(parts, "tools")
+	}
+	if caps.Resources != nil {
+		if caps.Resources.Subscribe {
+			parts = append(parts, "resources (subscribe)")
+		} else {
+			parts = append(parts, "resources")
+		}
+	}
+	if caps.Prompts != nil {
+		parts = append(parts, "prompts")
+	}
+	if caps.Logging != nil {
+		parts = append(parts, "logging")
+	}
+	if caps.Completions != nil {
+		parts = append(parts, "completions")
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// describeSchema summarizes an object schema's properties, marking optional
+// ones with "?", e.g. "path: string, depth?: integer".
+func describeSchema(raw json.RawMessage) string {
+	var schema struct {
+		Properties map[string]struct {
+			Type interface{} `json:"type"`
+		} `json:"properties"`
+		Required []string `json:"required"`
+	}
+	if err := json.Unmarshal(raw, &schema); err != nil || len(schema.Properties) == 0 {
+		return "-"
+	}
+
+	required := make(map[string]bool, len(schema.Required))
+	for _, name := range schema.Required {
+		required[name] = true
+	}
+
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if required[names[i]] != required[names[j]] {
+			return required[names[i]]
+		}
+		return names[i] < names[j]
+	})
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		label := name
+		if !required[name] {
+			label += "?"
+		}
+		if t := schemaType(schema.Properties[name].Type); t != "" {
+			label += ": " + t
+		}
+		parts = append(parts, label)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// schemaType renders a JSON Schema "type", which may be a string or a list.
+func schemaType(t interface{}) string {
+	switch v := t.(type) {
+	case string:
+		return v
+	case []interface{}:
+		types := make([]string, 0, len(v))
+		for _, item := range v {
+			types = append(types, fmt.Sprint(item))
+		}
+		return strings.Join(types, "|")
+	default:
+		return ""
+	}
+}
+
+func describePromptArgs(args []mcp.PromptArgument) string {
+	if len(args) == 0 {
+		return "-"
+	}
+	parts := make([]string, 0, len(args))
+	for _, arg := range args {
+		label := arg.Name
+		if !arg.Required {
+			label += "?"
+		}
+		parts = append(parts, label)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// firstLine returns the first line of s, truncated to max characters.
+func firstLine(s string, max int) string {
+	s, _, _ = strings.Cut(strings.TrimSpace(s), "\n")
+	if len(s) > max {
+		return s[:max-3] + "..."
+	}
+	return s
+}
@@ -69,6 +69,11 @@ func runInstall(app *App, serverName string, force bool, timeout time.Duration)
 		return fmt.Errorf("server %q not found in registry", serverName)
 	}
 
+	if server.IsRemote() {
+		fmt.Printf("Server %q is a remote server at %s; nothing to install.\n", serverName, server.Source.Remote)
+		return nil
+	}
+
 	installDir := app.Config.ServerInstallPath(serverName)
 
 	// Check if already installed
@@ -78,7 +78,7 @@ func printServersTable(app *App, servers []*manifest.Server, showInstalled bool)
 
 	for _, server := range servers {
 		installDir := app.Config.ServerInstallPath(server.Name)
-		installed := installer.IsInstalled(installDir)
+		installed := server.IsRemote() || installer.IsInstalled(installDir)
 
 		if showInstalled && !installed {
 			continue
@@ -121,7 +121,7 @@ func printServersJSON(app *App, servers []*manifest.Server, showInstalled bool)
 
 	for _, server := range servers {
 		installDir := app.Config.ServerInstallPath(server.Name)
-		installed := installer.IsInstalled(installDir)
+		installed := server.IsRemote() || installer.IsInstalled(installDir)
 
 		if showInstalled && !installed {
 			continue
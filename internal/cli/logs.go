@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/xenixo/mcp-adapter/internal/logs"
+)
+
+func newLogsCmd(app *App) *cobra.Command {
+	var (
+		follow bool
+		since  string
+		tail   int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "logs <server>",
+		Short: "Show a server's log",
+		Long: `Show the output captured from a server: its stderr (and stdout for HTTP
+servers), and the log messages it sends over MCP (notifications/message)
+with their level. Those are not captured when run connects the server
+straight to its client, as it does without a tool policy, middleware,
+timeouts or a recording.
+
+Logs are kept in ~/.mcp-adapter/logs/<server>/ and rotated when they reach
+10 MiB or a day old; the last 5 rotated files are kept.
+
+Example:
+  mcp-adapter logs github --tail 50
+  mcp-adapter logs github --since 10m --follow
+  mcp-adapter logs github --since 2025-01-02T15:04:05Z`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			start, err := parseSince(since)
+			if err != nil {
+				return err
+			}
+			return runLogs(app, args[0], start, tail, follow)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&follow, "follow", "f", false, "Keep printing new lines as they are logged")
+	cmd.Flags().StringVar(&since, "since", "", "Show lines since a time (RFC 3339) or for a duration (e.g. 10m)")
+	cmd.Flags().IntVarP(&tail, "tail", "n", -1, "Show only the last N lines (default all)")
+
+	return cmd
+}
+
+// parseSince parses --since as a duration before now or a timestamp.
+func parseSince(since string) (time.Time, error) {
+	if since == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(since); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, since); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q (use a duration such as 10m or an RFC 3339 time)", since)
+}
+
+func runLogs(app *App, serverName string, since time.Time, tail int, follow bool) error {
+	dir := logs.Dir(app.Config.BaseDir, serverName)
+	if _, err := os.Stat(dir); os.IsNotExist(err) && !follow {
+		return fmt.Errorf("no logs for server %q", serverName)
+	}
+
+	lines, err := logs.Read(dir, since, tail)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read logs: %w", err)
+	}
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+
+	if !follow {
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	return logs.Follow(ctx, dir, func(line string) {
+		fmt.Println(line)
+	})
+}
@@ -0,0 +1,205 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/xenixo/mcp-adapter/internal/logs"
+	"github.com/xenixo/mcp-adapter/internal/mcp"
+)
+
+// middlewareFactories builds the middleware that can be enabled by name in
+// a server's configuration.
+var middlewareFactories = map[string]func(app *App, serverName string, cfg *ServerConfig) mcp.Middleware{
+	"log":    logMiddleware,
+	"redact": redactMiddleware,
+}
+
+// serverMiddleware builds the proxy middleware chain for a server: its
+// configured middleware followed by capture of its log messages. It suits
+// callers that decode the server's messages anyway.
+func serverMiddleware(app *App, serverName string) ([]mcp.Middleware, error) {
+	chain, err := configuredMiddleware(app, serverName)
+	if err != nil {
+		return nil, err
+	}
+	return withLogCapture(app, serverName, chain), nil
+}
+
+// configuredMiddleware builds the middleware a server's configuration asks
+// for: its tool policy, if any, followed by the middleware named in its
+// configuration and finally its request deadlines.
+func configuredMiddleware(app *App, serverName string) ([]mcp.Middleware, error) {
+	var chain []mcp.Middleware
+	cfg, err := GetServerConfig(app, serverName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config for server %q: %w", serverName, err)
+	}
+
+	if cfg.Tools != nil {
+		chain = append(chain, mcp.FilterTools(&mcp.ToolFilter{
+			Allow: cfg.Tools.Allow,
+			Deny:  cfg.Tools.Deny,
+		}))
+	}
+
+	for _, name := range cfg.Middleware {
+		factory, ok := middlewareFactories[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown middleware %q for server %q (available: %s)",
+				name, serverName, strings.Join(middlewareNames(), ", "))
+		}
+		chain = append(chain, factory(app, serverName, cfg))
+	}
+
+	if t := cfg.Timeouts; t != nil {
+		chain = append(chain, mcp.Deadlines(&mcp.TimeoutPolicy{
+			Default: t.Default,
+			Methods: t.Methods,
+			Tools:   t.Tools,
+		}))
+	}
+
+	return chain, nil
+}
+
+// withLogCapture appends capture of the server's log messages to a chain.
+// It goes last so that it logs messages as the client gets them, with any
+// secrets redacted.
+func withLogCapture(app *App, serverName string, chain []mcp.Middleware) []mcp.Middleware {
+	serverLog, err := logs.ForServer(app.Config.BaseDir, serverName)
+	if err != nil {
+		app.Logger.Debug("server log messages will not be captured", zap.Error(err))
+		return chain
+	}
+	return append(chain[:len(chain):len(chain)], captureLogMessages(serverLog))
+}
+
+func middlewareNames() []string {
+	names := make([]string, 0, len(middlewareFactories))
+	for name := range middlewareFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// proxiedTransport is the client end of a proxy in front of a backend
+// transport. Closing it also closes the backend.
+type proxiedTransport struct {
+	mcp.Transport
+	backend mcp.Transport
+}
+
+// Close closes the proxy and the backend.
+func (t *proxiedTransport) Close() error {
+	t.Transport.Close()
+	return t.backend.Close()
+}
+
+// withMiddleware puts a proxy running the chain in front of the backend.
+// An empty chain returns the backend unchanged.
+func withMiddleware(backend mcp.Transport, chain []mcp.Middleware) mcp.Transport {
+	if len(chain) == 0 {
+		return backend
+	}
+
+	clientEnd, proxyEnd := mcp.NewPipe()
+	proxy := mcp.NewProxy(proxyEnd, backend)
+	proxy.Use(chain...)
+	go func() {
+		proxy.Run()
+		proxyEnd.Close()
+	}()
+
+	return &proxiedTransport{Transport: clientEnd, backend: backend}
+}
+
+// logMiddleware logs every message passing through the proxy.
+func logMiddleware(app *App, serverName string, _ *ServerConfig) mcp.Middleware {
+	return mcp.MiddlewareFunc(func(x *mcp.Exchange, msg *mcp.Message) (*mcp.Message, error) {
+		fields := []zap.Field{
+			zap.String("server", serverName),
+			zap.String("direction", x.Direction.String()),
+		}
+		if msg.Method != "" {
+			fields = append(fields, zap.String("method", msg.Method))
+		}
+		if msg.ID != nil {
+			fields = append(fields, zap.Any("id", msg.ID))
+		}
+		if msg.Error != nil {
+			fields = append(fields, zap.Int("errorCode", msg.Error.Code))
+		}
+		app.Logger.Info("mcp message", fields...)
+		return msg, nil
+	})
+}
+
+// captureLogMessages writes the server's notifications/message entries to
+// its log with their level.
+func captureLogMessages(serverLog *logs.Writer) mcp.Middleware {
+	return mcp.MiddlewareFunc(func(x *mcp.Exchange, msg *mcp.Message) (*mcp.Message, error) {
+		if x.Direction != mcp.ServerToClient || msg.Method != mcp.NotificationMessage {
+			return msg, nil
+		}
+
+		var p mcp.LoggingMessageParams
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			return msg, nil
+		}
+		text := string(p.Data)
+		var s string
+		if json.Unmarshal(p.Data, &s) == nil {
+			text = s
+		}
+		if p.Logger != "" {
+			text = p.Logger + ": " + text
+		}
+		serverLog.Write(logs.Entry{Source: "mcp", Level: p.Level, Text: text})
+		return msg, nil
+	})
+}
+
+// redactMiddleware masks the server's configured secrets, such as API
+// tokens, in everything it sends to the client.
+func redactMiddleware(_ *App, _ string, cfg *ServerConfig) mcp.Middleware {
+	var secrets []string
+	for _, values := range []map[string]string{cfg.Env, cfg.Headers} {
+		for key, value := range values {
+			if isSensitiveKey(key) && len(value) >= 8 {
+				secrets = append(secrets, value)
+			}
+		}
+	}
+
+	return mcp.MiddlewareFunc(func(x *mcp.Exchange, msg *mcp.Message) (*mcp.Message, error) {
+		if x.Direction != mcp.ServerToClient || len(secrets) == 0 {
+			return msg, nil
+		}
+
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return nil, err
+		}
+		redacted := string(data)
+		for _, secret := range secrets {
+			// Match the secret as it appears inside a JSON string.
+			quoted, _ := json.Marshal(secret)
+			redacted = strings.ReplaceAll(redacted, string(quoted[1:len(quoted)-1]), "[REDACTED]")
+		}
+		if redacted == string(data) {
+			return msg, nil
+		}
+
+		var out mcp.Message
+		if err := json.Unmarshal([]byte(redacted), &out); err != nil {
+			return nil, err
+		}
+		return &out, nil
+	})
+}
@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xenixo/mcp-adapter/internal/mcp"
+)
+
+const (
+	progressBarWidth = 30
+
+	// Servers may report progress far more often than it is worth
+	// redrawing; updates in between are folded into the next draw.
+	progressInterval      = 100 * time.Millisecond
+	progressPlainInterval = time.Second
+)
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// progressBar renders the progress a server reports for a request. On a
+// terminal it redraws a single line; otherwise it prints a line now and then.
+type progressBar struct {
+	out   io.Writer
+	tty   bool
+	label string
+	cols  int
+
+	mu     sync.Mutex
+	latest mcp.ProgressParams
+	drawn  time.Time
+	frame  int
+	width  int
+}
+
+// newProgressBar returns a progress bar writing to f, labelled with the
+// name of the operation.
+func newProgressBar(f *os.File, label string) *progressBar {
+	cols := 80
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 20 {
+		cols = n
+	}
+	return &progressBar{out: f, tty: isTerminal(f), label: label, cols: cols}
+}
+
+// isTerminal reports whether f is a character device such as a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Update records a progress notification and redraws the bar if enough
+// time has passed since the last draw. It is an mcp.ProgressFunc.
+func (b *progressBar) Update(p mcp.ProgressParams) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.latest = p
+	interval := progressInterval
+	if !b.tty {
+		interval = progressPlainInterval
+	}
+	complete := p.Total > 0 && p.Progress >= p.Total
+	if !complete && time.Since(b.drawn) < interval {
+		return
+	}
+	b.draw()
+}
+
+// Done clears the bar from the terminal.
+func (b *progressBar) Done() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.tty && b.width > 0 {
+		fmt.Fprintf(b.out, "\r%s\r", strings.Repeat(" ", b.width))
+		b.width = 0
+	}
+}
+
+func (b *progressBar) draw() {
+	b.drawn = time.Now()
+	line := b.render()
+	if !b.tty {
+		fmt.Fprintln(b.out, line)
+		return
+	}
+
+	if len([]rune(line)) > b.cols-1 {
+		line = string([]rune(line)[:b.cols-2]) + "…"
+	}
+	width := len([]rune(line))
+	pad := ""
+	if width < b.width {
+		pad = strings.Repeat(" ", b.width-width)
+	}
+	fmt.Fprintf(b.out, "\r%s%s", line, pad)
+	b.width = width
+}
+
+func (b *progressBar) render() string {
+	p := b.latest
+	var sb strings.Builder
+	sb.WriteString(b.label)
+
+	if p.Total > 0 {
+		fraction := p.Progress / p.Total
+		if fraction > 1 {
+			fraction = 1
+		}
+		if b.tty {
+			filled := int(fraction * progressBarWidth)
+			sb.WriteString(" [")
+			sb.WriteString(strings.Repeat("=", filled))
+			if filled < progressBarWidth {
+				sb.WriteString(">")
+				sb.WriteString(strings.Repeat(" ", progressBarWidth-filled-1))
+			}
+			sb.WriteString("]")
+		}
+		fmt.Fprintf(&sb, " %3.0f%% %s/%s", fraction*100, formatProgress(p.Progress), formatProgress(p.Total))
+	} else {
+		if b.tty {
+			sb.WriteString(" " + spinnerFrames[b.frame%len(spinnerFrames)])
+			b.frame++
+		}
+		sb.WriteString(" " + formatProgress(p.Progress))
+	}
+
+	if p.Message != "" {
+		sb.WriteString(" " + p.Message)
+	}
+	return sb.String()
+}
+
+func formatProgress(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
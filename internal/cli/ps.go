@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/xenixo/mcp-adapter/internal/supervisor"
+)
+
+func newPsCmd(app *App) *cobra.Command {
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:   "ps",
+		Short: "List background servers",
+		Long: `List the servers managed by the supervisor daemon with their state, process
+ID, uptime, last exit code, restart count and URL.
+
+A running server that fails its health checks is marked unhealthy. A
+crashed server waiting to be restarted is shown in the backoff state with
+the time until its restart. One that crashed too often to be restarted is
+shown as degraded.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPs(app, jsonOutput)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&jsonOutput, "json", "j", false, "Output in JSON format")
+
+	return cmd
+}
+
+func runPs(app *App, jsonOutput bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var servers []supervisor.Status
+	client, err := daemonClient(ctx, app, false)
+	if err == nil {
+		servers, err = client.List(ctx)
+	}
+	if err != nil && !errors.Is(err, supervisor.ErrDaemonNotRunning) {
+		return err
+	}
+
+	if jsonOutput {
+		if servers == nil {
+			servers = []supervisor.Status{}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(servers)
+	}
+
+	if len(servers) == 0 {
+		fmt.Println("No background servers.")
+		fmt.Println()
+		fmt.Println("Use 'mcp-adapter start <server>' to start one.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTATE\tPID\tUPTIME\tEXIT\tRESTARTS\tURL")
+	fmt.Fprintln(w, "----\t-----\t---\t------\t----\t--------\t---")
+	for _, s := range servers {
+		state, pid, uptime, exit := string(s.State), "-", "-", "-"
+		if s.PID > 0 {
+			pid = strconv.Itoa(s.PID)
+		}
+		if d := s.Uptime(); d > 0 {
+			uptime = formatUptime(d)
+		}
+		if s.Health == "unhealthy" {
+			state += " (unhealthy)"
+		}
+		if s.NextRestart != nil {
+			state += " (" + formatUptime(max(time.Until(*s.NextRestart), 0)) + ")"
+		}
+		if s.StopTime != nil || s.State == supervisor.StateBackoff {
+			exit = strconv.Itoa(s.ExitCode)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%s\n", s.Name, state, pid, uptime, exit, s.Restarts, s.URL)
+	}
+	w.Flush()
+
+	for _, s := range servers {
+		if s.State != supervisor.StateStopped && s.Error != "" {
+			fmt.Printf("\n%s: %s\n", s.Name, s.Error)
+		}
+	}
+	return nil
+}
+
+// formatUptime renders a duration compactly, such as 3d4h or 12m5s.
+func formatUptime(d time.Duration) string {
+	d = d.Round(time.Second)
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd%dh", d/(24*time.Hour), d%(24*time.Hour)/time.Hour)
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh%dm", d/time.Hour, d%time.Hour/time.Minute)
+	default:
+		return d.String()
+	}
+}
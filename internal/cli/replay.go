@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/xenixo/mcp-adapter/internal/launcher"
+	"github.com/xenixo/mcp-adapter/internal/mcp"
+)
+
+func newReplayCmd(app *App) *cobra.Command {
+	var (
+		args    []string
+		envVars []string
+		ignore  []string
+		timeout time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "replay <server> <recording> [-- args...]",
+		Short: "Replay a recorded session against a server",
+		Long: `Replay the client side of a session recorded with 'mcp-adapter run --record'
+against a server and compare its responses with the recorded ones.
+
+The command fails if any response differs, so recordings can serve as
+regression tests when upgrading a server. Use --ignore to skip fields that
+are expected to change.
+
+Example:
+  mcp-adapter run filesystem --record session.jsonl -- /tmp
+  mcp-adapter replay filesystem session.jsonl --ignore result.serverInfo.version -- /tmp`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, cmdArgs []string) error {
+			if len(cmdArgs) > 2 {
+				args = append(args, cmdArgs[2:]...)
+			}
+			return runReplay(app, cmdArgs[0], cmdArgs[1], args, envVars, ignore, timeout)
+		},
+	}
+
+	cmd.Flags().StringArrayVarP(&args, "arg", "a", nil, "Additional arguments to pass to the server")
+	cmd.Flags().StringArrayVarP(&envVars, "env", "e", nil, "Environment variables (KEY=VALUE)")
+	cmd.Flags().StringArrayVar(&ignore, "ignore", nil, "Response path to ignore when comparing (repeatable)")
+	cmd.Flags().DurationVar(&timeout, "timeout", 30*time.Second, "Time to wait for each response")
+
+	return cmd
+}
+
+func runReplay(app *App, serverName, recordingPath string, args, envVars, ignore []string, timeout time.Duration) error {
+	f, err := os.Open(recordingPath)
+	if err != nil {
+		return fmt.Errorf("failed to open recording: %w", err)
+	}
+	recording, err := mcp.ReadRecording(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read recording %s: %w", recordingPath, err)
+	}
+
+	server, err := findInstalledServer(app, serverName)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	launcherInst := launcher.NewLauncher(app.Config, app.Logger)
+	defer launcherInst.StopAll(stopTimeout)
+
+	opts, err := buildLaunchOptions(app, serverName, args, envVars)
+	if err != nil {
+		return err
+	}
+	backend, err := openServer(ctx, app, launcherInst, server, opts)
+	if err != nil {
+		return err
+	}
+	transport := withMiddleware(backend, withLogCapture(app, serverName, nil))
+	defer transport.Close()
+
+	results, err := mcp.Replay(ctx, transport, recording, &mcp.ReplayOptions{
+		Timeout: timeout,
+		Ignore:  ignore,
+	})
+	if err != nil {
+		return fmt.Errorf("replay failed: %w", err)
+	}
+
+	failed := 0
+	for _, r := range results {
+		if len(r.Diffs) == 0 {
+			fmt.Printf("✓ %s (id %v)\n", r.Method, r.ID)
+			continue
+		}
+		failed++
+		fmt.Printf("✗ %s (id %v)\n", r.Method, r.ID)
+		for _, d := range r.Diffs {
+			fmt.Printf("    %s\n", d)
+		}
+	}
+
+	fmt.Println()
+	fmt.Printf("%d of %d responses matched\n", len(results)-failed, len(results))
+
+	if failed > 0 {
+		return fmt.Errorf("%d responses differ from the recording", failed)
+	}
+	return nil
+}
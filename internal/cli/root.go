@@ -2,6 +2,7 @@
 package cli
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/spf13/cobra"
@@ -100,6 +101,19 @@ Features:
 	rootCmd.AddCommand(newListCmd(app))
 	rootCmd.AddCommand(newInstallCmd(app))
 	rootCmd.AddCommand(newRunCmd(app))
+	rootCmd.AddCommand(newServeCmd(app))
+	rootCmd.AddCommand(newGatewayCmd(app))
+	rootCmd.AddCommand(newStartCmd(app))
+	rootCmd.AddCommand(newStopCmd(app))
+	rootCmd.AddCommand(newRestartCmd(app))
+	rootCmd.AddCommand(newPsCmd(app))
+	rootCmd.AddCommand(newLogsCmd(app))
+	rootCmd.AddCommand(newDaemonCmd(app))
+	rootCmd.AddCommand(newReplayCmd(app))
+	rootCmd.AddCommand(newInspectCmd(app))
+	rootCmd.AddCommand(newCallCmd(app))
+	rootCmd.AddCommand(newShellCmd(app))
+	rootCmd.AddCommand(newTestCmd(app))
 	rootCmd.AddCommand(newDoctorCmd(app))
 	rootCmd.AddCommand(newUninstallCmd(app))
 	rootCmd.AddCommand(newRegistryCmd(app))
@@ -111,6 +125,7 @@ Features:
 // Execute runs the CLI.
 func Execute() {
 	if err := NewRootCmd().Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
 		os.Exit(1)
 	}
 }
@@ -2,23 +2,20 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/signal"
-	"path/filepath"
 	"syscall"
-	"time"
 
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 
-	"github.com/xenixo/mcp-adapter/internal/installer"
 	"github.com/xenixo/mcp-adapter/internal/launcher"
 	"github.com/xenixo/mcp-adapter/internal/manifest"
-	"github.com/xenixo/mcp-adapter/internal/registry"
+	"github.com/xenixo/mcp-adapter/internal/mcp"
 	"github.com/xenixo/mcp-adapter/internal/runtime"
-	"github.com/xenixo/mcp-adapter/manifests"
 )
 
 func newRunCmd(app *App) *cobra.Command {
@@ -26,6 +23,7 @@ func newRunCmd(app *App) *cobra.Command {
 		args    []string
 		envVars []string
 		stdio   bool
+		record  string
 	)
 
 	cmd := &cobra.Command{
@@ -37,47 +35,38 @@ This command launches the specified MCP server and manages its lifecycle.
 For stdio transport, stdin/stdout are connected to the server for MCP
 communication.
 
-The server must be installed before running. Use 'mcp-adapter install' first.`,
+The server must be installed before running. Use 'mcp-adapter install' first.
+
+With --record, every JSON-RPC message is written to a JSONL file with its
+direction and timestamp. Use 'mcp-adapter replay' to play it back.`,
 		Args: cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, cmdArgs []string) error {
 			serverName := cmdArgs[0]
 			if len(cmdArgs) > 1 {
 				args = append(args, cmdArgs[1:]...)
 			}
-			return runServer(app, serverName, args, envVars, stdio)
+			return runServer(app, serverName, args, envVars, stdio, record)
 		},
 	}
 
 	cmd.Flags().StringArrayVarP(&args, "arg", "a", nil, "Additional arguments to pass to the server")
 	cmd.Flags().StringArrayVarP(&envVars, "env", "e", nil, "Environment variables (KEY=VALUE)")
 	cmd.Flags().BoolVar(&stdio, "stdio", true, "Connect stdio for MCP communication")
+	cmd.Flags().StringVar(&record, "record", "", "Record the session's messages to a JSONL file")
 
 	return cmd
 }
 
-func runServer(app *App, serverName string, args, envVars []string, stdio bool) error {
-	// Load registry
-	reg := registry.New()
-
-	if err := reg.LoadFromEmbed(manifests.FS, "*.yaml"); err != nil {
-		return fmt.Errorf("failed to load embedded manifests: %w", err)
-	}
-
-	userManifestsDir := filepath.Join(app.Config.BaseDir, "manifests")
-	if err := reg.LoadFromDirectory(userManifestsDir); err != nil {
-		app.Logger.Debug("user manifests not loaded", zap.Error(err))
-	}
-
+func runServer(app *App, serverName string, args, envVars []string, stdio bool, record string) error {
 	// Find server
-	server, ok := reg.Get(serverName)
-	if !ok {
-		return fmt.Errorf("server %q not found in registry", serverName)
+	server, err := findInstalledServer(app, serverName)
+	if err != nil {
+		return err
 	}
 
-	// Check if installed
-	installDir := app.Config.ServerInstallPath(serverName)
-	if !installer.IsInstalled(installDir) {
-		return fmt.Errorf("server %q is not installed; run 'mcp-adapter install %s' first", serverName, serverName)
+	// Remote servers are bridged rather than launched
+	if server.IsRemote() {
+		return runRemoteBridge(app, server, record)
 	}
 
 	// Validate runtime
@@ -100,34 +89,18 @@ func runServer(app *App, serverName string, args, envVars []string, stdio bool)
 		return fmt.Errorf("runtime version %s does not meet requirement %s", rt.Version, versionReq)
 	}
 
-	// Load saved configuration
-	savedConfig, err := GetServerConfig(app, serverName)
+	// Merge saved configuration with command line args and env vars
+	opts, err := buildLaunchOptions(app, serverName, args, envVars)
 	if err != nil {
-		app.Logger.Debug("failed to load server config", zap.Error(err))
+		return err
 	}
-
-	// Merge environment variables: saved config < command line
-	env := make(map[string]string)
-	
-	// First, apply saved config
-	if savedConfig != nil {
-		for k, v := range savedConfig.Env {
-			env[k] = v
-		}
-		// Prepend saved args
-		if len(savedConfig.Args) > 0 {
-			args = append(savedConfig.Args, args...)
-		}
+	stdioOpts, err := stdioOptions(app, server)
+	if err != nil {
+		return err
 	}
-
-	// Then, apply command line env vars (override saved)
-	for _, e := range envVars {
-		for i, c := range e {
-			if c == '=' {
-				env[e[:i]] = e[i+1:]
-				break
-			}
-		}
+	health, err := healthOptions(app, serverName)
+	if err != nil {
+		return err
 	}
 
 	// Set up signal handling
@@ -136,21 +109,36 @@ func runServer(app *App, serverName string, args, envVars []string, stdio bool)
 
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
 
 	// Create launcher
 	launcherInst := launcher.NewLauncher(app.Config, app.Logger)
 
-	// Configure launch options
-	opts := &launcher.LaunchOptions{
-		Args: args,
-		Env:  env,
+	// For stdio transport, connect stdin/stdout directly unless messages
+	// must pass through the proxy to be recorded or intercepted
+	if record != "" && (!stdio || server.Transport != manifest.TransportStdio) {
+		return fmt.Errorf("--record requires a stdio server and --stdio")
+	}
+	chain, closeRecording, err := proxyChain(app, serverName, record)
+	if err != nil {
+		return err
+	}
+	defer closeRecording()
+	// Messages are relayed through a proxy when there is middleware to run
+	// or the server's framing must be translated for the client.
+	proxied := stdio && server.Transport == manifest.TransportStdio &&
+		(len(chain) > 0 || server.Framing == manifest.FramingContentLength)
+	if proxied {
+		// The proxy decodes every message, so it captures the server's log
+		// messages too.
+		chain = withLogCapture(app, serverName, chain)
 	}
-
-	// For stdio transport, connect stdin/stdout directly
 	if stdio && server.Transport == manifest.TransportStdio {
-		opts.Stdin = os.Stdin
-		opts.Stdout = os.Stdout
 		opts.Stderr = os.Stderr
+		if !proxied {
+			opts.Stdin = os.Stdin
+			opts.Stdout = os.Stdout
+		}
 	}
 
 	// Launch server
@@ -165,6 +153,32 @@ func runServer(app *App, serverName string, args, envVars []string, stdio bool)
 		return fmt.Errorf("failed to launch server: %w", err)
 	}
 
+	// HTTP servers are ready once their endpoint answers.
+	notReady := make(chan error, 1)
+	if server.Transport == manifest.TransportHTTP && server.Endpoint != "" {
+		check := launcher.HTTPCheck(server.Endpoint)
+		go func() {
+			if err := launcherInst.WaitReady(ctx, proc, check, health); err != nil {
+				notReady <- err
+				return
+			}
+			launcherInst.MonitorHealth(ctx, serverName, proc, check, health)
+		}()
+	}
+
+	if proxied {
+		go func() {
+			proxy := mcp.NewProxy(
+				mcp.NewStdioTransport(os.Stdin, os.Stdout),
+				mcp.NewStdioTransportWithOptions(proc.Stdout, proc.Stdin, stdioOpts),
+			)
+			proxy.Use(chain...)
+			proxy.Run()
+			// Closing the server's stdin asks it to exit
+			proc.Stdin.Close()
+		}()
+	}
+
 	// For non-stdio, stream output
 	if !stdio || server.Transport != manifest.TransportStdio {
 		if proc.Stdout != nil {
@@ -176,15 +190,15 @@ func runServer(app *App, serverName string, args, envVars []string, stdio bool)
 	}
 
 	// Wait for signal or process exit
-	go func() {
-		sig := <-sigChan
+	select {
+	case sig := <-sigChan:
 		app.Logger.Info("received signal", zap.String("signal", sig.String()))
-		launcherInst.Stop(serverName, 10*time.Second)
-		cancel()
-	}()
-
-	// Wait for process to exit
-	<-ctx.Done()
+		launcherInst.Stop(serverName, stopTimeout)
+	case err := <-notReady:
+		launcherInst.Stop(serverName, stopTimeout)
+		return err
+	case <-proc.Done():
+	}
 
 	// Get final state
 	if p, ok := launcherInst.Get(serverName); ok {
@@ -198,3 +212,74 @@ func runServer(app *App, serverName string, args, envVars []string, stdio bool)
 
 	return nil
 }
+
+// runRemoteBridge connects our stdio to a remote HTTP server so that clients
+// which only speak stdio can use it.
+func runRemoteBridge(app *App, server *manifest.Server, record string) error {
+	remote, err := dialRemote(app, server)
+	if err != nil {
+		return err
+	}
+	defer remote.Close()
+
+	local := mcp.NewStdioTransport(os.Stdin, os.Stdout)
+
+	app.Logger.Info("bridging stdio to remote server",
+		zap.String("server", server.Name),
+		zap.String("url", server.Source.Remote),
+		zap.String("flavor", string(server.EffectiveHTTPFlavor())),
+	)
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+
+	chain, closeRecording, err := proxyChain(app, server.Name, record)
+	if err != nil {
+		return err
+	}
+	defer closeRecording()
+
+	proxy := mcp.NewProxy(local, remote)
+	proxy.Use(withLogCapture(app, server.Name, chain)...)
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- proxy.Run()
+	}()
+
+	select {
+	case sig := <-sigChan:
+		app.Logger.Info("received signal", zap.String("signal", sig.String()))
+		return nil
+	case err := <-errChan:
+		// The client closing stdin ends the session normally
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return fmt.Errorf("bridge to %s failed: %w", server.Name, err)
+	}
+}
+
+// proxyChain builds the middleware for a run session: a recorder writing to
+// the record file, if given, followed by the server's configured middleware.
+// An empty chain needs no proxy. The returned function closes the recording.
+func proxyChain(app *App, serverName, record string) ([]mcp.Middleware, func(), error) {
+	chain, err := configuredMiddleware(app, serverName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if record == "" {
+		return chain, func() {}, nil
+	}
+
+	f, err := os.OpenFile(record, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create recording: %w", err)
+	}
+	app.Logger.Info("recording session", zap.String("file", record))
+
+	// The recorder goes first so it sees exactly what the client sent and received
+	chain = append([]mcp.Middleware{mcp.NewRecorder(f)}, chain...)
+	return chain, func() { f.Close() }, nil
+}
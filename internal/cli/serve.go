@@ -0,0 +1,166 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/xenixo/mcp-adapter/internal/launcher"
+	"github.com/xenixo/mcp-adapter/internal/mcp"
+)
+
+func newServeCmd(app *App) *cobra.Command {
+	var (
+		addr    string
+		path    string
+		shared  bool
+		args    []string
+		envVars []string
+		hosts   []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve <server> [-- args...]",
+		Short: "Expose a stdio MCP server over HTTP",
+		Long: `Expose an installed stdio MCP server over the Streamable HTTP transport.
+
+Each HTTP session gets its own server process by default. With --shared, all
+sessions are multiplexed onto a single process.
+
+Example:
+  mcp-adapter serve filesystem --http 127.0.0.1:8080 -- /path/to/dir`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, cmdArgs []string) error {
+			if len(cmdArgs) > 1 {
+				args = append(args, cmdArgs[1:]...)
+			}
+			return runServe(app, cmdArgs[0], addr, path, shared, args, envVars, hosts)
+		},
+	}
+
+	cmd.Flags().StringVar(&addr, "http", "127.0.0.1:8080", "Address to serve Streamable HTTP on")
+	cmd.Flags().StringVar(&path, "path", "/mcp", "URL path of the MCP endpoint")
+	cmd.Flags().StringArrayVar(&hosts, "allow-host", nil, "Host name besides loopback the endpoint may be reached by")
+	cmd.Flags().BoolVar(&shared, "shared", false, "Share one server process between all sessions")
+	cmd.Flags().StringArrayVarP(&args, "arg", "a", nil, "Additional arguments to pass to the server")
+	cmd.Flags().StringArrayVarP(&envVars, "env", "e", nil, "Environment variables (KEY=VALUE)")
+
+	return cmd
+}
+
+func runServe(app *App, serverName, addr, path string, shared bool, args, envVars, hosts []string) error {
+	server, err := findInstalledServer(app, serverName)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	launcherInst := launcher.NewLauncher(app.Config, app.Logger)
+	defer launcherInst.StopAll(stopTimeout)
+
+	opts, err := buildLaunchOptions(app, serverName, args, envVars)
+	if err != nil {
+		return err
+	}
+	stdioOpts, err := stdioOptions(app, server)
+	if err != nil {
+		return err
+	}
+	chain, err := serverMiddleware(app, serverName)
+	if err != nil {
+		return err
+	}
+
+	var newSession mcp.SessionFactory
+	if shared {
+		backend, err := launchStdio(ctx, app, launcherInst, server, opts)
+		if err != nil {
+			return err
+		}
+		mux := mcp.NewMultiplexer(withMiddleware(backend, chain))
+		defer mux.Close()
+		if _, err := superviseHealth(ctx, app, launcherInst, serverName, backend.proc, mux); err != nil {
+			return err
+		}
+
+		newSession = func(context.Context) (mcp.Transport, error) {
+			select {
+			case <-mux.Done():
+				return nil, fmt.Errorf("server %q has exited", serverName)
+			default:
+				return mux.Session(), nil
+			}
+		}
+	} else {
+		var instances int64
+		newSession = func(context.Context) (mcp.Transport, error) {
+			sessionOpts := *opts
+			sessionOpts.Instance = strconv.FormatInt(atomic.AddInt64(&instances, 1), 10)
+			backend, err := launchStdio(ctx, app, launcherInst, server, &sessionOpts)
+			if err != nil {
+				return nil, err
+			}
+			return withMiddleware(backend, chain), nil
+		}
+	}
+
+	handler := mcp.NewHTTPHandlerWithOptions(newSession, &mcp.HTTPHandlerOptions{
+		AllowedHosts:   hosts,
+		MaxMessageSize: stdioOpts.MaxMessageSize,
+	})
+	defer handler.Close()
+
+	mode := "per-session"
+	if shared {
+		mode = "shared"
+	}
+	app.Logger.Info("serving MCP over HTTP",
+		zap.String("server", serverName),
+		zap.String("url", "http://"+addr+path),
+		zap.String("mode", mode),
+	)
+	fmt.Fprintf(os.Stderr, "Serving %s at http://%s%s (%s process)\n", serverName, addr, path, mode)
+
+	return serveHTTP(app, handler, addr, path)
+}
+
+// serveHTTP serves an MCP handler at addr and path until SIGINT or SIGTERM.
+func serveHTTP(app *App, handler http.Handler, addr, path string) error {
+	routes := http.NewServeMux()
+	routes.Handle(path, handler)
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           routes,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+
+	go func() {
+		sig := <-sigChan
+		app.Logger.Info("received signal", zap.String("signal", sig.String()))
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer shutdownCancel()
+		httpServer.Shutdown(shutdownCtx)
+	}()
+
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("http server failed: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,305 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/xenixo/mcp-adapter/internal/installer"
+	"github.com/xenixo/mcp-adapter/internal/launcher"
+	"github.com/xenixo/mcp-adapter/internal/manifest"
+	"github.com/xenixo/mcp-adapter/internal/mcp"
+	"github.com/xenixo/mcp-adapter/internal/registry"
+	"github.com/xenixo/mcp-adapter/manifests"
+)
+
+// stopTimeout is how long a server gets to exit after SIGTERM.
+const stopTimeout = 10 * time.Second
+
+// loadRegistry loads the embedded manifests and the user's manifests.
+func loadRegistry(app *App) (*registry.Registry, error) {
+	reg := registry.New()
+
+	if err := reg.LoadFromEmbed(manifests.FS, "*.yaml"); err != nil {
+		return nil, fmt.Errorf("failed to load embedded manifests: %w", err)
+	}
+
+	userManifestsDir := filepath.Join(app.Config.BaseDir, "manifests")
+	if err := reg.LoadFromDirectory(userManifestsDir); err != nil {
+		app.Logger.Debug("user manifests not loaded", zap.Error(err))
+	}
+
+	return reg, nil
+}
+
+// findInstalledServer looks up a server in the registry and checks that it is
+// installed. Remote servers need no installation.
+func findInstalledServer(app *App, serverName string) (*manifest.Server, error) {
+	reg, err := loadRegistry(app)
+	if err != nil {
+		return nil, err
+	}
+
+	server, ok := reg.Get(serverName)
+	if !ok {
+		return nil, fmt.Errorf("server %q not found in registry", serverName)
+	}
+
+	if server.IsRemote() {
+		return server, nil
+	}
+
+	installDir := app.Config.ServerInstallPath(serverName)
+	if !installer.IsInstalled(installDir) {
+		return nil, fmt.Errorf("server %q is not installed; run 'mcp-adapter install %s' first", serverName, serverName)
+	}
+
+	return server, nil
+}
+
+// buildLaunchOptions merges the saved server configuration with command-line
+// arguments and environment variables (KEY=VALUE), which take precedence.
+// A configuration that cannot be loaded is an error rather than ignored, as
+// it may hold the server's sandbox and resource limits.
+func buildLaunchOptions(app *App, serverName string, args, envVars []string) (*launcher.LaunchOptions, error) {
+	savedConfig, err := GetServerConfig(app, serverName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config for server %q: %w", serverName, err)
+	}
+
+	env := make(map[string]string)
+	for k, v := range savedConfig.Env {
+		env[k] = v
+	}
+	if len(savedConfig.Args) > 0 {
+		args = append(append([]string{}, savedConfig.Args...), args...)
+	}
+
+	for _, e := range envVars {
+		if k, v, ok := strings.Cut(e, "="); ok {
+			env[k] = v
+		}
+	}
+
+	return &launcher.LaunchOptions{
+		Args:    args,
+		Env:     env,
+		Sandbox: savedConfig.Sandbox,
+		Network: savedConfig.Network,
+		Seccomp: savedConfig.Seccomp,
+		Limits:  savedConfig.Limits,
+	}, nil
+}
+
+// stdioOptions configures the transport to a launched stdio server: the
+// framing declared in its manifest, the message size limit from its saved
+// configuration, and logging of stray output such as startup banners.
+func stdioOptions(app *App, server *manifest.Server) (*mcp.StdioOptions, error) {
+	savedConfig, err := GetServerConfig(app, server.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config for server %q: %w", server.Name, err)
+	}
+
+	opts := &mcp.StdioOptions{
+		MaxMessageSize: savedConfig.MaxMessageSize,
+		OnStrayLine: func(line []byte) {
+			app.Logger.Warn("ignoring non-JSON output from server",
+				zap.String("server", server.Name),
+				zap.ByteString("line", line),
+			)
+		},
+	}
+	if server.Framing == manifest.FramingContentLength {
+		opts.Framing = mcp.FramingContentLength
+	}
+	return opts, nil
+}
+
+// healthOptions returns the health check options saved for a server, or
+// nil for the defaults.
+func healthOptions(app *App, serverName string) (*launcher.HealthOptions, error) {
+	savedConfig, err := GetServerConfig(app, serverName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config for server %q: %w", serverName, err)
+	}
+	return savedConfig.Health, nil
+}
+
+// processTransport speaks MCP over a launched server's stdio and stops the
+// server when closed. The process is marked ready once it answers an
+// initialize request.
+type processTransport struct {
+	*mcp.StdioTransport
+	launcher *launcher.Launcher
+	proc     *launcher.Process
+
+	mu     sync.Mutex
+	initID string
+}
+
+// Send sends a message to the server, noting initialize requests.
+func (t *processTransport) Send(msg *mcp.Message) error {
+	if msg.Method == mcp.MethodInitialize && msg.ID != nil {
+		id, _ := json.Marshal(msg.ID)
+		t.mu.Lock()
+		t.initID = string(id)
+		t.mu.Unlock()
+	}
+	return t.StdioTransport.Send(msg)
+}
+
+// Receive receives a message from the server, watching for the answer to
+// initialize.
+func (t *processTransport) Receive() (*mcp.Message, error) {
+	msg, err := t.StdioTransport.Receive()
+	if err == nil && msg.IsResponse() && msg.Error == nil {
+		id, _ := json.Marshal(msg.ID)
+		t.mu.Lock()
+		ready := t.initID != "" && t.initID == string(id)
+		if ready {
+			t.initID = ""
+		}
+		t.mu.Unlock()
+		if ready {
+			t.proc.MarkReady()
+		}
+	}
+	return msg, err
+}
+
+// Close stops the server process.
+func (t *processTransport) Close() error {
+	err := t.launcher.Stop(t.proc.Key(), stopTimeout)
+	select {
+	case <-t.proc.Done():
+		return nil
+	default:
+		return err
+	}
+}
+
+// launchStdio launches a stdio server with piped stdin/stdout and returns a
+// transport connected to it. Server stderr is forwarded to our stderr.
+func launchStdio(ctx context.Context, app *App, l *launcher.Launcher, server *manifest.Server, opts *launcher.LaunchOptions) (*processTransport, error) {
+	if server.Transport != manifest.TransportStdio {
+		return nil, fmt.Errorf("server %q uses %s transport, not stdio", server.Name, server.Transport)
+	}
+
+	stdioOpts, err := stdioOptions(app, server)
+	if err != nil {
+		return nil, err
+	}
+
+	launchOpts := *opts
+	launchOpts.Stdin = nil
+	launchOpts.Stdout = nil
+	if launchOpts.Stderr == nil {
+		launchOpts.Stderr = os.Stderr
+	}
+
+	proc, err := l.Launch(ctx, server, &launchOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to launch server: %w", err)
+	}
+
+	return &processTransport{
+		StdioTransport: mcp.NewStdioTransportWithOptions(proc.Stdout, proc.Stdin, stdioOpts),
+		launcher:       l,
+		proc:           proc,
+	}, nil
+}
+
+// dialRemote creates a client transport for a remote server, using the HTTP
+// flavor declared in its manifest and any headers saved in its configuration.
+func dialRemote(app *App, server *manifest.Server) (mcp.Transport, error) {
+	if !server.IsRemote() {
+		return nil, fmt.Errorf("server %q is not a remote server", server.Name)
+	}
+
+	savedConfig, err := GetServerConfig(app, server.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config for server %q: %w", server.Name, err)
+	}
+	opts := &mcp.HTTPTransportOptions{Headers: savedConfig.Headers}
+
+	switch server.EffectiveHTTPFlavor() {
+	case manifest.HTTPFlavorSSE:
+		return mcp.NewSSETransport(server.Source.Remote, opts), nil
+	default:
+		return mcp.NewStreamableHTTPTransport(server.Source.Remote, opts), nil
+	}
+}
+
+// openServer launches a local server or dials a remote one and returns a
+// transport connected to it.
+func openServer(ctx context.Context, app *App, l *launcher.Launcher, server *manifest.Server, opts *launcher.LaunchOptions) (mcp.Transport, error) {
+	if server.IsRemote() {
+		return dialRemote(app, server)
+	}
+	return launchStdio(ctx, app, l, server, opts)
+}
+
+// connectClient opens a server, puts the middleware chain in front of it
+// and performs the initialize handshake.
+func connectClient(ctx context.Context, app *App, l *launcher.Launcher, server *manifest.Server, opts *launcher.LaunchOptions, chain []mcp.Middleware) (*mcp.Client, error) {
+	health, err := healthOptions(app, server.Name)
+	if err != nil {
+		return nil, err
+	}
+	transport, err := openServer(ctx, app, l, server, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	client := mcp.NewClient(withMiddleware(transport, chain), &mcp.ClientOptions{
+		Info: mcp.Implementation{Name: "mcp-adapter", Version: Version},
+	})
+
+	initCtx, cancel := context.WithTimeout(ctx, health.WithDefaults().StartupTimeout)
+	defer cancel()
+	if _, err := client.Initialize(initCtx); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// superviseHealth waits until a launched server shared through mux completes
+// the initialize handshake, then pings it periodically until ctx is done or
+// the server exits. The checks use a private session, so the server's own
+// requests still reach the real clients.
+func superviseHealth(ctx context.Context, app *App, l *launcher.Launcher, serverName string, proc *launcher.Process, mux *mcp.Multiplexer) (*launcher.HealthMonitor, error) {
+	opts, err := healthOptions(app, serverName)
+	if err != nil {
+		return nil, err
+	}
+	client := mcp.NewClient(mux.PrivateSession(), &mcp.ClientOptions{
+		Info: mcp.Implementation{Name: "mcp-adapter", Version: Version},
+	})
+
+	err = l.WaitReady(ctx, proc, func(ctx context.Context) error {
+		_, err := client.Initialize(ctx)
+		return err
+	}, opts)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-proc.Done():
+		}
+		client.Close()
+	}()
+	return l.MonitorHealth(ctx, serverName, proc, client.Ping, opts), nil
+}
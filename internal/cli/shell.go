@@ -0,0 +1,514 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/xenixo/mcp-adapter/internal/launcher"
+	"github.com/xenixo/mcp-adapter/internal/mcp"
+)
+
+const shellHelp = `Commands:
+  info                      Show server info and capabilities
+  tools                     List tools
+  call <tool> [args]        Call a tool; args are a JSON object or key=value pairs
+  resources                 List resources
+  templates                 List resource templates
+  read <uri>                Read a resource
+  subscribe <uri>           Subscribe to resource updates
+  unsubscribe <uri>         Cancel a resource subscription
+  prompts                   List prompts
+  prompt <name> [args]      Get a prompt; args are a JSON object or key=value pairs
+  loglevel <level>          Set the server's logging level
+  ping                      Ping the server
+  help                      Show this help
+  quit                      Exit the shell
+
+Press Ctrl-C to cancel a running request.`
+
+func newShellCmd(app *App) *cobra.Command {
+	var (
+		args    []string
+		envVars []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "shell <server> [-- args...]",
+		Short: "Open an interactive MCP session with a server",
+		Long: `Launch a server and open an interactive session to explore it: list and call
+tools, read and subscribe to resources, and render prompts. Notifications
+(logging, progress, list changes) are shown as they arrive.
+
+Example:
+  mcp-adapter shell filesystem -- /tmp`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, cmdArgs []string) error {
+			if len(cmdArgs) > 1 {
+				args = append(args, cmdArgs[1:]...)
+			}
+			return runShell(app, cmdArgs[0], args, envVars)
+		},
+	}
+
+	cmd.Flags().StringArrayVarP(&args, "arg", "a", nil, "Additional arguments to pass to the server")
+	cmd.Flags().StringArrayVarP(&envVars, "env", "e", nil, "Environment variables (KEY=VALUE)")
+
+	return cmd
+}
+
+// shell is an interactive session with one server.
+type shell struct {
+	client *mcp.Client
+	name   string
+
+	// out serializes command output with asynchronous notifications.
+	mu  sync.Mutex
+	out io.Writer
+
+	cancelMu sync.Mutex
+	cancel   context.CancelFunc
+}
+
+func runShell(app *App, serverName string, args, envVars []string) error {
+	server, err := findInstalledServer(app, serverName)
+	if err != nil {
+		return err
+	}
+
+	chain, err := serverMiddleware(app, serverName)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	launcherInst := launcher.NewLauncher(app.Config, app.Logger)
+	defer launcherInst.StopAll(stopTimeout)
+
+	opts, err := buildLaunchOptions(app, serverName, args, envVars)
+	if err != nil {
+		return err
+	}
+	client, err := connectClient(ctx, app, launcherInst, server, opts, chain)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", serverName, err)
+	}
+	defer client.Close()
+
+	sh := &shell{client: client, name: serverName, out: os.Stdout}
+	client.OnNotification("", sh.notify)
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT)
+	defer signal.Stop(sigChan)
+	go func() {
+		for range sigChan {
+			if !sh.interrupt() {
+				sh.printf("\n(type quit to exit)\n%s> ", serverName)
+			}
+		}
+	}()
+
+	info := client.ServerInfo()
+	sh.printf("Connected to %s %s (protocol %s). Type help for commands.\n", info.Name, info.Version, client.ProtocolVersion())
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(os.Stdin)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+
+	for {
+		sh.printf("%s> ", serverName)
+
+		var line string
+		select {
+		case l, ok := <-lines:
+			if !ok {
+				sh.printf("\n")
+				return nil
+			}
+			line = l
+		case <-client.Done():
+			sh.printf("\n")
+			return fmt.Errorf("server %s disconnected: %v", serverName, client.Err())
+		}
+
+		if quit := sh.exec(ctx, strings.TrimSpace(line)); quit {
+			return nil
+		}
+	}
+}
+
+func (sh *shell) printf(format string, args ...interface{}) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	fmt.Fprintf(sh.out, format, args...)
+}
+
+// interrupt cancels the running request, reporting whether there was one.
+func (sh *shell) interrupt() bool {
+	sh.cancelMu.Lock()
+	defer sh.cancelMu.Unlock()
+	if sh.cancel == nil {
+		return false
+	}
+	sh.cancel()
+	return true
+}
+
+// exec runs one command line and reports whether the shell should exit.
+func (sh *shell) exec(parent context.Context, line string) bool {
+	if line == "" {
+		return false
+	}
+	command, rest, _ := strings.Cut(line, " ")
+	rest = strings.TrimSpace(rest)
+
+	ctx, cancel := context.WithCancel(parent)
+	sh.cancelMu.Lock()
+	sh.cancel = cancel
+	sh.cancelMu.Unlock()
+	defer func() {
+		sh.cancelMu.Lock()
+		sh.cancel = nil
+		sh.cancelMu.Unlock()
+		cancel()
+	}()
+
+	var err error
+	switch command {
+	case "quit", "exit":
+		return true
+	case "help", "?":
+		sh.printf("%s\n", shellHelp)
+	case "info":
+		sh.info()
+	case "tools":
+		err = sh.tools(ctx)
+	case "call":
+		err = sh.call(ctx, rest)
+	case "resources":
+		err = sh.resources(ctx)
+	case "templates":
+		err = sh.templates(ctx)
+	case "read":
+		err = sh.read(ctx, rest)
+	case "subscribe":
+		err = sh.requireArg("uri", rest, func() error { return sh.client.Subscribe(ctx, rest) })
+	case "unsubscribe":
+		err = sh.requireArg("uri", rest, func() error { return sh.client.Unsubscribe(ctx, rest) })
+	case "prompts":
+		err = sh.prompts(ctx)
+	case "prompt":
+		err = sh.prompt(ctx, rest)
+	case "loglevel":
+		err = sh.requireArg("level", rest, func() error {
+			return sh.client.Call(ctx, mcp.MethodLoggingSetLevel, map[string]string{"level": rest}, nil)
+		})
+	case "ping":
+		err = sh.client.Ping(ctx)
+		if err == nil {
+			sh.printf("pong\n")
+		}
+	default:
+		sh.printf("unknown command %q (type help for commands)\n", command)
+	}
+
+	if err != nil {
+		sh.printf("error: %v\n", err)
+	}
+	return false
+}
+
+func (sh *shell) requireArg(name, value string, fn func() error) error {
+	if value == "" {
+		return fmt.Errorf("missing %s", name)
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+	sh.printf("ok\n")
+	return nil
+}
+
+func (sh *shell) info() {
+	result := sh.client.InitializeResult()
+	sh.printf("Server:       %s %s\n", result.ServerInfo.Name, result.ServerInfo.Version)
+	sh.printf("Protocol:     %s\n", result.ProtocolVersion)
+	sh.printf("Capabilities: %s\n", describeCapabilities(result.Capabilities))
+	if result.Instructions != "" {
+		sh.printf("Instructions: %s\n", result.Instructions)
+	}
+}
+
+func (sh *shell) tools(ctx context.Context) error {
+	tools, err := sh.client.ListTools(ctx)
+	if err != nil {
+		return err
+	}
+	sh.table("NAME\tPARAMETERS\tDESCRIPTION", len(tools), func(w io.Writer, i int) {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", tools[i].Name, describeSchema(tools[i].InputSchema), firstLine(tools[i].Description, 60))
+	})
+	return nil
+}
+
+func (sh *shell) call(ctx context.Context, rest string) error {
+	toolName, argText, _ := strings.Cut(rest, " ")
+	if toolName == "" {
+		return fmt.Errorf("usage: call <tool> [args]")
+	}
+
+	tool, err := findTool(ctx, sh.client, toolName)
+	if err != nil {
+		return err
+	}
+	args, err := parseShellArgs(tool.InputSchema, strings.TrimSpace(argText))
+	if err != nil {
+		return err
+	}
+	if err := mcp.ValidateArguments(tool.InputSchema, args); err != nil {
+		return err
+	}
+
+	// Ask the server to report progress for long calls.
+	result, err := sh.client.CallTool(mcp.WithProgress(ctx, sh.progress), toolName, args)
+	if err != nil {
+		return err
+	}
+
+	sh.mu.Lock()
+	err = printContent(sh.out, result.Content, ".", toolName)
+	if len(result.StructuredContent) > 0 {
+		fmt.Fprintf(sh.out, "structured: %s\n", result.StructuredContent)
+	}
+	if result.IsError {
+		fmt.Fprintln(sh.out, "(tool reported an error)")
+	}
+	sh.mu.Unlock()
+	return err
+}
+
+func (sh *shell) resources(ctx context.Context) error {
+	resources, err := sh.client.ListResources(ctx)
+	if err != nil {
+		return err
+	}
+	sh.table("URI\tNAME\tMIME TYPE", len(resources), func(w io.Writer, i int) {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", resources[i].URI, resources[i].Name, resources[i].MimeType)
+	})
+	return nil
+}
+
+func (sh *shell) templates(ctx context.Context) error {
+	templates, err := sh.client.ListResourceTemplates(ctx)
+	if err != nil {
+		return err
+	}
+	sh.table("URI TEMPLATE\tNAME\tDESCRIPTION", len(templates), func(w io.Writer, i int) {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", templates[i].URITemplate, templates[i].Name, firstLine(templates[i].Description, 50))
+	})
+	return nil
+}
+
+func (sh *shell) read(ctx context.Context, uri string) error {
+	if uri == "" {
+		return fmt.Errorf("usage: read <uri>")
+	}
+	result, err := sh.client.ReadResource(ctx, uri)
+	if err != nil {
+		return err
+	}
+
+	blocks := make([]mcp.Content, 0, len(result.Contents))
+	for i := range result.Contents {
+		blocks = append(blocks, mcp.Content{Type: "resource", Resource: &result.Contents[i]})
+	}
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	return printContent(sh.out, blocks, ".", "resource")
+}
+
+func (sh *shell) prompts(ctx context.Context) error {
+	prompts, err := sh.client.ListPrompts(ctx)
+	if err != nil {
+		return err
+	}
+	sh.table("NAME\tARGUMENTS\tDESCRIPTION", len(prompts), func(w io.Writer, i int) {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", prompts[i].Name, describePromptArgs(prompts[i].Arguments), firstLine(prompts[i].Description, 60))
+	})
+	return nil
+}
+
+func (sh *shell) prompt(ctx context.Context, rest string) error {
+	name, argText, _ := strings.Cut(rest, " ")
+	if name == "" {
+		return fmt.Errorf("usage: prompt <name> [args]")
+	}
+
+	parsed, err := parseShellArgs(nil, strings.TrimSpace(argText))
+	if err != nil {
+		return err
+	}
+	args := make(map[string]string, len(parsed))
+	for k, v := range parsed {
+		if s, ok := v.(string); ok {
+			args[k] = s
+		} else {
+			args[k] = compactValue(v)
+		}
+	}
+
+	result, err := sh.client.GetPrompt(ctx, name, args)
+	if err != nil {
+		return err
+	}
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if result.Description != "" {
+		fmt.Fprintf(sh.out, "# %s\n", result.Description)
+	}
+	for _, msg := range result.Messages {
+		fmt.Fprintf(sh.out, "%s: ", msg.Role)
+		if err := printContent(sh.out, []mcp.Content{msg.Content}, ".", name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sh *shell) table(header string, n int, row func(w io.Writer, i int)) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	if n == 0 {
+		fmt.Fprintln(sh.out, "(none)")
+		return
+	}
+	w := tabwriter.NewWriter(sh.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, header)
+	for i := 0; i < n; i++ {
+		row(w, i)
+	}
+	w.Flush()
+}
+
+// progress prints the progress reported for a running call.
+func (sh *shell) progress(p mcp.ProgressParams) {
+	if p.Total > 0 {
+		sh.printf("[progress %.0f/%.0f] %s\n", p.Progress, p.Total, p.Message)
+	} else {
+		sh.printf("[progress %.0f] %s\n", p.Progress, p.Message)
+	}
+}
+
+// notify prints a server notification as it arrives.
+func (sh *shell) notify(method string, params json.RawMessage) {
+	switch method {
+	case mcp.NotificationMessage:
+		var p mcp.LoggingMessageParams
+		json.Unmarshal(params, &p)
+		data := string(p.Data)
+		var text string
+		if json.Unmarshal(p.Data, &text) == nil {
+			data = text
+		}
+		if p.Logger != "" {
+			sh.printf("[log %s] %s: %s\n", p.Level, p.Logger, data)
+		} else {
+			sh.printf("[log %s] %s\n", p.Level, data)
+		}
+
+	case mcp.NotificationToolsChanged, mcp.NotificationPromptsChanged, mcp.NotificationResourcesChanged:
+		sh.printf("[%s]\n", strings.TrimPrefix(method, "notifications/"))
+
+	case mcp.NotificationResourceUpdated:
+		var p mcp.ResourceParams
+		json.Unmarshal(params, &p)
+		sh.printf("[resource updated] %s\n", p.URI)
+
+	case mcp.NotificationProgress:
+		// Shown by the call it belongs to.
+
+	default:
+		sh.printf("[%s] %s\n", method, params)
+	}
+}
+
+// parseShellArgs parses either a JSON object or key=value pairs.
+func parseShellArgs(schema json.RawMessage, text string) (map[string]interface{}, error) {
+	if strings.HasPrefix(text, "{") {
+		return parseToolArgs(schema, text, nil, nil)
+	}
+	pairs, err := splitFields(text)
+	if err != nil {
+		return nil, err
+	}
+	return parseToolArgs(schema, "", pairs, nil)
+}
+
+// splitFields splits text on spaces, keeping single- or double-quoted runs
+// together so values may contain spaces, e.g. text="hello world".
+func splitFields(text string) ([]string, error) {
+	var (
+		fields  []string
+		current strings.Builder
+		quote   rune
+		inField bool
+	)
+	for _, r := range text {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inField = true
+		case r == ' ' || r == '\t':
+			if inField {
+				fields = append(fields, current.String())
+				current.Reset()
+				inField = false
+			}
+		default:
+			current.WriteRune(r)
+			inField = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if inField {
+		fields = append(fields, current.String())
+	}
+	return fields, nil
+}
+
+func compactValue(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,219 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/xenixo/mcp-adapter/internal/launcher"
+	"github.com/xenixo/mcp-adapter/internal/supervisor"
+)
+
+func newStartCmd(app *App) *cobra.Command {
+	var (
+		addr    string
+		path    string
+		args    []string
+		envVars []string
+		restart string
+		hosts   []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "start <server> [-- args...]",
+		Short: "Start a server in the background",
+		Long: `Start an installed stdio server under the supervisor daemon, which keeps it
+running in the background between sessions and exposes it over Streamable
+HTTP. The daemon is started automatically if it is not already running.
+
+Without --http the server is given a free port on 127.0.0.1, which it keeps
+across restarts. Use 'mcp-adapter ps' to see its URL.
+
+A server that crashes is restarted after an exponentially growing delay,
+following the restart policy in its configuration (on-failure by default).
+One that crashes too often is left down and reported as degraded. Use
+--restart to override the policy's mode (never, on-failure or always).
+
+Example:
+  mcp-adapter start filesystem -- /path/to/dir
+  mcp-adapter start github --http 127.0.0.1:8081
+  mcp-adapter start flaky-server --restart always`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, cmdArgs []string) error {
+			if len(cmdArgs) > 1 {
+				args = append(args, cmdArgs[1:]...)
+			}
+			policy, err := restartPolicy(app, cmdArgs[0], restart)
+			if err != nil {
+				return err
+			}
+			return runStart(app, &supervisor.Spec{
+				Server:       cmdArgs[0],
+				Args:         args,
+				Env:          envVars,
+				Addr:         addr,
+				Path:         path,
+				AllowedHosts: hosts,
+				Restart:      policy,
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&addr, "http", "127.0.0.1:0", "Address to serve Streamable HTTP on")
+	cmd.Flags().StringVar(&path, "path", "/mcp", "URL path of the MCP endpoint")
+	cmd.Flags().StringArrayVar(&hosts, "allow-host", nil, "Host name besides loopback the endpoint may be reached by")
+	cmd.Flags().StringArrayVarP(&args, "arg", "a", nil, "Additional arguments to pass to the server")
+	cmd.Flags().StringArrayVarP(&envVars, "env", "e", nil, "Environment variables (KEY=VALUE)")
+	cmd.Flags().StringVar(&restart, "restart", "", "Restart policy: never, on-failure or always (default from config, else on-failure)")
+
+	return cmd
+}
+
+// restartPolicy returns a server's configured restart policy, or the
+// default, with its mode overridden if mode is set.
+func restartPolicy(app *App, serverName, mode string) (*launcher.RestartPolicy, error) {
+	policy := launcher.DefaultRestartPolicy()
+	if cfg, err := GetServerConfig(app, serverName); err != nil {
+		return nil, err
+	} else if cfg.Restart != nil {
+		configured := *cfg.Restart
+		policy = &configured
+	}
+	if mode != "" {
+		policy.Mode = launcher.RestartMode(mode)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("server %q: %w", serverName, err)
+	}
+	return policy, nil
+}
+
+func runStart(app *App, spec *supervisor.Spec) error {
+	// Check the server here so that mistakes are reported before a daemon
+	// is spawned.
+	server, err := findInstalledServer(app, spec.Server)
+	if err != nil {
+		return err
+	}
+	if server.IsRemote() {
+		return fmt.Errorf("server %q is a remote server; connect to %s directly", spec.Server, server.Source.Remote)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), supervisor.DefaultStartTimeout)
+	defer cancel()
+
+	client, err := daemonClient(ctx, app, true)
+	if err != nil {
+		return err
+	}
+
+	status, err := client.Start(ctx, spec)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Started %s (pid %d) at %s\n", status.Name, status.PID, status.URL)
+	return nil
+}
+
+func newStopCmd(app *App) *cobra.Command {
+	var all bool
+
+	cmd := &cobra.Command{
+		Use:   "stop <server>...",
+		Short: "Stop background servers",
+		Long: `Stop servers running under the supervisor daemon.
+
+With --all, every server is stopped and the daemon exits.
+
+Example:
+  mcp-adapter stop filesystem
+  mcp-adapter stop --all`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !all && len(args) == 0 {
+				return errors.New("specify a server to stop, or --all")
+			}
+			return runStop(app, args, all)
+		},
+	}
+
+	cmd.Flags().BoolVar(&all, "all", false, "Stop every server and the daemon")
+
+	return cmd
+}
+
+func runStop(app *App, names []string, all bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*stopTimeout)
+	defer cancel()
+
+	client, err := daemonClient(ctx, app, false)
+	if errors.Is(err, supervisor.ErrDaemonNotRunning) && all {
+		fmt.Println("Supervisor daemon is not running.")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if all {
+		if err := client.Shutdown(ctx); err != nil {
+			return err
+		}
+		fmt.Println("Stopped all servers and the supervisor daemon.")
+		return nil
+	}
+
+	var failed int
+	for _, name := range names {
+		status, err := client.Stop(ctx, name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to stop %s: %v\n", name, err)
+			failed++
+			continue
+		}
+		fmt.Printf("Stopped %s (exit code %d)\n", status.Name, status.ExitCode)
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to stop %d server(s)", failed)
+	}
+	return nil
+}
+
+func newRestartCmd(app *App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restart <server>",
+		Short: "Restart a background server",
+		Long: `Restart a server running under the supervisor daemon with the arguments it
+was started with. Its saved configuration is read again, and it keeps its
+address. The restart policy is the exception: it is kept from 'start', so
+stop and start the server to change it.
+
+Example:
+  mcp-adapter restart filesystem`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRestart(app, args[0])
+		},
+	}
+
+	return cmd
+}
+
+func runRestart(app *App, name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), stopTimeout+supervisor.DefaultStartTimeout)
+	defer cancel()
+
+	client, err := daemonClient(ctx, app, false)
+	if err != nil {
+		return err
+	}
+
+	status, err := client.Restart(ctx, name)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Restarted %s (pid %d) at %s\n", status.Name, status.PID, status.URL)
+	return nil
+}
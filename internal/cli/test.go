@@ -0,0 +1,183 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/xenixo/mcp-adapter/internal/conformance"
+	"github.com/xenixo/mcp-adapter/internal/launcher"
+)
+
+func newTestCmd(app *App) *cobra.Command {
+	var (
+		args       []string
+		envVars    []string
+		junitPath  string
+		checks     []string
+		timeout    time.Duration
+		jsonOutput bool
+		showStderr bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "test <server> [-- args...]",
+		Short: "Run MCP protocol conformance checks against a server",
+		Long: `Launch a server and check that it follows the MCP protocol: handshake and
+version negotiation, ping, error codes for unknown methods and malformed
+JSON, tools/list pagination, cancellation handling, and that nothing but
+JSON-RPC messages is written to stdout.
+
+Each check runs against a fresh server process. The command exits with a
+non-zero status if any check fails.
+
+Checks: ` + strings.Join(conformance.CheckNames(), ", ") + `
+
+Example:
+  mcp-adapter test filesystem -- /tmp
+  mcp-adapter test github --junit report.xml`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, cmdArgs []string) error {
+			if len(cmdArgs) > 1 {
+				args = append(args, cmdArgs[1:]...)
+			}
+			return runTest(app, cmdArgs[0], args, envVars, &conformance.Options{Timeout: timeout, Checks: checks}, junitPath, jsonOutput, showStderr)
+		},
+	}
+
+	cmd.Flags().StringArrayVarP(&args, "arg", "a", nil, "Additional arguments to pass to the server")
+	cmd.Flags().StringArrayVarP(&envVars, "env", "e", nil, "Environment variables (KEY=VALUE)")
+	cmd.Flags().StringVar(&junitPath, "junit", "", "Write a JUnit XML report to this file")
+	cmd.Flags().StringSliceVar(&checks, "check", nil, "Run only these checks (repeatable)")
+	cmd.Flags().DurationVar(&timeout, "timeout", conformance.DefaultTimeout, "Time to wait for each server response")
+	cmd.Flags().BoolVarP(&jsonOutput, "json", "j", false, "Output in JSON format")
+	cmd.Flags().BoolVar(&showStderr, "stderr", false, "Show the server's stderr output")
+
+	return cmd
+}
+
+func runTest(app *App, serverName string, args, envVars []string, opts *conformance.Options, junitPath string, jsonOutput, showStderr bool) error {
+	server, err := findInstalledServer(app, serverName)
+	if err != nil {
+		return err
+	}
+	if server.IsRemote() {
+		return fmt.Errorf("server %q is a remote server; conformance checks need a local stdio server", serverName)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+	go func() {
+		select {
+		case <-sigChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	launcherInst := launcher.NewLauncher(app.Config, app.Logger)
+	defer launcherInst.StopAll(stopTimeout)
+
+	launchOpts, err := buildLaunchOptions(app, serverName, args, envVars)
+	if err != nil {
+		return err
+	}
+	launchOpts.Stderr = io.Discard
+	if showStderr {
+		launchOpts.Stderr = os.Stderr
+	}
+
+	launch := func(ctx context.Context) (io.ReadWriteCloser, error) {
+		t, err := launchStdio(ctx, app, launcherInst, server, launchOpts)
+		if err != nil {
+			return nil, err
+		}
+		return &processConn{t}, nil
+	}
+
+	report, err := conformance.Run(ctx, launch, opts)
+	if err != nil {
+		return err
+	}
+
+	if junitPath != "" {
+		f, err := os.Create(junitPath)
+		if err != nil {
+			return fmt.Errorf("failed to create JUnit report: %w", err)
+		}
+		werr := report.WriteJUnit(f, serverName)
+		if cerr := f.Close(); werr == nil {
+			werr = cerr
+		}
+		if werr != nil {
+			return fmt.Errorf("failed to write JUnit report: %w", werr)
+		}
+	}
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		printReport(os.Stdout, report)
+	}
+
+	if !report.Passed() {
+		return fmt.Errorf("%s failed %d of %d conformance checks", serverName, report.Count(conformance.StatusFail), len(report.Results))
+	}
+	return nil
+}
+
+func printReport(out io.Writer, report *conformance.Report) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, r := range report.Results {
+		mark := "✓"
+		switch r.Status {
+		case conformance.StatusFail:
+			mark = "✗"
+		case conformance.StatusSkip:
+			mark = "-"
+		}
+		detail := r.Description
+		if r.Message != "" {
+			detail = r.Message
+		}
+		fmt.Fprintf(w, "%s %s\t%s\t%s\n", mark, r.Name, r.Duration.Round(time.Millisecond), detail)
+	}
+	w.Flush()
+
+	fmt.Fprintf(out, "\n%d checks: %d passed, %d failed, %d skipped\n",
+		len(report.Results),
+		report.Count(conformance.StatusPass),
+		report.Count(conformance.StatusFail),
+		report.Count(conformance.StatusSkip))
+}
+
+// processConn exposes a launched server's raw stdio as a single stream.
+type processConn struct {
+	t *processTransport
+}
+
+func (c *processConn) Read(p []byte) (int, error)  { return c.t.proc.Stdout.Read(p) }
+func (c *processConn) Write(p []byte) (int, error) { return c.t.proc.Stdin.Write(p) }
+
+// Close stops the server process.
+func (c *processConn) Close() error {
+	c.t.proc.Stdin.Close()
+	return c.t.Close()
+}
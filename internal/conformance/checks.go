@@ -0,0 +1,287 @@
+package conformance
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/xenixo/mcp-adapter/internal/mcp"
+)
+
+// unsupportedVersion is a protocol version no server supports.
+const unsupportedVersion = "1999-01-01"
+
+// maxPages bounds how many tools/list pages are followed.
+const maxPages = 100
+
+// settleTime is how long the stdout check keeps listening after the
+// server's last response.
+const settleTime = 500 * time.Millisecond
+
+type check struct {
+	name        string
+	description string
+	run         func(c *conn) error
+}
+
+var checks = []check{
+	{"handshake", "initialize succeeds and returns server info and capabilities", checkHandshake},
+	{"version-negotiation", "an unsupported protocol version is answered with a supported one", checkVersionNegotiation},
+	{"ping", "ping returns an empty result", checkPing},
+	{"unknown-method", "unknown methods return -32601 (method not found)", checkUnknownMethod},
+	{"parse-error", "malformed JSON returns -32700 (parse error) and the server keeps running", checkParseError},
+	{"tools-pagination", "tools/list cursors page through all tools without repeats", checkToolsPagination},
+	{"cancellation", "cancellation of in-flight and unknown requests is tolerated", checkCancellation},
+	{"stdout", "stdout carries only JSON-RPC 2.0 messages", checkStdout},
+}
+
+func checkHandshake(c *conn) error {
+	msg, err := c.initialize(mcp.LatestProtocolVersion)
+	if err != nil {
+		return err
+	}
+	if msg.JSONRPC != "2.0" {
+		return fmt.Errorf("response has jsonrpc %q, want \"2.0\"", msg.JSONRPC)
+	}
+	if msg.Error != nil {
+		return fmt.Errorf("initialize failed: %v", msg.Error)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(msg.Result, &fields); err != nil {
+		return fmt.Errorf("initialize result is not an object: %s", truncate(string(msg.Result), 60))
+	}
+	for _, field := range []string{"protocolVersion", "capabilities", "serverInfo"} {
+		if _, ok := fields[field]; !ok {
+			return fmt.Errorf("initialize result is missing %q", field)
+		}
+	}
+
+	var result mcp.InitializeResult
+	if err := json.Unmarshal(msg.Result, &result); err != nil {
+		return fmt.Errorf("invalid initialize result: %w", err)
+	}
+	if !mcp.IsSupportedProtocolVersion(result.ProtocolVersion) {
+		return fmt.Errorf("server negotiated protocol version %q, which mcp-adapter does not support", result.ProtocolVersion)
+	}
+	if result.ServerInfo.Name == "" {
+		return fmt.Errorf("serverInfo.name is empty")
+	}
+
+	return c.notify(mcp.NotificationInitialized, nil)
+}
+
+func checkVersionNegotiation(c *conn) error {
+	msg, err := c.initialize(unsupportedVersion)
+	if err != nil {
+		return err
+	}
+	if msg.Error != nil {
+		return fmt.Errorf("server rejected version %s with an error instead of offering a supported version: %v", unsupportedVersion, msg.Error)
+	}
+
+	var result mcp.InitializeResult
+	if err := json.Unmarshal(msg.Result, &result); err != nil {
+		return fmt.Errorf("invalid initialize result: %w", err)
+	}
+	switch result.ProtocolVersion {
+	case "":
+		return fmt.Errorf("initialize result has no protocolVersion")
+	case unsupportedVersion:
+		return fmt.Errorf("server accepted unsupported version %s", unsupportedVersion)
+	}
+	return nil
+}
+
+func checkPing(c *conn) error {
+	if _, err := c.handshake(); err != nil {
+		return err
+	}
+
+	msg, err := c.request(mcp.MethodPing, nil)
+	if err != nil {
+		return err
+	}
+	if msg.Error != nil {
+		return fmt.Errorf("ping failed: %v", msg.Error)
+	}
+
+	var result map[string]json.RawMessage
+	if err := json.Unmarshal(msg.Result, &result); err != nil || result == nil {
+		return fmt.Errorf("ping result is not an object: %s", truncate(string(msg.Result), 60))
+	}
+	return nil
+}
+
+func checkUnknownMethod(c *conn) error {
+	if _, err := c.handshake(); err != nil {
+		return err
+	}
+
+	msg, err := c.request("mcp-adapter/conformance/unknown", nil)
+	if err != nil {
+		return err
+	}
+	if msg.Error == nil {
+		return fmt.Errorf("unknown method returned a result instead of an error")
+	}
+	if msg.Error.Code != mcp.ErrMethodNotFound {
+		return fmt.Errorf("unknown method returned error code %d, want %d", msg.Error.Code, mcp.ErrMethodNotFound)
+	}
+	return nil
+}
+
+func checkParseError(c *conn) error {
+	if _, err := c.handshake(); err != nil {
+		return err
+	}
+
+	if err := c.sendLine(`{"jsonrpc": "2.0", "id": 1000, "method": "ping"`); err != nil {
+		return err
+	}
+	msg, err := c.await("parse error response", func(msg *mcp.Message) bool {
+		return msg.Method == "" && msg.Error != nil
+	})
+	if err != nil {
+		return err
+	}
+	if msg.Error.Code != mcp.ErrParse {
+		return fmt.Errorf("malformed JSON returned error code %d, want %d", msg.Error.Code, mcp.ErrParse)
+	}
+	if msg.ID != nil {
+		return fmt.Errorf("parse error response has id %v, want null", msg.ID)
+	}
+
+	if _, err := c.request(mcp.MethodPing, nil); err != nil {
+		return fmt.Errorf("server stopped responding after malformed input: %w", err)
+	}
+	return nil
+}
+
+func checkToolsPagination(c *conn) error {
+	init, err := c.handshake()
+	if err != nil {
+		return err
+	}
+	if init.Capabilities.Tools == nil {
+		return skipError("server does not offer tools")
+	}
+
+	seenTools := make(map[string]bool)
+	seenCursors := make(map[string]bool)
+	cursor := ""
+	for page := 1; ; page++ {
+		if page > maxPages {
+			return fmt.Errorf("tools/list returned more than %d pages", maxPages)
+		}
+
+		msg, err := c.request(mcp.MethodToolsList, &mcp.PaginatedParams{Cursor: cursor})
+		if err != nil {
+			return err
+		}
+		if msg.Error != nil {
+			return fmt.Errorf("tools/list page %d failed: %v", page, msg.Error)
+		}
+
+		var result struct {
+			Tools      *[]mcp.Tool `json:"tools"`
+			NextCursor string      `json:"nextCursor"`
+		}
+		if err := json.Unmarshal(msg.Result, &result); err != nil {
+			return fmt.Errorf("invalid tools/list result on page %d: %w", page, err)
+		}
+		if result.Tools == nil {
+			return fmt.Errorf("tools/list result on page %d has no \"tools\" array", page)
+		}
+
+		for _, tool := range *result.Tools {
+			if tool.Name == "" {
+				return fmt.Errorf("tools/list page %d has a tool without a name", page)
+			}
+			if seenTools[tool.Name] {
+				return fmt.Errorf("tool %q is listed more than once", tool.Name)
+			}
+			seenTools[tool.Name] = true
+		}
+
+		if result.NextCursor == "" {
+			break
+		}
+		if seenCursors[result.NextCursor] {
+			return fmt.Errorf("tools/list returned cursor %q twice", result.NextCursor)
+		}
+		seenCursors[result.NextCursor] = true
+		cursor = result.NextCursor
+	}
+
+	// Servers may ignore a cursor they do not recognise, but if they
+	// reject it the error must be invalid params.
+	msg, err := c.request(mcp.MethodToolsList, &mcp.PaginatedParams{Cursor: "mcp-adapter-invalid-cursor"})
+	if err != nil {
+		return err
+	}
+	if msg.Error != nil && msg.Error.Code != mcp.ErrInvalidParams {
+		return fmt.Errorf("invalid cursor returned error code %d, want %d", msg.Error.Code, mcp.ErrInvalidParams)
+	}
+	return nil
+}
+
+func checkCancellation(c *conn) error {
+	init, err := c.handshake()
+	if err != nil {
+		return err
+	}
+
+	method := mcp.MethodPing
+	if init.Capabilities.Tools != nil {
+		method = mcp.MethodToolsList
+	}
+
+	id, err := c.sendRequest(method, nil)
+	if err != nil {
+		return err
+	}
+	if err := c.notify(mcp.NotificationCancelled, &mcp.CancelledParams{RequestID: id, Reason: "conformance test"}); err != nil {
+		return err
+	}
+	if err := c.notify(mcp.NotificationCancelled, &mcp.CancelledParams{RequestID: "mcp-adapter-unknown-request", Reason: "conformance test"}); err != nil {
+		return err
+	}
+
+	if _, err := c.request(mcp.MethodPing, nil); err != nil {
+		return fmt.Errorf("server stopped responding after cancellation: %w", err)
+	}
+
+	for _, msg := range c.unmatched {
+		if msg.Method == "" && msg.ID == nil && msg.Error != nil {
+			return fmt.Errorf("server answered a cancellation notification with an error: %v", msg.Error)
+		}
+	}
+	return nil
+}
+
+func checkStdout(c *conn) error {
+	init, err := c.handshake()
+	if err != nil {
+		return err
+	}
+	if _, err := c.request(mcp.MethodPing, nil); err != nil {
+		return err
+	}
+	if init.Capabilities.Tools != nil {
+		if _, err := c.request(mcp.MethodToolsList, nil); err != nil {
+			return err
+		}
+	}
+	c.drain(settleTime)
+
+	violations := c.Violations()
+	switch len(violations) {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("%s", violations[0])
+	default:
+		return fmt.Errorf("%s (and %d more)", violations[0], len(violations)-1)
+	}
+}
@@ -0,0 +1,164 @@
+// Package conformance runs MCP protocol conformance checks against a server.
+//
+// Each check launches a fresh server process and talks to it over raw stdio,
+// so that malformed input and misbehaving output can be exercised without the
+// mcp package's own framing getting in the way.
+package conformance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// DefaultTimeout is how long a check waits for each server response.
+const DefaultTimeout = 10 * time.Second
+
+// Status is the outcome of a check.
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusFail Status = "fail"
+	StatusSkip Status = "skip"
+)
+
+// LaunchFunc starts a new server process. Reads come from the server's
+// stdout, writes go to its stdin, and Close stops the process.
+type LaunchFunc func(ctx context.Context) (io.ReadWriteCloser, error)
+
+// Options configures a conformance run.
+type Options struct {
+	// Timeout bounds each wait for a server response. Defaults to
+	// DefaultTimeout.
+	Timeout time.Duration
+
+	// Checks limits the run to the named checks. All checks run if empty.
+	Checks []string
+}
+
+// Result is the outcome of one check.
+type Result struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Status      Status        `json:"status"`
+	Message     string        `json:"message,omitempty"`
+	Duration    time.Duration `json:"duration"`
+}
+
+// Report is the outcome of a conformance run.
+type Report struct {
+	Results  []Result      `json:"results"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Count returns the number of results with the given status.
+func (r *Report) Count(status Status) int {
+	n := 0
+	for _, result := range r.Results {
+		if result.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// Passed reports whether no check failed.
+func (r *Report) Passed() bool {
+	return r.Count(StatusFail) == 0
+}
+
+// CheckNames returns the names of all checks in the order they run.
+func CheckNames() []string {
+	names := make([]string, 0, len(checks))
+	for _, c := range checks {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+// Run runs the conformance checks, launching a new server for each one.
+func Run(ctx context.Context, launch LaunchFunc, opts *Options) (*Report, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	selected, err := selectChecks(opts.Checks)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{}
+	start := time.Now()
+	for _, c := range selected {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		report.Results = append(report.Results, runCheck(ctx, launch, c, timeout))
+	}
+	report.Duration = time.Since(start)
+
+	return report, nil
+}
+
+func selectChecks(names []string) ([]check, error) {
+	if len(names) == 0 {
+		return checks, nil
+	}
+
+	byName := make(map[string]check, len(checks))
+	for _, c := range checks {
+		byName[c.name] = c
+	}
+
+	selected := make([]check, 0, len(names))
+	for _, name := range names {
+		c, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown check %q", name)
+		}
+		selected = append(selected, c)
+	}
+	return selected, nil
+}
+
+func runCheck(ctx context.Context, launch LaunchFunc, c check, timeout time.Duration) (result Result) {
+	result = Result{Name: c.name, Description: c.description}
+	start := time.Now()
+	defer func() { result.Duration = time.Since(start) }()
+
+	rwc, err := launch(ctx)
+	if err != nil {
+		result.Status = StatusFail
+		result.Message = fmt.Sprintf("failed to launch server: %v", err)
+		return result
+	}
+
+	conn := newConn(rwc, timeout)
+	err = c.run(conn)
+	conn.Close()
+
+	var skip skipError
+	switch {
+	case err == nil:
+		result.Status = StatusPass
+	case errors.As(err, &skip):
+		result.Status = StatusSkip
+		result.Message = string(skip)
+	default:
+		result.Status = StatusFail
+		result.Message = err.Error()
+	}
+	return result
+}
+
+// skipError marks a check that does not apply to the server.
+type skipError string
+
+func (e skipError) Error() string { return string(e) }
@@ -0,0 +1,256 @@
+package conformance
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xenixo/mcp-adapter/internal/mcp"
+)
+
+// fakeServer is a hand-written stdio MCP server whose flaws can be toggled.
+type fakeServer struct {
+	tools    []string
+	pageSize int
+
+	banner           bool // print a banner to stdout before speaking MCP
+	dieOnParseError  bool // exit on malformed input
+	unknownErrorCode int  // error code for unknown methods
+	echoVersion      bool // accept any requested protocol version
+}
+
+func newGoodServer() *fakeServer {
+	return &fakeServer{
+		tools:            []string{"a", "b", "c"},
+		pageSize:         2,
+		unknownErrorCode: mcp.ErrMethodNotFound,
+	}
+}
+
+type pipeConn struct {
+	io.Reader
+	io.Writer
+	close func()
+}
+
+func (p *pipeConn) Close() error {
+	p.close()
+	return nil
+}
+
+func (s *fakeServer) launch(ctx context.Context) (io.ReadWriteCloser, error) {
+	stdinR, stdinW := io.Pipe()
+	stdoutR, stdoutW := io.Pipe()
+
+	go func() {
+		defer stdoutW.Close()
+		s.serve(stdinR, stdoutW)
+	}()
+
+	return &pipeConn{
+		Reader: stdoutR,
+		Writer: stdinW,
+		close: func() {
+			stdinW.Close()
+			stdoutR.Close()
+		},
+	}, nil
+}
+
+func (s *fakeServer) serve(in io.Reader, out io.Writer) {
+	if s.banner {
+		fmt.Fprintln(out, "Fake server v1 listening on stdio")
+	}
+
+	reply := func(id interface{}, result interface{}, rpcErr *mcp.Error) {
+		msg := map[string]interface{}{"jsonrpc": "2.0", "id": id}
+		if rpcErr != nil {
+			msg["error"] = rpcErr
+		} else {
+			msg["result"] = result
+		}
+		data, _ := json.Marshal(msg)
+		out.Write(append(data, '\n'))
+	}
+
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		var msg mcp.Message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			if s.dieOnParseError {
+				return
+			}
+			reply(nil, nil, &mcp.Error{Code: mcp.ErrParse, Message: "parse error"})
+			continue
+		}
+		if msg.ID == nil {
+			continue
+		}
+
+		switch msg.Method {
+		case mcp.MethodInitialize:
+			var params mcp.InitializeParams
+			json.Unmarshal(msg.Params, &params)
+			version := params.ProtocolVersion
+			if !s.echoVersion && !mcp.IsSupportedProtocolVersion(version) {
+				version = mcp.LatestProtocolVersion
+			}
+			reply(msg.ID, map[string]interface{}{
+				"protocolVersion": version,
+				"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+				"serverInfo":      map[string]string{"name": "fake", "version": "1.0.0"},
+			}, nil)
+
+		case mcp.MethodPing:
+			reply(msg.ID, map[string]interface{}{}, nil)
+
+		case mcp.MethodToolsList:
+			var params mcp.PaginatedParams
+			json.Unmarshal(msg.Params, &params)
+			start := 0
+			if params.Cursor != "" {
+				if _, err := fmt.Sscanf(params.Cursor, "page-%d", &start); err != nil {
+					reply(msg.ID, nil, &mcp.Error{Code: mcp.ErrInvalidParams, Message: "invalid cursor"})
+					continue
+				}
+			}
+			end := start + s.pageSize
+			result := map[string]interface{}{}
+			if end < len(s.tools) {
+				result["nextCursor"] = fmt.Sprintf("page-%d", end)
+			} else {
+				end = len(s.tools)
+			}
+			tools := []map[string]interface{}{}
+			for _, name := range s.tools[start:end] {
+				tools = append(tools, map[string]interface{}{"name": name, "inputSchema": map[string]string{"type": "object"}})
+			}
+			result["tools"] = tools
+			reply(msg.ID, result, nil)
+
+		default:
+			reply(msg.ID, nil, &mcp.Error{Code: s.unknownErrorCode, Message: "unknown method"})
+		}
+	}
+}
+
+func runFake(t *testing.T, s *fakeServer) *Report {
+	t.Helper()
+	report, err := Run(context.Background(), s.launch, &Options{Timeout: 2 * time.Second})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	return report
+}
+
+func resultsByName(report *Report) map[string]Result {
+	results := make(map[string]Result, len(report.Results))
+	for _, r := range report.Results {
+		results[r.Name] = r
+	}
+	return results
+}
+
+func TestRunCompliantServer(t *testing.T) {
+	report := runFake(t, newGoodServer())
+
+	if len(report.Results) != len(checks) {
+		t.Fatalf("got %d results, want %d", len(report.Results), len(checks))
+	}
+	for _, r := range report.Results {
+		if r.Status != StatusPass {
+			t.Errorf("check %s: status = %s (%s), want pass", r.Name, r.Status, r.Message)
+		}
+	}
+	if !report.Passed() {
+		t.Error("Passed() = false for a compliant server")
+	}
+}
+
+func TestRunBrokenServer(t *testing.T) {
+	s := newGoodServer()
+	s.banner = true
+	s.dieOnParseError = true
+	s.unknownErrorCode = mcp.ErrInternal
+	s.echoVersion = true
+	s.tools = []string{"a", "b", "a"}
+
+	report := runFake(t, s)
+	results := resultsByName(report)
+
+	wantFail := map[string]string{
+		"version-negotiation": "accepted unsupported version",
+		"unknown-method":      "error code -32603",
+		"parse-error":         "closed stdout",
+		"tools-pagination":    `tool "a" is listed more than once`,
+		"stdout":              "non-JSON output on stdout: Fake server v1",
+	}
+	for name, want := range wantFail {
+		r := results[name]
+		if r.Status != StatusFail {
+			t.Errorf("check %s: status = %s, want fail", name, r.Status)
+			continue
+		}
+		if !strings.Contains(r.Message, want) {
+			t.Errorf("check %s: message = %q, want it to contain %q", name, r.Message, want)
+		}
+	}
+
+	for _, name := range []string{"handshake", "ping", "cancellation"} {
+		if r := results[name]; r.Status != StatusPass {
+			t.Errorf("check %s: status = %s (%s), want pass", name, r.Status, r.Message)
+		}
+	}
+	if report.Passed() {
+		t.Error("Passed() = true for a broken server")
+	}
+}
+
+func TestRunSelectedChecks(t *testing.T) {
+	s := newGoodServer()
+	report, err := Run(context.Background(), s.launch, &Options{Checks: []string{"ping", "handshake"}})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(report.Results) != 2 || report.Results[0].Name != "ping" {
+		t.Errorf("results = %+v, want ping then handshake", report.Results)
+	}
+
+	if _, err := Run(context.Background(), s.launch, &Options{Checks: []string{"nope"}}); err == nil {
+		t.Error("Run() with an unknown check succeeded")
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	report := &Report{
+		Duration: 1500 * time.Millisecond,
+		Results: []Result{
+			{Name: "handshake", Status: StatusPass, Duration: time.Second},
+			{Name: "ping", Description: "ping returns an empty result", Status: StatusFail, Message: "no response to ping within 10s"},
+			{Name: "tools-pagination", Status: StatusSkip, Message: "server does not offer tools"},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteJUnit(&buf, "fake"); err != nil {
+		t.Fatalf("WriteJUnit() error = %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		`<testsuite name="mcp-conformance.fake" tests="3" failures="1" skipped="1" time="1.500">`,
+		`<testcase name="handshake" classname="mcp-conformance.fake" time="1.000"></testcase>`,
+		`<failure message="no response to ping within 10s">ping returns an empty result</failure>`,
+		`<skipped message="server does not offer tools"></skipped>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("JUnit output missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package conformance
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/xenixo/mcp-adapter/internal/mcp"
+)
+
+// conn is a raw line-oriented connection to a server under test. Unlike
+// mcp.StdioTransport it never gives up on bad output: lines that are not
+// JSON-RPC 2.0 messages are recorded as violations and reading continues.
+type conn struct {
+	rwc      io.ReadWriteCloser
+	timeout  time.Duration
+	incoming chan *mcp.Message
+
+	writeMu sync.Mutex
+	nextID  int64
+
+	mu         sync.Mutex
+	violations []string
+	readErr    error
+
+	// unmatched holds messages seen while waiting for something else.
+	unmatched []*mcp.Message
+}
+
+func newConn(rwc io.ReadWriteCloser, timeout time.Duration) *conn {
+	c := &conn{
+		rwc:      rwc,
+		timeout:  timeout,
+		incoming: make(chan *mcp.Message, 256),
+	}
+	go c.readLoop()
+	return c
+}
+
+func (c *conn) Close() error {
+	return c.rwc.Close()
+}
+
+func (c *conn) readLoop() {
+	defer close(c.incoming)
+
+	reader := bufio.NewReader(c.rwc)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			c.handleLine(trimmed)
+		}
+		if err != nil {
+			if err != io.EOF {
+				c.mu.Lock()
+				c.readErr = err
+				c.mu.Unlock()
+			}
+			return
+		}
+	}
+}
+
+func (c *conn) handleLine(line []byte) {
+	var msg mcp.Message
+	if err := json.Unmarshal(line, &msg); err != nil {
+		c.violate("non-JSON output on stdout: %s", truncate(string(line), 60))
+		return
+	}
+	if msg.JSONRPC != "2.0" {
+		c.violate("message without \"jsonrpc\": \"2.0\": %s", truncate(string(line), 60))
+	}
+
+	// Requests from the server (sampling, roots, ...) are declined so the
+	// server is not left waiting.
+	if msg.IsRequest() {
+		c.send(&mcp.Message{
+			JSONRPC: "2.0",
+			ID:      msg.ID,
+			Error:   &mcp.Error{Code: mcp.ErrMethodNotFound, Message: "not supported during conformance tests"},
+		})
+		return
+	}
+
+	c.incoming <- &msg
+}
+
+func (c *conn) violate(format string, args ...interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.violations = append(c.violations, fmt.Sprintf(format, args...))
+}
+
+// Violations returns the protocol violations seen on stdout so far.
+func (c *conn) Violations() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.violations...)
+}
+
+func (c *conn) send(msg *mcp.Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return c.sendLine(string(data))
+}
+
+// sendLine writes one raw line to the server.
+func (c *conn) sendLine(line string) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if _, err := io.WriteString(c.rwc, line+"\n"); err != nil {
+		return fmt.Errorf("failed to write to server: %w", err)
+	}
+	return nil
+}
+
+// sendRequest sends a request and returns its ID without waiting.
+func (c *conn) sendRequest(method string, params interface{}) (int64, error) {
+	c.writeMu.Lock()
+	c.nextID++
+	id := c.nextID
+	c.writeMu.Unlock()
+
+	msg := &mcp.Message{JSONRPC: "2.0", ID: id, Method: method}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return 0, err
+		}
+		msg.Params = data
+	}
+	return id, c.send(msg)
+}
+
+func (c *conn) notify(method string, params interface{}) error {
+	msg := &mcp.Message{JSONRPC: "2.0", Method: method}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		msg.Params = data
+	}
+	return c.send(msg)
+}
+
+// request sends a request and waits for its response, which may carry an
+// error.
+func (c *conn) request(method string, params interface{}) (*mcp.Message, error) {
+	id, err := c.sendRequest(method, params)
+	if err != nil {
+		return nil, err
+	}
+	return c.awaitResponse(id, method)
+}
+
+func (c *conn) awaitResponse(id int64, method string) (*mcp.Message, error) {
+	want := fmt.Sprint(id)
+	return c.await("response to "+method, func(msg *mcp.Message) bool {
+		return msg.IsResponse() && fmt.Sprint(msg.ID) == want
+	})
+}
+
+// await waits for a message matching match. Other messages are kept in
+// unmatched.
+func (c *conn) await(what string, match func(*mcp.Message) bool) (*mcp.Message, error) {
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case msg, ok := <-c.incoming:
+			if !ok {
+				return nil, c.closedErr(what)
+			}
+			if match(msg) {
+				return msg, nil
+			}
+			c.unmatched = append(c.unmatched, msg)
+		case <-timer.C:
+			return nil, fmt.Errorf("no %s within %s", what, c.timeout)
+		}
+	}
+}
+
+// drain collects messages for d, catching output the server writes after
+// its last response.
+func (c *conn) drain(d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	for {
+		select {
+		case msg, ok := <-c.incoming:
+			if !ok {
+				return
+			}
+			c.unmatched = append(c.unmatched, msg)
+		case <-timer.C:
+			return
+		}
+	}
+}
+
+func (c *conn) closedErr(what string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.readErr != nil {
+		return fmt.Errorf("server output ended while waiting for %s: %w", what, c.readErr)
+	}
+	return fmt.Errorf("server closed stdout while waiting for %s", what)
+}
+
+// initialize sends an initialize request for the given protocol version.
+func (c *conn) initialize(version string) (*mcp.Message, error) {
+	return c.request(mcp.MethodInitialize, &mcp.InitializeParams{
+		ProtocolVersion: version,
+		ClientInfo:      mcp.Implementation{Name: "mcp-adapter-conformance", Version: "1.0.0"},
+	})
+}
+
+// handshake performs a successful initialize exchange and returns the
+// server's result.
+func (c *conn) handshake() (*mcp.InitializeResult, error) {
+	msg, err := c.initialize(mcp.LatestProtocolVersion)
+	if err != nil {
+		return nil, err
+	}
+	if msg.Error != nil {
+		return nil, fmt.Errorf("initialize failed: %v", msg.Error)
+	}
+
+	var result mcp.InitializeResult
+	if err := json.Unmarshal(msg.Result, &result); err != nil {
+		return nil, fmt.Errorf("invalid initialize result: %w", err)
+	}
+
+	if err := c.notify(mcp.NotificationInitialized, nil); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func truncate(s string, max int) string {
+	if len(s) > max {
+		return s[:max-3] + "..."
+	}
+	return s
+}
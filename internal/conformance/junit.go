@@ -0,0 +1,77 @@
+package conformance
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the report as JUnit XML, with one test suite named
+// after the server and one test case per check.
+func (r *Report) WriteJUnit(w io.Writer, server string) error {
+	suite := junitTestSuite{
+		Name:     "mcp-conformance." + server,
+		Tests:    len(r.Results),
+		Failures: r.Count(StatusFail),
+		Skipped:  r.Count(StatusSkip),
+		Time:     junitSeconds(r.Duration),
+	}
+
+	for _, result := range r.Results {
+		tc := junitTestCase{
+			Name:      result.Name,
+			ClassName: suite.Name,
+			Time:      junitSeconds(result.Duration),
+		}
+		switch result.Status {
+		case StatusFail:
+			tc.Failure = &junitMessage{Message: result.Message, Text: result.Description}
+		case StatusSkip:
+			tc.Skipped = &junitMessage{Message: result.Message}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(junitTestSuites{Suites: []junitTestSuite{suite}}); err != nil {
+		return fmt.Errorf("failed to encode JUnit report: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,516 @@
+// Package gateway aggregates several MCP servers behind a single MCP endpoint.
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/xenixo/mcp-adapter/internal/mcp"
+)
+
+// Separator joins a server name and a tool or prompt name in the names the
+// gateway exposes, e.g. "filesystem__read_file".
+const Separator = "__"
+
+// Backend is an initialized client session to one aggregated server.
+type Backend struct {
+	// Name is the server name used to prefix its tools and prompts.
+	Name string
+
+	// Client is the initialized session to the server.
+	Client *mcp.Client
+
+	// Health reports why the server is unhealthy, or nil if it is usable.
+	// Unhealthy backends are left out of lists and refuse calls. A nil
+	// Health means the backend is always considered healthy.
+	Health func() error
+}
+
+// healthErr returns why the backend is unhealthy, or nil.
+func (b *Backend) healthErr() error {
+	if b.Health == nil {
+		return nil
+	}
+	return b.Health()
+}
+
+// Options configures a Gateway.
+type Options struct {
+	// Info identifies the gateway to its clients.
+	Info mcp.Implementation
+
+	// Logger receives warnings about failing backends. May be nil.
+	Logger *zap.Logger
+}
+
+// Gateway serves the union of the tools, resources and prompts of its
+// backends and routes each request to the backend that owns it.
+type Gateway struct {
+	backends []*Backend
+	byName   map[string]*Backend
+	info     mcp.Implementation
+	logger   *zap.Logger
+
+	mu        sync.Mutex
+	resources map[string]*Backend
+	templates map[*Backend][]string
+	sessions  map[*mcp.Server]struct{}
+}
+
+// New creates a gateway over the given backends. Backend names must be
+// unique and must not contain Separator.
+func New(backends []*Backend, opts *Options) (*Gateway, error) {
+	g := &Gateway{
+		byName:    make(map[string]*Backend),
+		info:      mcp.Implementation{Name: "mcp-adapter-gateway", Version: "dev"},
+		logger:    zap.NewNop(),
+		resources: make(map[string]*Backend),
+		templates: make(map[*Backend][]string),
+		sessions:  make(map[*mcp.Server]struct{}),
+	}
+	if opts != nil {
+		if opts.Info.Name != "" {
+			g.info = opts.Info
+		}
+		if opts.Logger != nil {
+			g.logger = opts.Logger
+		}
+	}
+
+	for _, b := range backends {
+		if b.Name == "" || strings.Contains(b.Name, Separator) {
+			return nil, fmt.Errorf("invalid backend name %q", b.Name)
+		}
+		if _, exists := g.byName[b.Name]; exists {
+			return nil, fmt.Errorf("duplicate backend %q", b.Name)
+		}
+		g.byName[b.Name] = b
+		g.backends = append(g.backends, b)
+		g.watch(b)
+	}
+
+	return g, nil
+}
+
+// Serve runs one client session over the transport until it ends.
+func (g *Gateway) Serve(t mcp.Transport) error {
+	srv := mcp.NewServer(t, &mcp.ServerOptions{
+		Info:         g.info,
+		Capabilities: g.capabilities(),
+	})
+
+	srv.HandleRequest(mcp.MethodToolsList, g.listTools)
+	srv.HandleRequest(mcp.MethodToolsCall, g.forwardNamed(srv, mcp.MethodToolsCall, "tool"))
+	srv.HandleRequest(mcp.MethodPromptsList, g.listPrompts)
+	srv.HandleRequest(mcp.MethodPromptsGet, g.forwardNamed(srv, mcp.MethodPromptsGet, "prompt"))
+	srv.HandleRequest(mcp.MethodResourcesList, g.listResources)
+	srv.HandleRequest(mcp.MethodResourceTemplatesList, g.listResourceTemplates)
+	srv.HandleRequest(mcp.MethodResourcesRead, g.forwardResource(srv, mcp.MethodResourcesRead))
+	srv.HandleRequest(mcp.MethodResourcesSubscribe, g.forwardResource(srv, mcp.MethodResourcesSubscribe))
+	srv.HandleRequest(mcp.MethodResourcesUnsubscribe, g.forwardResource(srv, mcp.MethodResourcesUnsubscribe))
+	srv.HandleRequest(mcp.MethodLoggingSetLevel, g.setLogLevel)
+
+	g.mu.Lock()
+	g.sessions[srv] = struct{}{}
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.sessions, srv)
+		g.mu.Unlock()
+	}()
+
+	return srv.Serve()
+}
+
+// capabilities advertises the union of the backends' capabilities. List
+// changes are always announced since backends may come and go.
+func (g *Gateway) capabilities() mcp.ServerCapabilities {
+	caps := mcp.ServerCapabilities{
+		Tools:     &mcp.ToolsCapability{ListChanged: true},
+		Resources: &mcp.ResourcesCapability{ListChanged: true},
+		Prompts:   &mcp.PromptsCapability{ListChanged: true},
+	}
+	for _, b := range g.backends {
+		bc := b.Client.Capabilities()
+		if bc.Resources != nil && bc.Resources.Subscribe {
+			caps.Resources.Subscribe = true
+		}
+		if bc.Logging != nil {
+			caps.Logging = &struct{}{}
+		}
+	}
+	return caps
+}
+
+// watch relays a backend's change and log notifications to all sessions.
+func (g *Gateway) watch(b *Backend) {
+	for _, method := range []string{
+		mcp.NotificationToolsChanged,
+		mcp.NotificationPromptsChanged,
+		mcp.NotificationResourcesChanged,
+		mcp.NotificationResourceUpdated,
+		mcp.NotificationMessage,
+	} {
+		b.Client.OnNotification(method, func(method string, params json.RawMessage) {
+			// Handlers run on the backend's receive loop and must not block.
+			go g.broadcast(method, params)
+		})
+	}
+}
+
+// relayProgress asks the backend for progress on a forwarded request if the
+// session asked for it, and passes the reports to that session under its
+// own token. The backend sees a token of the backend client's choosing, as
+// session tokens are only unique within a session. The returned function
+// sends any reports still queued and must be called before the response.
+func relayProgress(ctx context.Context, srv *mcp.Server, params json.RawMessage) (context.Context, func()) {
+	token := mcp.ProgressToken(params)
+	if token == nil {
+		return ctx, func() {}
+	}
+
+	reports := make(chan mcp.ProgressParams, 64)
+	finished := make(chan struct{})
+	relayed := make(chan struct{})
+	go func() {
+		defer close(relayed)
+		notify := func(p mcp.ProgressParams) {
+			p.ProgressToken = token
+			_ = srv.Notify(mcp.NotificationProgress, p)
+		}
+		for {
+			select {
+			case p := <-reports:
+				notify(p)
+			case <-finished:
+				for {
+					select {
+					case p := <-reports:
+						notify(p)
+					default:
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	ctx = mcp.WithProgress(ctx, func(p mcp.ProgressParams) {
+		// Progress runs on the backend's receive loop and must not block.
+		select {
+		case reports <- p:
+		default:
+		}
+	})
+	return ctx, func() {
+		close(finished)
+		<-relayed
+	}
+}
+
+func (g *Gateway) broadcast(method string, params json.RawMessage) {
+	g.mu.Lock()
+	sessions := make([]*mcp.Server, 0, len(g.sessions))
+	for s := range g.sessions {
+		sessions = append(sessions, s)
+	}
+	g.mu.Unlock()
+
+	for _, s := range sessions {
+		_ = s.Notify(method, params)
+	}
+}
+
+// each calls fn concurrently for every backend that passes the filter and is
+// still connected and healthy. Failures are logged and the backend is left
+// out.
+func (g *Gateway) each(ctx context.Context, what string, filter func(mcp.ServerCapabilities) bool, fn func(context.Context, *Backend) error) error {
+	var wg sync.WaitGroup
+	for _, b := range g.backends {
+		if !filter(b.Client.Capabilities()) {
+			continue
+		}
+		select {
+		case <-b.Client.Done():
+			continue
+		default:
+		}
+		if err := b.healthErr(); err != nil {
+			g.logger.Debug("skipping unhealthy backend",
+				zap.String("server", b.Name),
+				zap.String("request", what),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		wg.Add(1)
+		go func(b *Backend) {
+			defer wg.Done()
+			err := fn(ctx, b)
+			if err == nil {
+				return
+			}
+			log := g.logger.Warn
+			var rpcErr *mcp.Error
+			if errors.As(err, &rpcErr) && rpcErr.Code == mcp.ErrMethodNotFound {
+				// Optional methods such as resources/templates/list.
+				log = g.logger.Debug
+			}
+			log("backend request failed",
+				zap.String("server", b.Name),
+				zap.String("request", what),
+				zap.Error(err),
+			)
+		}(b)
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+func hasTools(c mcp.ServerCapabilities) bool     { return c.Tools != nil }
+func hasPrompts(c mcp.ServerCapabilities) bool   { return c.Prompts != nil }
+func hasResources(c mcp.ServerCapabilities) bool { return c.Resources != nil }
+func hasLogging(c mcp.ServerCapabilities) bool   { return c.Logging != nil }
+
+func (g *Gateway) listTools(ctx context.Context, _ json.RawMessage) (interface{}, error) {
+	lists := make(map[*Backend][]mcp.Tool)
+	var mu sync.Mutex
+
+	err := g.each(ctx, mcp.MethodToolsList, hasTools, func(ctx context.Context, b *Backend) error {
+		tools, err := b.Client.ListTools(ctx)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		lists[b] = tools
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := mcp.ListToolsResult{Tools: []mcp.Tool{}}
+	for _, b := range g.backends {
+		for _, tool := range lists[b] {
+			tool.Name = b.Name + Separator + tool.Name
+			result.Tools = append(result.Tools, tool)
+		}
+	}
+	return result, nil
+}
+
+func (g *Gateway) listPrompts(ctx context.Context, _ json.RawMessage) (interface{}, error) {
+	lists := make(map[*Backend][]mcp.Prompt)
+	var mu sync.Mutex
+
+	err := g.each(ctx, mcp.MethodPromptsList, hasPrompts, func(ctx context.Context, b *Backend) error {
+		prompts, err := b.Client.ListPrompts(ctx)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		lists[b] = prompts
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := mcp.ListPromptsResult{Prompts: []mcp.Prompt{}}
+	for _, b := range g.backends {
+		for _, prompt := range lists[b] {
+			prompt.Name = b.Name + Separator + prompt.Name
+			result.Prompts = append(result.Prompts, prompt)
+		}
+	}
+	return result, nil
+}
+
+// forwardNamed returns a handler that routes a request whose "name"
+// parameter carries a backend prefix. The remaining parameters, including
+// _meta, are passed through, and the backend's progress is relayed to srv.
+func (g *Gateway) forwardNamed(srv *mcp.Server, method, kind string) mcp.RequestHandler {
+	return func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(params, &fields); err != nil {
+			return nil, &mcp.Error{Code: mcp.ErrInvalidParams, Message: "invalid params: " + err.Error()}
+		}
+
+		var name string
+		if err := json.Unmarshal(fields["name"], &name); err != nil {
+			return nil, &mcp.Error{Code: mcp.ErrInvalidParams, Message: "missing " + kind + " name"}
+		}
+
+		b, local, ok := g.route(name)
+		if !ok {
+			return nil, &mcp.Error{Code: mcp.ErrInvalidParams, Message: fmt.Sprintf("unknown %s: %s", kind, name)}
+		}
+
+		if err := b.healthErr(); err != nil {
+			return nil, fmt.Errorf("server %s is unhealthy: %w", b.Name, err)
+		}
+		fields["name"], _ = json.Marshal(local)
+
+		ctx, flush := relayProgress(ctx, srv, params)
+		defer flush()
+		var result json.RawMessage
+		if err := b.Client.Call(ctx, method, fields, &result); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+}
+
+// route splits a prefixed name into its backend and the backend's own name.
+func (g *Gateway) route(name string) (*Backend, string, bool) {
+	server, local, ok := strings.Cut(name, Separator)
+	if !ok || local == "" {
+		return nil, "", false
+	}
+	b, ok := g.byName[server]
+	return b, local, ok
+}
+
+func (g *Gateway) listResources(ctx context.Context, _ json.RawMessage) (interface{}, error) {
+	lists := make(map[*Backend][]mcp.Resource)
+	var mu sync.Mutex
+
+	err := g.each(ctx, mcp.MethodResourcesList, hasResources, func(ctx context.Context, b *Backend) error {
+		resources, err := b.Client.ListResources(ctx)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		lists[b] = resources
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := mcp.ListResourcesResult{Resources: []mcp.Resource{}}
+	g.mu.Lock()
+	g.resources = make(map[string]*Backend)
+	for _, b := range g.backends {
+		for _, r := range lists[b] {
+			if _, taken := g.resources[r.URI]; !taken {
+				g.resources[r.URI] = b
+			}
+			result.Resources = append(result.Resources, r)
+		}
+	}
+	g.mu.Unlock()
+	return result, nil
+}
+
+func (g *Gateway) listResourceTemplates(ctx context.Context, _ json.RawMessage) (interface{}, error) {
+	lists := make(map[*Backend][]mcp.ResourceTemplate)
+	var mu sync.Mutex
+
+	err := g.each(ctx, mcp.MethodResourceTemplatesList, hasResources, func(ctx context.Context, b *Backend) error {
+		templates, err := b.Client.ListResourceTemplates(ctx)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		lists[b] = templates
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := mcp.ListResourceTemplatesResult{ResourceTemplates: []mcp.ResourceTemplate{}}
+	g.mu.Lock()
+	for _, b := range g.backends {
+		prefixes := make([]string, 0, len(lists[b]))
+		for _, t := range lists[b] {
+			prefix, _, _ := strings.Cut(t.URITemplate, "{")
+			prefixes = append(prefixes, prefix)
+			result.ResourceTemplates = append(result.ResourceTemplates, t)
+		}
+		g.templates[b] = prefixes
+	}
+	g.mu.Unlock()
+	return result, nil
+}
+
+// forwardResource returns a handler that routes a URI-addressed request to
+// the backend owning the resource and relays the backend's progress to srv.
+func (g *Gateway) forwardResource(srv *mcp.Server, method string) mcp.RequestHandler {
+	return func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		var p mcp.ResourceParams
+		if err := json.Unmarshal(params, &p); err != nil || p.URI == "" {
+			return nil, &mcp.Error{Code: mcp.ErrInvalidParams, Message: "missing resource uri"}
+		}
+
+		b := g.resourceOwner(p.URI)
+		if b == nil {
+			// The client may not have listed resources through us yet.
+			if _, err := g.listResources(ctx, nil); err != nil {
+				return nil, err
+			}
+			if _, err := g.listResourceTemplates(ctx, nil); err != nil {
+				return nil, err
+			}
+			b = g.resourceOwner(p.URI)
+		}
+		if b == nil {
+			return nil, &mcp.Error{Code: mcp.ErrInvalidParams, Message: "unknown resource: " + p.URI}
+		}
+
+		ctx, flush := relayProgress(ctx, srv, params)
+		defer flush()
+		var result json.RawMessage
+		if err := b.Client.Call(ctx, method, params, &result); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+}
+
+// resourceOwner finds the backend for a URI, first among listed resources
+// and then by the longest matching resource template prefix.
+func (g *Gateway) resourceOwner(uri string) *Backend {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if b, ok := g.resources[uri]; ok {
+		return b
+	}
+
+	var (
+		owner *Backend
+		best  int
+	)
+	for b, prefixes := range g.templates {
+		for _, prefix := range prefixes {
+			if len(prefix) > best && strings.HasPrefix(uri, prefix) {
+				owner, best = b, len(prefix)
+			}
+		}
+	}
+	return owner
+}
+
+func (g *Gateway) setLogLevel(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	err := g.each(ctx, mcp.MethodLoggingSetLevel, hasLogging, func(ctx context.Context, b *Backend) error {
+		return b.Client.Call(ctx, mcp.MethodLoggingSetLevel, params, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return struct{}{}, nil
+}
@@ -0,0 +1,241 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xenixo/mcp-adapter/internal/mcp"
+)
+
+// newBackend starts an in-memory server offering an "echo" tool, a prompt
+// and one resource, and returns an initialized backend connected to it.
+func newBackend(t *testing.T, name string) (*Backend, *mcp.Server) {
+	t.Helper()
+	clientEnd, serverEnd := mcp.NewPipe()
+
+	srv := mcp.NewServer(serverEnd, &mcp.ServerOptions{
+		Info: mcp.Implementation{Name: name, Version: "1.0.0"},
+		Capabilities: mcp.ServerCapabilities{
+			Tools:     &mcp.ToolsCapability{},
+			Prompts:   &mcp.PromptsCapability{},
+			Resources: &mcp.ResourcesCapability{},
+		},
+	})
+	srv.HandleRequest(mcp.MethodToolsList, func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return mcp.ListToolsResult{Tools: []mcp.Tool{{Name: "echo", InputSchema: json.RawMessage(`{"type":"object"}`)}}}, nil
+	})
+	srv.HandleRequest(mcp.MethodToolsCall, func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		var p mcp.CallToolParams
+		json.Unmarshal(params, &p)
+		return mcp.CallToolResult{Content: []mcp.Content{{Type: "text", Text: name + ":" + p.Name + ":" + p.Arguments["msg"].(string)}}}, nil
+	})
+	srv.HandleRequest(mcp.MethodPromptsList, func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return mcp.ListPromptsResult{Prompts: []mcp.Prompt{{Name: "greet"}}}, nil
+	})
+	srv.HandleRequest(mcp.MethodResourcesList, func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return mcp.ListResourcesResult{Resources: []mcp.Resource{{URI: name + "://doc", Name: "doc"}}}, nil
+	})
+	srv.HandleRequest(mcp.MethodResourcesRead, func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		var p mcp.ResourceParams
+		json.Unmarshal(params, &p)
+		return mcp.ReadResourceResult{Contents: []mcp.ResourceContents{{URI: p.URI, Text: "from " + name}}}, nil
+	})
+	go srv.Serve()
+
+	client := mcp.NewClient(clientEnd, &mcp.ClientOptions{RequestTimeout: 2 * time.Second})
+	if _, err := client.Initialize(context.Background()); err != nil {
+		t.Fatalf("backend %s: Initialize() error = %v", name, err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	return &Backend{Name: name, Client: client}, srv
+}
+
+func newGatewayClient(t *testing.T, g *Gateway) *mcp.Client {
+	t.Helper()
+	clientEnd, serverEnd := mcp.NewPipe()
+	go g.Serve(serverEnd)
+
+	client := mcp.NewClient(clientEnd, &mcp.ClientOptions{RequestTimeout: 2 * time.Second})
+	if _, err := client.Initialize(context.Background()); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestGatewayAggregatesAndRoutes(t *testing.T) {
+	alpha, _ := newBackend(t, "alpha")
+	beta, _ := newBackend(t, "beta")
+
+	g, err := New([]*Backend{alpha, beta}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	client := newGatewayClient(t, g)
+	ctx := context.Background()
+
+	tools, err := client.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("ListTools() error = %v", err)
+	}
+	if len(tools) != 2 || tools[0].Name != "alpha__echo" || tools[1].Name != "beta__echo" {
+		t.Errorf("ListTools() = %+v, want alpha__echo and beta__echo", tools)
+	}
+
+	result, err := client.CallTool(ctx, "beta__echo", map[string]interface{}{"msg": "hi"})
+	if err != nil {
+		t.Fatalf("CallTool() error = %v", err)
+	}
+	if len(result.Content) != 1 || result.Content[0].Text != "beta:echo:hi" {
+		t.Errorf("CallTool() content = %+v, want beta:echo:hi", result.Content)
+	}
+
+	if _, err := client.CallTool(ctx, "gamma__echo", nil); err == nil {
+		t.Error("CallTool() on an unknown server should fail")
+	}
+
+	prompts, err := client.ListPrompts(ctx)
+	if err != nil {
+		t.Fatalf("ListPrompts() error = %v", err)
+	}
+	if len(prompts) != 2 || prompts[0].Name != "alpha__greet" {
+		t.Errorf("ListPrompts() = %+v, want prefixed prompts", prompts)
+	}
+
+	// Reading before listing makes the gateway discover the owner itself.
+	read, err := client.ReadResource(ctx, "beta://doc")
+	if err != nil {
+		t.Fatalf("ReadResource() error = %v", err)
+	}
+	if len(read.Contents) != 1 || read.Contents[0].Text != "from beta" {
+		t.Errorf("ReadResource() = %+v, want contents from beta", read.Contents)
+	}
+
+	if _, err := client.ReadResource(ctx, "nowhere://doc"); err == nil {
+		t.Error("ReadResource() on an unknown URI should fail")
+	}
+}
+
+func TestGatewayRelaysListChanged(t *testing.T) {
+	alpha, alphaServer := newBackend(t, "alpha")
+
+	g, err := New([]*Backend{alpha}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	client := newGatewayClient(t, g)
+
+	changed := make(chan struct{}, 1)
+	client.OnNotification(mcp.NotificationToolsChanged, func(method string, params json.RawMessage) {
+		changed <- struct{}{}
+	})
+
+	if err := alphaServer.Notify(mcp.NotificationToolsChanged, nil); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	select {
+	case <-changed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("tools/list_changed was not relayed")
+	}
+}
+
+func TestGatewayRelaysProgress(t *testing.T) {
+	alpha, alphaServer := newBackend(t, "alpha")
+	alphaServer.HandleRequest(mcp.MethodToolsCall, func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		token := mcp.ProgressToken(params)
+		if token == nil {
+			return nil, errors.New("no progress token")
+		}
+		for i := 1; i <= 2; i++ {
+			alphaServer.Notify(mcp.NotificationProgress, mcp.ProgressParams{ProgressToken: token, Progress: float64(i), Total: 2})
+		}
+		return mcp.CallToolResult{Content: []mcp.Content{{Type: "text", Text: "done"}}}, nil
+	})
+
+	g, err := New([]*Backend{alpha}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	caller := newGatewayClient(t, g)
+	other := newGatewayClient(t, g)
+
+	var leaked int32
+	other.OnNotification(mcp.NotificationProgress, func(method string, params json.RawMessage) {
+		atomic.AddInt32(&leaked, 1)
+	})
+
+	var reports []mcp.ProgressParams
+	ctx := mcp.WithProgress(context.Background(), func(p mcp.ProgressParams) {
+		reports = append(reports, p)
+	})
+	if _, err := caller.CallTool(ctx, "alpha"+Separator+"echo", nil); err != nil {
+		t.Fatalf("CallTool() error = %v", err)
+	}
+
+	// Progress precedes the response, so it has all arrived by now.
+	if len(reports) != 2 || reports[1].Progress != 2 || reports[1].Total != 2 {
+		t.Errorf("caller received progress %+v, want 1/2 and 2/2", reports)
+	}
+	if err := other.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+	if n := atomic.LoadInt32(&leaked); n != 0 {
+		t.Errorf("another session received %d progress notifications", n)
+	}
+}
+
+func TestGatewaySkipsUnhealthyBackends(t *testing.T) {
+	alpha, _ := newBackend(t, "alpha")
+	beta, _ := newBackend(t, "beta")
+
+	var unhealthy atomic.Bool
+	beta.Health = func() error {
+		if unhealthy.Load() {
+			return errors.New("ping timed out")
+		}
+		return nil
+	}
+
+	g, err := New([]*Backend{alpha, beta}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	client := newGatewayClient(t, g)
+	ctx := context.Background()
+
+	unhealthy.Store(true)
+	tools, err := client.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("ListTools() error = %v", err)
+	}
+	if len(tools) != 1 || tools[0].Name != "alpha__echo" {
+		t.Errorf("ListTools() = %+v, want only alpha__echo", tools)
+	}
+	if _, err := client.CallTool(ctx, "beta__echo", map[string]interface{}{"msg": "hi"}); err == nil || !strings.Contains(err.Error(), "unhealthy") {
+		t.Errorf("CallTool() on an unhealthy backend error = %v", err)
+	}
+
+	unhealthy.Store(false)
+	if _, err := client.CallTool(ctx, "beta__echo", map[string]interface{}{"msg": "hi"}); err != nil {
+		t.Errorf("CallTool() after recovery error = %v", err)
+	}
+}
+
+func TestNewRejectsBadNames(t *testing.T) {
+	alpha, _ := newBackend(t, "alpha")
+
+	if _, err := New([]*Backend{alpha, {Name: "alpha", Client: alpha.Client}}, nil); err == nil {
+		t.Error("New() should reject duplicate backend names")
+	}
+	if _, err := New([]*Backend{{Name: "a__b", Client: alpha.Client}}, nil); err == nil {
+		t.Error("New() should reject names containing the separator")
+	}
+}
@@ -0,0 +1,235 @@
+package launcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Health check defaults.
+const (
+	DefaultStartupTimeout   = 30 * time.Second
+	DefaultCheckInterval    = 30 * time.Second
+	DefaultCheckTimeout     = 5 * time.Second
+	DefaultFailureThreshold = 3
+)
+
+// readyRetryDelay is the pause between failed readiness checks.
+const readyRetryDelay = 200 * time.Millisecond
+
+// HealthCheck reports whether a server is usable, such as by sending an MCP
+// ping.
+type HealthCheck func(ctx context.Context) error
+
+// HealthOptions configures readiness and liveness checks. Zero values use
+// the defaults.
+type HealthOptions struct {
+	// StartupTimeout bounds how long a server may take to become ready.
+	StartupTimeout time.Duration `yaml:"startupTimeout,omitempty"`
+
+	// Interval is the time between liveness checks.
+	Interval time.Duration `yaml:"interval,omitempty"`
+
+	// Timeout bounds each liveness check.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+
+	// FailureThreshold is the number of consecutive failed checks after
+	// which a server is unhealthy.
+	FailureThreshold int `yaml:"failureThreshold,omitempty"`
+}
+
+// WithDefaults returns a copy of the options with zero values replaced by
+// the defaults. A nil receiver gives the defaults.
+func (o *HealthOptions) WithDefaults() HealthOptions {
+	var opts HealthOptions
+	if o != nil {
+		opts = *o
+	}
+	if opts.StartupTimeout <= 0 {
+		opts.StartupTimeout = DefaultStartupTimeout
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultCheckInterval
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultCheckTimeout
+	}
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = DefaultFailureThreshold
+	}
+	return opts
+}
+
+// HTTPCheck checks that an HTTP endpoint responds. Any response other than
+// a server error counts, since MCP endpoints may reject a bare GET.
+func HTTPCheck(url string) HealthCheck {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 500 {
+			return fmt.Errorf("%s responded %s", url, resp.Status)
+		}
+		return nil
+	}
+}
+
+// MarkReady records that a running process has answered MCP.
+func (p *Process) MarkReady() {
+	p.setHealth(StateReady, nil)
+}
+
+// setHealth moves a running process to StateReady or StateUnhealthy. It has
+// no effect once the process is stopping or has exited.
+func (p *Process) setHealth(state State, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.State.Alive() {
+		return
+	}
+	p.State = state
+	p.HealthError = err
+}
+
+// WaitReady runs check until it succeeds, retrying failures, and marks the
+// process ready. It fails if the server does not become ready within the
+// startup timeout or exits first.
+func (l *Launcher) WaitReady(ctx context.Context, proc *Process, check HealthCheck, opts *HealthOptions) error {
+	o := opts.WithDefaults()
+	ctx, cancel := context.WithTimeout(ctx, o.StartupTimeout)
+	defer cancel()
+
+	for {
+		err := check(ctx)
+		if err == nil {
+			proc.MarkReady()
+			l.logger.Info("server ready",
+				zap.String("server", proc.key),
+				zap.Duration("startup", time.Since(proc.Info().StartTime)),
+			)
+			return nil
+		}
+
+		select {
+		case <-proc.done:
+			return fmt.Errorf("server %q exited before it was ready: %w", proc.key, err)
+		case <-ctx.Done():
+			return fmt.Errorf("server %q was not ready within %s: %w", proc.key, o.StartupTimeout, err)
+		case <-time.After(readyRetryDelay):
+		}
+	}
+}
+
+// HealthMonitor runs periodic liveness checks against a server. It is
+// created by Launcher.MonitorHealth.
+type HealthMonitor struct {
+	name   string
+	proc   *Process
+	check  HealthCheck
+	opts   HealthOptions
+	logger *zap.Logger
+
+	mu       sync.Mutex
+	err      error
+	failures int
+}
+
+// MonitorHealth checks a server at the configured interval until ctx is
+// done or the process exits. After FailureThreshold consecutive failures
+// the server is unhealthy, until a check succeeds again. proc may be nil
+// for servers that were not launched, such as remote ones.
+func (l *Launcher) MonitorHealth(ctx context.Context, name string, proc *Process, check HealthCheck, opts *HealthOptions) *HealthMonitor {
+	m := &HealthMonitor{
+		name:   name,
+		proc:   proc,
+		check:  check,
+		opts:   opts.WithDefaults(),
+		logger: l.logger,
+	}
+	go m.run(ctx)
+	return m
+}
+
+// Err returns why the server is unhealthy, or nil if it is healthy.
+func (m *HealthMonitor) Err() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.err
+}
+
+func (m *HealthMonitor) run(ctx context.Context) {
+	var exited <-chan struct{}
+	if m.proc != nil {
+		exited = m.proc.done
+	}
+
+	ticker := time.NewTicker(m.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-exited:
+			return
+		case <-ticker.C:
+		}
+
+		checkCtx, cancel := context.WithTimeout(ctx, m.opts.Timeout)
+		err := m.check(checkCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+		m.record(err)
+	}
+}
+
+// record updates the health after a check.
+func (m *HealthMonitor) record(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err == nil {
+		if m.err != nil {
+			m.logger.Info("server is healthy again", zap.String("server", m.name))
+		}
+		m.failures = 0
+		m.err = nil
+		if m.proc != nil {
+			m.proc.setHealth(StateReady, nil)
+		}
+		return
+	}
+
+	m.failures++
+	m.logger.Debug("health check failed",
+		zap.String("server", m.name),
+		zap.Int("failures", m.failures),
+		zap.Error(err),
+	)
+	if m.failures < m.opts.FailureThreshold {
+		return
+	}
+	if m.err == nil {
+		m.logger.Warn("server is unhealthy",
+			zap.String("server", m.name),
+			zap.Int("failures", m.failures),
+			zap.Error(err),
+		)
+	}
+	m.err = fmt.Errorf("%d consecutive health checks failed: %w", m.failures, err)
+	if m.proc != nil {
+		m.proc.setHealth(StateUnhealthy, m.err)
+	}
+}
@@ -0,0 +1,222 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// fakeCheck is a health check whose result the test controls.
+type fakeCheck struct {
+	mu    sync.Mutex
+	err   error
+	calls int
+}
+
+func (f *fakeCheck) check(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	return f.err
+}
+
+func (f *fakeCheck) set(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+func (f *fakeCheck) callCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+func newRunningProcess() *Process {
+	return &Process{key: "test", State: StateRunning, done: make(chan struct{})}
+}
+
+func waitForProcessState(t *testing.T, proc *Process, want State) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for proc.Info().State != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("state = %s, want %s", proc.Info().State, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSetHealth(t *testing.T) {
+	unhealthy := errors.New("no answer")
+	tests := []struct {
+		from      State
+		to        State
+		err       error
+		want      State
+		wantError error
+	}{
+		{StateRunning, StateReady, nil, StateReady, nil},
+		{StateReady, StateUnhealthy, unhealthy, StateUnhealthy, unhealthy},
+		{StateUnhealthy, StateReady, nil, StateReady, nil},
+		{StateStopping, StateReady, nil, StateStopping, nil},
+		{StateStopped, StateUnhealthy, unhealthy, StateStopped, nil},
+		{StateFailed, StateReady, nil, StateFailed, nil},
+	}
+	for _, tt := range tests {
+		proc := &Process{State: tt.from}
+		proc.setHealth(tt.to, tt.err)
+		if info := proc.Info(); info.State != tt.want || info.HealthError != tt.wantError {
+			t.Errorf("setHealth(%s) from %s: state = %s, error = %v; want %s, %v",
+				tt.to, tt.from, info.State, info.HealthError, tt.want, tt.wantError)
+		}
+	}
+}
+
+func TestWaitReady(t *testing.T) {
+	l := NewLauncher(nil, zap.NewNop())
+
+	t.Run("ready after failures", func(t *testing.T) {
+		proc := newRunningProcess()
+		var calls int32
+		check := func(context.Context) error {
+			if atomic.AddInt32(&calls, 1) < 3 {
+				return errors.New("connection refused")
+			}
+			return nil
+		}
+		if err := l.WaitReady(context.Background(), proc, check, nil); err != nil {
+			t.Fatalf("WaitReady() error = %v", err)
+		}
+		if got := proc.Info().State; got != StateReady {
+			t.Errorf("state = %s, want %s", got, StateReady)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		proc := newRunningProcess()
+		check := &fakeCheck{err: errors.New("connection refused")}
+		err := l.WaitReady(context.Background(), proc, check.check, &HealthOptions{StartupTimeout: 100 * time.Millisecond})
+		if err == nil || !strings.Contains(err.Error(), "not ready within 100ms") {
+			t.Fatalf("WaitReady() error = %v, want a startup timeout", err)
+		}
+		if got := proc.Info().State; got != StateRunning {
+			t.Errorf("state = %s, want %s", got, StateRunning)
+		}
+	})
+
+	t.Run("exit", func(t *testing.T) {
+		proc := newRunningProcess()
+		close(proc.done)
+		check := &fakeCheck{err: errors.New("connection refused")}
+		err := l.WaitReady(context.Background(), proc, check.check, nil)
+		if err == nil || !strings.Contains(err.Error(), "exited before it was ready") {
+			t.Fatalf("WaitReady() error = %v, want an exit error", err)
+		}
+	})
+}
+
+func TestMonitorHealth(t *testing.T) {
+	l := NewLauncher(nil, zap.NewNop())
+	proc := newRunningProcess()
+	check := &fakeCheck{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m := l.MonitorHealth(ctx, "test", proc, check.check, &HealthOptions{
+		Interval:         10 * time.Millisecond,
+		FailureThreshold: 3,
+	})
+	waitForProcessState(t, proc, StateReady)
+
+	// Unhealthy only after three failures in a row.
+	check.set(errors.New("no answer"))
+	before := check.callCount()
+	waitForProcessState(t, proc, StateUnhealthy)
+	if n := check.callCount() - before; n < 3 {
+		t.Errorf("unhealthy after %d failed checks, want 3", n)
+	}
+	if m.Err() == nil || proc.Info().HealthError == nil {
+		t.Errorf("Err() = %v, HealthError = %v; want errors", m.Err(), proc.Info().HealthError)
+	}
+
+	// One good check brings it back.
+	check.set(nil)
+	waitForProcessState(t, proc, StateReady)
+	if m.Err() != nil || proc.Info().HealthError != nil {
+		t.Errorf("Err() = %v, HealthError = %v after recovery; want nil", m.Err(), proc.Info().HealthError)
+	}
+}
+
+func TestMonitorHealthStops(t *testing.T) {
+	l := NewLauncher(nil, zap.NewNop())
+	opts := &HealthOptions{Interval: 10 * time.Millisecond}
+
+	// assertStopped checks that no more checks are made.
+	assertStopped := func(t *testing.T, check *fakeCheck) {
+		t.Helper()
+		time.Sleep(30 * time.Millisecond)
+		calls := check.callCount()
+		time.Sleep(50 * time.Millisecond)
+		if check.callCount() != calls {
+			t.Errorf("monitor made %d more checks after it should have stopped", check.callCount()-calls)
+		}
+	}
+
+	t.Run("context", func(t *testing.T) {
+		check := &fakeCheck{}
+		ctx, cancel := context.WithCancel(context.Background())
+		l.MonitorHealth(ctx, "test", newRunningProcess(), check.check, opts)
+		time.Sleep(30 * time.Millisecond)
+		cancel()
+		assertStopped(t, check)
+	})
+
+	t.Run("exit", func(t *testing.T) {
+		check := &fakeCheck{}
+		proc := newRunningProcess()
+		l.MonitorHealth(context.Background(), "test", proc, check.check, opts)
+		time.Sleep(30 * time.Millisecond)
+		close(proc.done)
+		assertStopped(t, check)
+	})
+}
+
+func TestHTTPCheck(t *testing.T) {
+	tests := []struct {
+		status  int
+		wantErr bool
+	}{
+		{http.StatusOK, false},
+		{http.StatusMethodNotAllowed, false},
+		{http.StatusNotFound, false},
+		{http.StatusInternalServerError, true},
+		{http.StatusServiceUnavailable, true},
+	}
+	for _, tt := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+		}))
+		err := HTTPCheck(srv.URL)(context.Background())
+		srv.Close()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("HTTPCheck() for status %d error = %v, wantErr %v", tt.status, err, tt.wantErr)
+		}
+	}
+
+	// Nothing listening.
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+	if err := HTTPCheck(url)(context.Background()); err == nil {
+		t.Error("HTTPCheck() with nothing listening error = nil, want an error")
+	}
+}
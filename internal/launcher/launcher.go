@@ -15,8 +15,10 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/xenixo/mcp-adapter/internal/config"
+	"github.com/xenixo/mcp-adapter/internal/logs"
 	"github.com/xenixo/mcp-adapter/internal/manifest"
 	"github.com/xenixo/mcp-adapter/internal/runtime"
+	"github.com/xenixo/mcp-adapter/internal/sandbox"
 )
 
 // State represents the state of a launched server.
@@ -29,6 +31,18 @@ const (
 	StateStopping
 	StateStopped
 	StateFailed
+	// StateBackoff is an exited process that is about to be replaced by a
+	// restart.
+	StateBackoff
+	// StateDegraded is a crashed process that is not restarted because the
+	// server is crash-looping.
+	StateDegraded
+	// StateReady is a running process that has answered MCP: it completed
+	// the initialize handshake or its HTTP endpoint responds.
+	StateReady
+	// StateUnhealthy is a running process that has failed its liveness
+	// checks.
+	StateUnhealthy
 )
 
 func (s State) String() string {
@@ -43,11 +57,34 @@ func (s State) String() string {
 		return "stopped"
 	case StateFailed:
 		return "failed"
+	case StateBackoff:
+		return "backoff"
+	case StateDegraded:
+		return "degraded"
+	case StateReady:
+		return "ready"
+	case StateUnhealthy:
+		return "unhealthy"
 	default:
 		return "unknown"
 	}
 }
 
+// Alive reports whether the state is that of a running process, whether or
+// not it is known to be ready or healthy.
+func (s State) Alive() bool {
+	return s == StateRunning || s == StateReady || s == StateUnhealthy
+}
+
+// waitDelay bounds how long a process's output is still read once it has
+// exited or been killed. Descendants that inherited its stdout or stderr,
+// such as a browser started by the server, would otherwise hold up the
+// wait for as long as they run.
+const waitDelay = 5 * time.Second
+
+// killTimeout is how long Stop waits for a killed process to be reaped.
+const killTimeout = waitDelay + 5*time.Second
+
 // Process represents a launched MCP server process.
 type Process struct {
 	Server     *manifest.Server
@@ -60,8 +97,94 @@ type Process struct {
 	Stdin      io.WriteCloser
 	Stdout     io.ReadCloser
 	Stderr     io.ReadCloser
+	key        string
+	streams    []*logs.Stream
+	sandbox    *sandbox.Sandbox
+	cgroup     *cgroup
+	log        *logs.Writer
+	done       chan struct{}
 	cancelFunc context.CancelFunc
 	mu         sync.RWMutex
+
+	// HealthError is why the process was last found unhealthy.
+	HealthError error
+}
+
+// Key returns the name the process is tracked under by the launcher.
+func (p *Process) Key() string {
+	return p.key
+}
+
+// Done returns a channel that is closed when the process exits.
+func (p *Process) Done() <-chan struct{} {
+	return p.done
+}
+
+// Info is a point-in-time view of a process.
+type Info struct {
+	Key       string
+	PID       int
+	State     State
+	StartTime time.Time
+	StopTime  time.Time
+	ExitCode  int
+	Error     error
+
+	// HealthError is why the process was last found unhealthy.
+	HealthError error
+}
+
+// Info returns the process's current state.
+func (p *Process) Info() Info {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	info := Info{
+		Key:       p.key,
+		State:     p.State,
+		StartTime: p.StartTime,
+		StopTime:  p.StopTime,
+		ExitCode:  p.ExitCode,
+		Error:     p.Error,
+
+		HealthError: p.HealthError,
+	}
+	if p.Cmd != nil && p.Cmd.Process != nil {
+		info.PID = p.Cmd.Process.Pid
+	}
+	return info
+}
+
+// MarkRestart records a restart decision for an exited process: StateBackoff
+// if it is to be restarted, StateDegraded if it is left down after crashing
+// too often. It has no effect on a process that is still running.
+func (p *Process) MarkRestart(state State) {
+	select {
+	case <-p.done:
+	default:
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.State = state
+}
+
+// teeWriter also writes to log, if set.
+func teeWriter(w io.Writer, log *logs.Stream) io.Writer {
+	if log == nil {
+		return w
+	}
+	// The log never fails, so it goes first to see everything.
+	return io.MultiWriter(log, w)
+}
+
+// teeReader also logs what is read from r, if log is set.
+func teeReader(r io.ReadCloser, log *logs.Stream) io.ReadCloser {
+	if log == nil {
+		return r
+	}
+	return logs.TeeReader(r, log)
 }
 
 // Launcher handles MCP server process lifecycle.
@@ -102,6 +225,30 @@ type LaunchOptions struct {
 
 	// Stderr is the writer for stderr.
 	Stderr io.Writer
+
+	// Instance distinguishes concurrent processes of the same server.
+	// When set, the process is tracked as InstanceKey(server, instance).
+	Instance string
+
+	// Sandbox overrides the sandbox policy from the server's manifest.
+	Sandbox *sandbox.Policy
+
+	// Network overrides the network policy from the server's manifest.
+	Network *sandbox.NetworkPolicy
+
+	// Seccomp replaces the seccomp policy from the server's manifest.
+	Seccomp *sandbox.SeccompPolicy
+
+	// Limits caps the resources the server may use.
+	Limits *ResourceLimits
+}
+
+// InstanceKey returns the name an instance of a server is tracked under.
+func InstanceKey(serverName, instance string) string {
+	if instance == "" {
+		return serverName
+	}
+	return serverName + "/" + instance
 }
 
 // Launch starts an MCP server process.
@@ -109,10 +256,12 @@ func (l *Launcher) Launch(ctx context.Context, server *manifest.Server, opts *La
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
+	key := InstanceKey(server.Name, opts.Instance)
+
 	// Check if already running
-	if proc, ok := l.procs[server.Name]; ok {
-		if proc.State == StateRunning {
-			return nil, fmt.Errorf("server %q is already running", server.Name)
+	if proc, ok := l.procs[key]; ok {
+		if proc.Info().State.Alive() {
+			return nil, fmt.Errorf("server %q is already running", key)
 		}
 	}
 
@@ -161,6 +310,7 @@ func (l *Launcher) Launch(ctx context.Context, server *manifest.Server, opts *La
 	case manifest.ServerTypeBinary:
 		cmd = exec.CommandContext(cmdCtx, entrypoint, args...)
 	}
+	cmd.WaitDelay = waitDelay
 
 	// Set environment
 	cmd.Env = os.Environ()
@@ -180,11 +330,80 @@ func (l *Launcher) Launch(ctx context.Context, server *manifest.Server, opts *La
 		cmd.Dir = installDir
 	}
 
+	serverLog, err := logs.ForServer(l.cfg.BaseDir, server.Name)
+	if err != nil {
+		l.logger.Warn("server output will not be logged", zap.String("server", server.Name), zap.Error(err))
+	}
+
+	// Sandbox the server if its policies ask for it
+	network := server.Network
+	if opts.Network != nil {
+		network = opts.Network
+	}
+	seccomp := server.Seccomp
+	if opts.Seccomp != nil {
+		seccomp = opts.Seccomp
+	}
+	policy := server.Sandbox.Merge(opts.Sandbox)
+	if err := policy.Validate(); err != nil {
+		cancel()
+		return nil, err
+	}
+	if err := network.Validate(); err != nil {
+		cancel()
+		return nil, err
+	}
+	if err := seccomp.Validate(); err != nil {
+		cancel()
+		return nil, err
+	}
+	if err := opts.Limits.Validate(); err != nil {
+		cancel()
+		return nil, err
+	}
+	var networkWarning string
+	if network.EffectiveMode() != sandbox.NetworkFull && server.Transport != manifest.TransportStdio {
+		// The server must stay reachable on its port.
+		networkWarning = fmt.Sprintf("network policy %q is not applied to %s servers", network.EffectiveMode(), server.Transport)
+		network = nil
+	}
+	cg, rlimits, limitWarnings := applyLimits(key, opts.Limits)
+	sb, err := sandbox.Apply(cmd, &sandbox.Options{
+		Policy:      policy,
+		InstallDir:  installDir,
+		Executables: []string{rt.Path, entrypoint},
+		Network:     network,
+		Seccomp:     seccomp,
+		Rlimits:     rlimits,
+		RunDir:      filepath.Join(l.cfg.BaseDir, "run"),
+		OnViolation: func(source, msg string) {
+			l.logger.Warn("sandbox violation", zap.String("server", key), zap.String("violation", msg))
+			if serverLog != nil {
+				serverLog.Write(logs.Entry{Source: source, Level: "warning", Text: msg})
+			}
+		},
+	})
+	if err != nil {
+		cancel()
+		cg.Close()
+		return nil, fmt.Errorf("failed to sandbox server: %w", err)
+	}
+	// The sandbox may have set up the process attributes.
+	cg.attach(cmd)
+	if networkWarning != "" {
+		sb.Warnings = append(sb.Warnings, networkWarning)
+	}
+
 	// Set up I/O
 	proc := &Process{
 		Server:     server,
 		Cmd:        cmd,
 		State:      StateStarting,
+		key:        key,
+		sandbox:    sb,
+		cgroup:     cg,
+		log:        serverLog,
+		done:       make(chan struct{}),
 		cancelFunc: cancel,
 	}
 
@@ -194,31 +413,61 @@ func (l *Launcher) Launch(ctx context.Context, server *manifest.Server, opts *La
 		stdin, err := cmd.StdinPipe()
 		if err != nil {
 			cancel()
+			sb.Close()
+			cg.Close()
 			return nil, fmt.Errorf("failed to create stdin pipe: %w", err)
 		}
 		proc.Stdin = stdin
 	}
 
+	// Stderr is captured to the server's log, and so is stdout unless it
+	// carries the protocol.
+	var stdoutLog, stderrLog *logs.Stream
+	if serverLog != nil {
+		stderrLog = serverLog.Stream("stderr")
+		if server.Transport != manifest.TransportStdio {
+			stdoutLog = serverLog.Stream("stdout")
+		}
+	}
+	proc.streams = []*logs.Stream{stdoutLog, stderrLog}
+
 	if opts.Stdout != nil {
-		cmd.Stdout = opts.Stdout
+		cmd.Stdout = teeWriter(opts.Stdout, stdoutLog)
 	} else {
 		stdout, err := cmd.StdoutPipe()
 		if err != nil {
 			cancel()
+			sb.Close()
+			cg.Close()
 			return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
 		}
-		proc.Stdout = stdout
+		proc.Stdout = teeReader(stdout, stdoutLog)
 	}
 
 	if opts.Stderr != nil {
-		cmd.Stderr = opts.Stderr
+		cmd.Stderr = teeWriter(opts.Stderr, stderrLog)
 	} else {
 		stderr, err := cmd.StderrPipe()
 		if err != nil {
 			cancel()
+			sb.Close()
+			cg.Close()
 			return nil, fmt.Errorf("failed to create stderr pipe: %w", err)
 		}
-		proc.Stderr = stderr
+		proc.Stderr = teeReader(stderr, stderrLog)
+	}
+
+	for _, warning := range sb.Warnings {
+		l.logger.Warn("sandbox is incomplete", zap.String("server", server.Name), zap.String("reason", warning))
+		if serverLog != nil {
+			serverLog.Write(logs.Entry{Source: "sandbox", Level: "warning", Text: warning})
+		}
+	}
+	for _, warning := range limitWarnings {
+		l.logger.Warn("resource limits are incomplete", zap.String("server", server.Name), zap.String("reason", warning))
+		if serverLog != nil {
+			serverLog.Write(logs.Entry{Source: "limits", Level: "warning", Text: warning})
+		}
 	}
 
 	// Start process
@@ -230,6 +479,8 @@ func (l *Launcher) Launch(ctx context.Context, server *manifest.Server, opts *La
 
 	if err := cmd.Start(); err != nil {
 		cancel()
+		sb.Close()
+		cg.Close()
 		proc.State = StateFailed
 		proc.Error = err
 		return nil, fmt.Errorf("failed to start server: %w", err)
@@ -237,7 +488,7 @@ func (l *Launcher) Launch(ctx context.Context, server *manifest.Server, opts *La
 
 	proc.StartTime = time.Now()
 	proc.State = StateRunning
-	l.procs[server.Name] = proc
+	l.procs[key] = proc
 
 	// Monitor process in background
 	go l.monitorProcess(proc)
@@ -256,7 +507,7 @@ func (l *Launcher) Stop(serverName string, timeout time.Duration) error {
 	}
 
 	proc.mu.Lock()
-	if proc.State != StateRunning {
+	if !proc.State.Alive() {
 		proc.mu.Unlock()
 		return fmt.Errorf("server %q is not running (state: %s)", serverName, proc.State)
 	}
@@ -268,21 +519,20 @@ func (l *Launcher) Stop(serverName string, timeout time.Duration) error {
 		proc.Cmd.Process.Signal(syscall.SIGTERM)
 	}
 
-	// Wait for graceful shutdown
-	done := make(chan struct{})
-	go func() {
-		proc.Cmd.Wait()
-		close(done)
-	}()
-
+	// Wait for graceful shutdown; monitorProcess reaps the process
 	select {
-	case <-done:
+	case <-proc.done:
 		// Graceful shutdown
 	case <-time.After(timeout):
 		// Force kill
 		if proc.Cmd.Process != nil {
 			proc.Cmd.Process.Kill()
 		}
+		select {
+		case <-proc.done:
+		case <-time.After(killTimeout):
+			return fmt.Errorf("server %q did not exit after being killed", serverName)
+		}
 	}
 
 	proc.mu.Lock()
@@ -308,7 +558,7 @@ func (l *Launcher) ListRunning() []*Process {
 
 	var result []*Process
 	for _, proc := range l.procs {
-		if proc.State == StateRunning {
+		if proc.Info().State.Alive() {
 			result = append(result, proc)
 		}
 	}
@@ -334,14 +584,32 @@ func (l *Launcher) monitorProcess(proc *Process) {
 
 	proc.mu.Lock()
 	defer proc.mu.Unlock()
+	defer close(proc.done)
 
 	proc.StopTime = time.Now()
+	for _, stream := range proc.streams {
+		if stream != nil {
+			stream.Flush()
+		}
+	}
+	proc.sandbox.Close()
+
+	// A limit hit is reported as such rather than as a bare exit status.
+	var limitErr *LimitError
+	if err != nil {
+		limitErr = proc.cgroup.limitError(err)
+	}
+	limitWarning := proc.cgroup.limitWarning()
+	proc.cgroup.Close()
 
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
 			proc.ExitCode = exitErr.ExitCode()
 		}
 		proc.Error = err
+		if limitErr != nil {
+			proc.Error = limitErr
+		}
 		if proc.State != StateStopping && proc.State != StateStopped {
 			proc.State = StateFailed
 		} else {
@@ -352,8 +620,27 @@ func (l *Launcher) monitorProcess(proc *Process) {
 		proc.State = StateStopped
 	}
 
+	if limitErr != nil {
+		l.logger.Warn("server hit a resource limit",
+			zap.String("server", proc.key),
+			zap.String("limit", limitErr.Resource),
+			zap.String("reason", limitErr.Reason),
+		)
+		if proc.log != nil {
+			proc.log.Write(logs.Entry{Source: "limits", Level: "error", Text: limitErr.Reason})
+		}
+	}
+	if limitWarning != "" {
+		l.logger.Warn("server ran into a resource limit",
+			zap.String("server", proc.key),
+			zap.String("reason", limitWarning),
+		)
+		if proc.log != nil {
+			proc.log.Write(logs.Entry{Source: "limits", Level: "warning", Text: limitWarning})
+		}
+	}
 	l.logger.Info("server stopped",
-		zap.String("server", proc.Server.Name),
+		zap.String("server", proc.key),
 		zap.Int("exitCode", proc.ExitCode),
 		zap.Duration("runtime", proc.StopTime.Sub(proc.StartTime)),
 	)
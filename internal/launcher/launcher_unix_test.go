@@ -0,0 +1,83 @@
+//go:build unix
+
+package launcher
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/xenixo/mcp-adapter/internal/config"
+	"github.com/xenixo/mcp-adapter/internal/manifest"
+)
+
+// installScript installs a binary server that runs script.
+func installScript(t *testing.T, cfg *config.Config, name, script string) *manifest.Server {
+	t.Helper()
+	dir := cfg.ServerInstallPath(name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "server.sh"), []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return &manifest.Server{
+		Name:       name,
+		Type:       manifest.ServerTypeBinary,
+		Entrypoint: "server.sh",
+		Transport:  manifest.TransportStdio,
+	}
+}
+
+func TestStopWithLingeringChild(t *testing.T) {
+	cfg := config.New(t.TempDir())
+	pidFile := filepath.Join(cfg.BaseDir, "child.pid")
+	// The child keeps the server's stderr open after the server is gone.
+	server := installScript(t, cfg, "forker", "sleep 60 &\necho $! > "+pidFile+"\nexec sleep 60\n")
+	t.Cleanup(func() {
+		if data, err := os.ReadFile(pidFile); err == nil {
+			if pid, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil {
+				syscall.Kill(pid, syscall.SIGKILL)
+			}
+		}
+	})
+
+	l := NewLauncher(cfg, zap.NewNop())
+	var stderr bytes.Buffer
+	proc, err := l.Launch(context.Background(), server, &LaunchOptions{Stderr: &stderr})
+	if err != nil {
+		t.Fatalf("Launch() error = %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, err := os.Stat(pidFile); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("server did not start its child")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- l.Stop(proc.Key(), 100*time.Millisecond) }()
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Fatalf("Stop() error = %v", err)
+		}
+	case <-time.After(waitDelay + 5*time.Second):
+		t.Fatal("Stop() did not return while the child was running")
+	}
+	if got := proc.Info().State; got != StateStopped {
+		t.Errorf("state = %s, want %s", got, StateStopped)
+	}
+}
@@ -0,0 +1,123 @@
+package launcher
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ByteSize is an amount of memory, written in config.yaml as a number of
+// bytes or with a binary suffix such as "512M" or "2GiB".
+type ByteSize int64
+
+// byteUnits are the suffixes ByteSize accepts, from the largest.
+var byteUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"T", 1 << 40},
+	{"G", 1 << 30},
+	{"M", 1 << 20},
+	{"K", 1 << 10},
+}
+
+// UnmarshalText parses a size such as "512M".
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	s := strings.TrimSpace(string(text))
+	number, unit := strings.TrimSuffix(strings.ToUpper(s), "B"), int64(1)
+	for _, u := range byteUnits {
+		// "2G", "2GB" and "2GiB" are all the same.
+		if digits, ok := strings.CutSuffix(strings.TrimSuffix(number, "I"), u.suffix); ok {
+			number, unit = strings.TrimSpace(digits), u.size
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil || n < 0 || math.IsInf(n, 0) || math.IsNaN(n) {
+		return fmt.Errorf("invalid size %q (use bytes or a suffix such as 512M or 2G)", s)
+	}
+	*b = ByteSize(n * float64(unit))
+	return nil
+}
+
+// MarshalText formats the size with the largest exact suffix.
+func (b ByteSize) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+// String formats the size with the largest exact suffix, such as "512M".
+func (b ByteSize) String() string {
+	for _, u := range byteUnits {
+		if b != 0 && int64(b)%u.size == 0 {
+			return strconv.FormatInt(int64(b)/u.size, 10) + u.suffix
+		}
+	}
+	return strconv.FormatInt(int64(b), 10)
+}
+
+// ResourceLimits caps what a server may use. Zero values leave a resource
+// unlimited. File descriptors are limited with an rlimit; memory, CPU and
+// processes with a cgroup v2 of the server's own where the system lets
+// the launcher create one, falling back to an rlimit for memory.
+type ResourceLimits struct {
+	// Memory caps the memory of the server and its children, which may
+	// not swap beyond it.
+	Memory ByteSize `yaml:"memory,omitempty"`
+
+	// CPU caps the server's CPU time, in cores: 0.5 is half of one core.
+	CPU float64 `yaml:"cpu,omitempty"`
+
+	// Files caps the file descriptors each server process may open.
+	Files int `yaml:"files,omitempty"`
+
+	// Processes caps the processes and threads of the server and its
+	// children.
+	Processes int `yaml:"processes,omitempty"`
+}
+
+// IsZero reports whether no limit is set.
+func (l *ResourceLimits) IsZero() bool {
+	return l == nil || *l == ResourceLimits{}
+}
+
+// Validate checks that the limits are not negative and are large enough to
+// start a server.
+func (l *ResourceLimits) Validate() error {
+	if l == nil {
+		return nil
+	}
+	if l.Memory < 0 || l.CPU < 0 || l.Files < 0 || l.Processes < 0 {
+		return fmt.Errorf("resource limits must not be negative")
+	}
+	if l.Memory > 0 && l.Memory < 4<<20 {
+		return fmt.Errorf("memory limit %s is too low (at least 4M)", l.Memory)
+	}
+	if l.CPU > 0 && l.CPU < 0.01 {
+		return fmt.Errorf("cpu limit %g is too low (at least 0.01)", l.CPU)
+	}
+	if l.Files > 0 && l.Files < 16 {
+		return fmt.Errorf("file limit %d is too low (at least 16)", l.Files)
+	}
+	return nil
+}
+
+// LimitError is the exit of a server that hit one of its resource limits.
+type LimitError struct {
+	// Resource is the limit that was hit, such as "memory".
+	Resource string
+
+	// Reason describes what happened, such as "killed by the OOM killer".
+	Reason string
+
+	// Err is how the process exited.
+	Err error
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Reason, e.Err)
+}
+
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
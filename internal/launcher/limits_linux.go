@@ -0,0 +1,305 @@
+package launcher
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/xenixo/mcp-adapter/internal/sandbox"
+)
+
+// applyLimits works out how to enforce limits on a server: a cgroup of its
+// own where the system allows one, and rlimits set by the sandbox helper.
+// It returns a description of each limit that cannot be enforced.
+func applyLimits(key string, limits *ResourceLimits) (*cgroup, []sandbox.Rlimit, []string) {
+	if limits.IsZero() {
+		return nil, nil, nil
+	}
+
+	var rlimits []sandbox.Rlimit
+	if limits.Files > 0 {
+		rlimits = append(rlimits, sandbox.Rlimit{Resource: syscall.RLIMIT_NOFILE, Limit: uint64(limits.Files)})
+	}
+	if limits.Memory == 0 && limits.CPU == 0 && limits.Processes == 0 {
+		return nil, rlimits, nil
+	}
+
+	cg, missing, err := newCgroup(key, limits)
+	reason := func(controller string) string {
+		return fmt.Sprintf("the %s controller is not available", controller)
+	}
+	if err != nil {
+		reason = func(string) string { return err.Error() }
+		if limits.Memory > 0 {
+			missing = append(missing, "memory")
+		}
+		if limits.CPU > 0 {
+			missing = append(missing, "cpu")
+		}
+		if limits.Processes > 0 {
+			missing = append(missing, "pids")
+		}
+	}
+
+	var warnings []string
+	for _, controller := range missing {
+		switch controller {
+		case "memory":
+			// Each process is limited on its own instead, without
+			// counting shared memory or reporting a limit hit.
+			rlimits = append(rlimits, sandbox.Rlimit{Resource: syscall.RLIMIT_DATA, Limit: uint64(limits.Memory)})
+			warnings = append(warnings, fmt.Sprintf("no cgroup for the memory limit (%s): limiting each process's data with an rlimit", reason(controller)))
+		case "cpu":
+			warnings = append(warnings, fmt.Sprintf("no cgroup for the cpu limit (%s): CPU is not limited", reason(controller)))
+		case "pids":
+			warnings = append(warnings, fmt.Sprintf("no cgroup for the process limit (%s): processes are not limited", reason(controller)))
+		}
+	}
+	return cg, rlimits, warnings
+}
+
+// cgroupMount is where the cgroup v2 hierarchy is mounted.
+const cgroupMount = "/sys/fs/cgroup"
+
+// cgroup2Magic is the file system type of cgroup v2.
+const cgroup2Magic = 0x63677270
+
+// cgroupPeriod is the CPU period of cpu.max, in microseconds.
+const cgroupPeriod = 100000
+
+// accessWrite is W_OK, which checks for write access with access(2).
+const accessWrite = 0x2
+
+var (
+	cgroupOnce        sync.Once
+	cgroupParent      string
+	cgroupControllers map[string]bool
+	cgroupErr         error
+	cgroupSeq         atomic.Uint64
+)
+
+// serverCgroups returns the cgroup server cgroups are created in, and the
+// controllers enabled for them. The result is cached.
+func serverCgroups() (string, map[string]bool, error) {
+	cgroupOnce.Do(func() {
+		cgroupParent, cgroupControllers, cgroupErr = setupServerCgroups()
+	})
+	return cgroupParent, cgroupControllers, cgroupErr
+}
+
+func setupServerCgroups() (string, map[string]bool, error) {
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(cgroupMount, &fs); err != nil || fs.Type != cgroup2Magic {
+		return "", nil, fmt.Errorf("cgroup v2 is not mounted at %s", cgroupMount)
+	}
+	own, err := ownCgroup()
+	if err != nil {
+		return "", nil, err
+	}
+	return delegatedCgroup(cgroupMount, own)
+}
+
+// delegatedCgroup returns the cgroup server cgroups are created in, and
+// the controllers it passes on to them. Since cgroup v2 only lets a cgroup
+// without processes pass controllers on, they go beside the launcher's own
+// cgroup own, or below it for the root, rather than moving the launcher.
+// Only controllers the system has already enabled there are used.
+func delegatedCgroup(mount, own string) (string, map[string]bool, error) {
+	parent := filepath.Join(mount, filepath.Dir(own))
+
+	data, err := os.ReadFile(filepath.Join(parent, "cgroup.subtree_control"))
+	if err != nil {
+		return "", nil, err
+	}
+	available := make(map[string]bool)
+	for _, c := range strings.Fields(string(data)) {
+		if c == "memory" || c == "cpu" || c == "pids" {
+			available[c] = true
+		}
+	}
+	if len(available) == 0 {
+		return "", nil, fmt.Errorf("no memory, cpu or pids controller is enabled in %s", parent)
+	}
+	if err := syscall.Access(parent, accessWrite); err != nil {
+		return "", nil, fmt.Errorf("cannot create cgroups in %s; run mcp-adapter in a cgroup whose parent is delegated to you, such as with systemd-run --user --scope", parent)
+	}
+	return parent, available, nil
+}
+
+// ownCgroup returns the path of the launcher's cgroup in the v2 hierarchy.
+func ownCgroup() (string, error) {
+	f, err := os.Open("/proc/self/cgroup")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if path, ok := strings.CutPrefix(scanner.Text(), "0::"); ok {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("not in a cgroup v2")
+}
+
+// cgroup is a server's own cgroup, which enforces its memory, CPU and
+// process limits.
+type cgroup struct {
+	path   string
+	dir    *os.File
+	limits ResourceLimits
+}
+
+// newCgroup creates a cgroup for a server with the limits cgroups enforce,
+// returning the limits it could not apply.
+func newCgroup(key string, limits *ResourceLimits) (*cgroup, []string, error) {
+	parent, controllers, err := serverCgroups()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Named for the launcher too, as other processes' cgroups may be
+	// alongside.
+	name := fmt.Sprintf("mcp-adapter-%d-%s-%d", os.Getpid(), strings.ReplaceAll(key, "/", "-"), cgroupSeq.Add(1))
+	path := filepath.Join(parent, name)
+	if err := os.Mkdir(path, 0755); err != nil {
+		return nil, nil, fmt.Errorf("failed to create cgroup: %w", err)
+	}
+	cg := &cgroup{path: path, limits: *limits}
+
+	var missing []string
+	set := func(controller, file, value string) error {
+		if !controllers[controller] {
+			missing = append(missing, controller)
+			return nil
+		}
+		if err := os.WriteFile(filepath.Join(path, file), []byte(value), 0); err != nil {
+			return fmt.Errorf("failed to set %s: %w", file, err)
+		}
+		return nil
+	}
+
+	if limits.Memory > 0 {
+		err = set("memory", "memory.max", strconv.FormatInt(int64(limits.Memory), 10))
+		if err == nil && controllers["memory"] {
+			// Without swap the limit is hit rather than worked around, and
+			// the whole server is killed together.
+			os.WriteFile(filepath.Join(path, "memory.swap.max"), []byte("0"), 0)
+			os.WriteFile(filepath.Join(path, "memory.oom.group"), []byte("1"), 0)
+		}
+	}
+	if err == nil && limits.CPU > 0 {
+		quota := int64(limits.CPU * cgroupPeriod)
+		err = set("cpu", "cpu.max", fmt.Sprintf("%d %d", quota, cgroupPeriod))
+	}
+	if err == nil && limits.Processes > 0 {
+		err = set("pids", "pids.max", strconv.Itoa(limits.Processes))
+	}
+	if err == nil {
+		cg.dir, err = os.Open(path)
+	}
+	if err != nil {
+		cg.Close()
+		return nil, nil, err
+	}
+	return cg, missing, nil
+}
+
+// attach starts cmd in the cgroup, if there is one.
+func (c *cgroup) attach(cmd *exec.Cmd) {
+	if c == nil {
+		return
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.UseCgroupFD = true
+	cmd.SysProcAttr.CgroupFD = int(c.dir.Fd())
+}
+
+// limitError returns the limit the server's exit was caused by: the
+// memory limit, if the OOM killer acted in the cgroup and the server was
+// killed.
+func (c *cgroup) limitError(exitErr error) *LimitError {
+	if c == nil || !killed(exitErr) {
+		return nil
+	}
+	if n := c.event("memory.events", "oom_kill"); n > 0 {
+		return &LimitError{
+			Resource: "memory",
+			Reason:   fmt.Sprintf("killed by the OOM killer at the memory limit of %s", c.limits.Memory),
+			Err:      exitErr,
+		}
+	}
+	return nil
+}
+
+// limitWarning describes the process limit stopping the server from
+// forking, if it did. The server may have carried on, so its exit is not
+// put down to the limit.
+func (c *cgroup) limitWarning() string {
+	if c == nil {
+		return ""
+	}
+	if n := c.event("pids.events", "max"); n > 0 {
+		return fmt.Sprintf("process limit of %d reached (%d forks failed)", c.limits.Processes, n)
+	}
+	return ""
+}
+
+// killed reports whether a process exited on SIGKILL, as the OOM killer
+// leaves it.
+func killed(exitErr error) bool {
+	var ee *exec.ExitError
+	if !errors.As(exitErr, &ee) {
+		return false
+	}
+	status, ok := ee.Sys().(syscall.WaitStatus)
+	return ok && status.Signaled() && status.Signal() == syscall.SIGKILL
+}
+
+// event returns a counter from one of the cgroup's events files.
+func (c *cgroup) event(file, key string) int {
+	data, err := os.ReadFile(filepath.Join(c.path, file))
+	if err != nil {
+		return 0
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if value, ok := strings.CutPrefix(line, key+" "); ok {
+			n, _ := strconv.Atoi(value)
+			return n
+		}
+	}
+	return 0
+}
+
+// Close kills what is left of the server and removes the cgroup.
+func (c *cgroup) Close() error {
+	if c == nil {
+		return nil
+	}
+	if c.dir != nil {
+		c.dir.Close()
+	}
+	os.WriteFile(filepath.Join(c.path, "cgroup.kill"), []byte("1"), 0)
+
+	// The cgroup can be removed once the killed processes are gone.
+	var err error
+	for i := 0; i < 50; i++ {
+		if err = os.Remove(c.path); err == nil || os.IsNotExist(err) {
+			return nil
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return err
+}
@@ -0,0 +1,222 @@
+package launcher
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/xenixo/mcp-adapter/internal/sandbox"
+)
+
+func TestDelegatedCgroup(t *testing.T) {
+	mount := t.TempDir()
+	mkCgroup := func(path, subtree string) {
+		dir := filepath.Join(mount, path)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "cgroup.subtree_control"), []byte(subtree), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mkCgroup("", "cpuset cpu io memory pids")
+	mkCgroup("user.slice/app.slice", "memory pids")
+	mkCgroup("user.slice/app.slice/term.scope", "")
+	mkCgroup("system.slice", "io")
+	mkCgroup("system.slice/svc.service", "")
+
+	tests := []struct {
+		own     string
+		parent  string
+		want    map[string]bool
+		wantErr bool
+	}{
+		{"/", "", map[string]bool{"cpu": true, "memory": true, "pids": true}, false},
+		{"/user.slice/app.slice/term.scope", "user.slice/app.slice", map[string]bool{"memory": true, "pids": true}, false},
+		{"/system.slice/svc.service", "", nil, true},
+		{"/missing.scope/leaf", "", nil, true},
+	}
+	for _, tt := range tests {
+		parent, got, err := delegatedCgroup(mount, tt.own)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("delegatedCgroup(%s) error = %v, wantErr %v", tt.own, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if want := filepath.Join(mount, tt.parent); parent != want {
+			t.Errorf("delegatedCgroup(%s) parent = %s, want %s", tt.own, parent, want)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("delegatedCgroup(%s) controllers = %v, want %v", tt.own, got, tt.want)
+		}
+	}
+
+}
+
+func TestCgroupLimitReports(t *testing.T) {
+	exitErr := func(script string) error {
+		err := exec.Command("sh", "-c", script).Run()
+		if err == nil {
+			t.Fatalf("sh -c %q succeeded", script)
+		}
+		return err
+	}
+	sigkill := exitErr("kill -9 $$")
+	failed := exitErr("exit 1")
+
+	tests := []struct {
+		name        string
+		memory      string
+		pids        string
+		exitErr     error
+		wantLimit   string
+		wantWarning bool
+	}{
+		{"oom kill", "oom 1\noom_kill 1\n", "max 0\n", sigkill, "memory", false},
+		{"oom kill of a child", "oom 1\noom_kill 1\n", "max 0\n", failed, "", false},
+		{"forks refused", "oom_kill 0\n", "max 3\n", failed, "", true},
+		{"forks refused then killed", "oom_kill 0\n", "max 3\n", sigkill, "", true},
+		{"no limit hit", "oom_kill 0\n", "max 0\n", failed, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			os.WriteFile(filepath.Join(dir, "memory.events"), []byte(tt.memory), 0644)
+			os.WriteFile(filepath.Join(dir, "pids.events"), []byte(tt.pids), 0644)
+			cg := &cgroup{path: dir, limits: ResourceLimits{Memory: 64 << 20, Processes: 32}}
+
+			limitErr := cg.limitError(tt.exitErr)
+			switch {
+			case tt.wantLimit == "" && limitErr != nil:
+				t.Errorf("limitError() = %v, want nil", limitErr)
+			case tt.wantLimit != "" && (limitErr == nil || limitErr.Resource != tt.wantLimit):
+				t.Errorf("limitError() = %v, want a %s limit", limitErr, tt.wantLimit)
+			case limitErr != nil && !errors.Is(limitErr, tt.exitErr):
+				t.Errorf("limitError() does not wrap the exit error")
+			}
+			if warning := cg.limitWarning(); (warning != "") != tt.wantWarning {
+				t.Errorf("limitWarning() = %q, want a warning: %v", warning, tt.wantWarning)
+			}
+		})
+	}
+}
+
+// fakeServerCgroups makes serverCgroups return parent and controllers, or
+// err, for the rest of the test.
+func fakeServerCgroups(t *testing.T, parent string, controllers map[string]bool, err error) {
+	t.Helper()
+	cgroupOnce = sync.Once{}
+	cgroupOnce.Do(func() {
+		cgroupParent, cgroupControllers, cgroupErr = parent, controllers, err
+	})
+	t.Cleanup(func() { cgroupOnce = sync.Once{} })
+}
+
+func TestApplyLimits(t *testing.T) {
+	tests := []struct {
+		name         string
+		limits       *ResourceLimits
+		controllers  map[string]bool
+		cgroupErr    error
+		wantCgroup   bool
+		wantRlimits  []sandbox.Rlimit
+		wantWarnings []string
+	}{
+		{
+			name:   "none",
+			limits: &ResourceLimits{},
+		},
+		{
+			name:        "files only",
+			limits:      &ResourceLimits{Files: 256},
+			cgroupErr:   errors.New("unused"),
+			wantRlimits: []sandbox.Rlimit{{Resource: syscall.RLIMIT_NOFILE, Limit: 256}},
+		},
+		{
+			name:        "all controllers",
+			limits:      &ResourceLimits{Memory: 64 << 20, CPU: 0.5, Processes: 32},
+			controllers: map[string]bool{"memory": true, "cpu": true, "pids": true},
+			wantCgroup:  true,
+		},
+		{
+			name:        "missing controllers",
+			limits:      &ResourceLimits{Memory: 64 << 20, CPU: 0.5, Processes: 32},
+			controllers: map[string]bool{"pids": true},
+			wantCgroup:  true,
+			wantRlimits: []sandbox.Rlimit{{Resource: syscall.RLIMIT_DATA, Limit: 64 << 20}},
+			wantWarnings: []string{
+				"no cgroup for the memory limit (the memory controller is not available): limiting each process's data with an rlimit",
+				"no cgroup for the cpu limit (the cpu controller is not available): CPU is not limited",
+			},
+		},
+		{
+			name:      "no cgroups",
+			limits:    &ResourceLimits{Memory: 64 << 20, Files: 256, Processes: 32},
+			cgroupErr: errors.New("cgroup v2 is not mounted at /sys/fs/cgroup"),
+			wantRlimits: []sandbox.Rlimit{
+				{Resource: syscall.RLIMIT_NOFILE, Limit: 256},
+				{Resource: syscall.RLIMIT_DATA, Limit: 64 << 20},
+			},
+			wantWarnings: []string{
+				"no cgroup for the memory limit (cgroup v2 is not mounted at /sys/fs/cgroup): limiting each process's data with an rlimit",
+				"no cgroup for the process limit (cgroup v2 is not mounted at /sys/fs/cgroup): processes are not limited",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Server cgroups are plain directories here; their files only
+			// need to be writable.
+			parent := t.TempDir()
+			fakeServerCgroups(t, parent, tt.controllers, tt.cgroupErr)
+
+			cg, rlimits, warnings := applyLimits("test", tt.limits)
+			if cg != nil {
+				cg.dir.Close()
+				defer os.RemoveAll(cg.path)
+			}
+			if (cg != nil) != tt.wantCgroup {
+				t.Errorf("cgroup = %v, want one: %v", cg, tt.wantCgroup)
+			}
+			if cg != nil && tt.controllers["cpu"] {
+				if data, _ := os.ReadFile(filepath.Join(cg.path, "cpu.max")); string(data) != "50000 100000" {
+					t.Errorf("cpu.max = %q, want %q", data, "50000 100000")
+				}
+			}
+			if !reflect.DeepEqual(rlimits, tt.wantRlimits) {
+				t.Errorf("rlimits = %v, want %v", rlimits, tt.wantRlimits)
+			}
+			if !reflect.DeepEqual(warnings, tt.wantWarnings) {
+				t.Errorf("warnings = %q, want %q", warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestApplyLimitsFiles(t *testing.T) {
+	fakeServerCgroups(t, "", nil, errors.New("unused"))
+	cg, rlimits, warnings := applyLimits("test", &ResourceLimits{Files: 100})
+	if cg != nil || len(warnings) > 0 {
+		t.Fatalf("applyLimits() = %v, %q; want only rlimits", cg, warnings)
+	}
+
+	cmd := exec.Command("/bin/sh", "-c", "ulimit -n")
+	if _, err := sandbox.Apply(cmd, &sandbox.Options{Rlimits: rlimits}); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("limited command failed: %v: %s", err, out)
+	}
+	if got := strings.TrimSpace(string(out)); got != "100" {
+		t.Errorf("open file limit = %s, want 100", got)
+	}
+}
@@ -0,0 +1,37 @@
+//go:build !linux
+
+package launcher
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+
+	"github.com/xenixo/mcp-adapter/internal/sandbox"
+)
+
+// cgroup stands in for a server's cgroup, which only Linux has.
+type cgroup struct{}
+
+// applyLimits enforces nothing: resource limits are set by the Linux
+// sandbox helper and cgroups.
+func applyLimits(key string, limits *ResourceLimits) (*cgroup, []sandbox.Rlimit, []string) {
+	if limits.IsZero() {
+		return nil, nil, nil
+	}
+	return nil, nil, []string{fmt.Sprintf("resource limits are not supported on %s: the server is not limited", runtime.GOOS)}
+}
+
+func (c *cgroup) attach(cmd *exec.Cmd) {}
+
+func (c *cgroup) limitError(exitErr error) *LimitError {
+	return nil
+}
+
+func (c *cgroup) limitWarning() string {
+	return ""
+}
+
+func (c *cgroup) Close() error {
+	return nil
+}
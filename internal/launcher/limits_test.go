@@ -0,0 +1,103 @@
+package launcher
+
+import (
+	"os"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/xenixo/mcp-adapter/internal/sandbox"
+)
+
+func TestMain(m *testing.M) {
+	// Limited commands in the tests run this binary as the sandbox helper.
+	sandbox.Init()
+	os.Exit(m.Run())
+}
+
+func TestByteSizeYAML(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    ByteSize
+		wantErr bool
+	}{
+		{"1048576", 1 << 20, false},
+		{"512B", 512, false},
+		{"64K", 64 << 10, false},
+		{"512M", 512 << 20, false},
+		{"2G", 2 << 30, false},
+		{"1.5G", 3 << 29, false},
+		{"2GB", 2 << 30, false},
+		{"2GiB", 2 << 30, false},
+		{"2 g", 2 << 30, false},
+		{"1T", 1 << 40, false},
+		{"1I", 0, true},
+		{"1IB", 0, true},
+		{"G", 0, true},
+		{"-1G", 0, true},
+		{"lots", 0, true},
+		{"inf", 0, true},
+	}
+	for _, tt := range tests {
+		var limits ResourceLimits
+		err := yaml.Unmarshal([]byte("memory: "+tt.in), &limits)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("memory %q: error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && limits.Memory != tt.want {
+			t.Errorf("memory %q = %d, want %d", tt.in, limits.Memory, tt.want)
+		}
+	}
+}
+
+func TestByteSizeString(t *testing.T) {
+	tests := []struct {
+		size ByteSize
+		want string
+	}{
+		{0, "0"},
+		{1000, "1000"},
+		{4 << 10, "4K"},
+		{1536 << 20, "1536M"},
+		{2 << 30, "2G"},
+	}
+	for _, tt := range tests {
+		if got := tt.size.String(); got != tt.want {
+			t.Errorf("ByteSize(%d).String() = %q, want %q", int64(tt.size), got, tt.want)
+		}
+		out, err := yaml.Marshal(ResourceLimits{Memory: tt.size})
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		var back ResourceLimits
+		if err := yaml.Unmarshal(out, &back); err != nil || back.Memory != tt.size {
+			t.Errorf("round trip of %d = %d, %v", int64(tt.size), back.Memory, err)
+		}
+	}
+}
+
+func TestResourceLimitsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		limits  *ResourceLimits
+		wantErr bool
+	}{
+		{"nil", nil, false},
+		{"none", &ResourceLimits{}, false},
+		{"all", &ResourceLimits{Memory: 2 << 30, CPU: 1.5, Files: 4096, Processes: 256}, false},
+		{"smallest", &ResourceLimits{Memory: 4 << 20, CPU: 0.01, Files: 16, Processes: 1}, false},
+		{"negative memory", &ResourceLimits{Memory: -1}, true},
+		{"negative cpu", &ResourceLimits{CPU: -1}, true},
+		{"negative files", &ResourceLimits{Files: -1}, true},
+		{"negative processes", &ResourceLimits{Processes: -1}, true},
+		{"memory too low", &ResourceLimits{Memory: 1 << 20}, true},
+		{"cpu too low", &ResourceLimits{CPU: 0.001}, true},
+		{"files too low", &ResourceLimits{Files: 8}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.limits.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
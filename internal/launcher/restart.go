@@ -0,0 +1,169 @@
+package launcher
+
+import (
+	"fmt"
+	"time"
+)
+
+// RestartMode decides which exits a server is restarted after.
+type RestartMode string
+
+const (
+	// RestartNever leaves the server down after it exits.
+	RestartNever RestartMode = "never"
+	// RestartOnFailure restarts the server if it exits with a non-zero
+	// code, is killed by a signal, or fails to start.
+	RestartOnFailure RestartMode = "on-failure"
+	// RestartAlways restarts the server whenever it exits on its own.
+	RestartAlways RestartMode = "always"
+)
+
+// Restart policy defaults.
+const (
+	DefaultMaxRestarts    = 5
+	DefaultRestartWindow  = 10 * time.Minute
+	DefaultInitialBackoff = time.Second
+	DefaultMaxBackoff     = time.Minute
+)
+
+// RestartPolicy configures automatic restarts of a crashed server. The delay
+// before each restart doubles from InitialBackoff up to MaxBackoff. A server
+// that needs more than MaxRestarts restarts within Window is crash-looping:
+// it is left down and reported as degraded. Earlier restarts are forgotten
+// once the server stays up for Window/MaxRestarts.
+type RestartPolicy struct {
+	Mode           RestartMode   `json:"mode" yaml:"policy"`
+	MaxRestarts    int           `json:"maxRestarts,omitempty" yaml:"maxRestarts,omitempty"`
+	Window         time.Duration `json:"window,omitempty" yaml:"window,omitempty"`
+	InitialBackoff time.Duration `json:"initialBackoff,omitempty" yaml:"backoff,omitempty"`
+	MaxBackoff     time.Duration `json:"maxBackoff,omitempty" yaml:"maxBackoff,omitempty"`
+}
+
+// DefaultRestartPolicy restarts failed servers with the default limits.
+func DefaultRestartPolicy() *RestartPolicy {
+	return &RestartPolicy{Mode: RestartOnFailure}
+}
+
+// Validate checks the policy's mode and limits.
+func (p *RestartPolicy) Validate() error {
+	switch p.Mode {
+	case RestartNever, RestartOnFailure, RestartAlways:
+	default:
+		return fmt.Errorf("invalid restart policy %q (must be never, on-failure or always)", p.Mode)
+	}
+	if p.MaxRestarts < 0 || p.Window < 0 || p.InitialBackoff < 0 || p.MaxBackoff < 0 {
+		return fmt.Errorf("restart limits must not be negative")
+	}
+	return nil
+}
+
+// ShouldRestart reports whether an exit calls for a restart. A nil policy
+// never restarts.
+func (p *RestartPolicy) ShouldRestart(failed bool) bool {
+	if p == nil {
+		return false
+	}
+	switch p.Mode {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return failed
+	default:
+		return false
+	}
+}
+
+// Backoff returns the delay before the given restart, counting from 1.
+func (p *RestartPolicy) Backoff(restart int) time.Duration {
+	delay, limit := p.InitialBackoff, p.MaxBackoff
+	if delay <= 0 {
+		delay = DefaultInitialBackoff
+	}
+	if limit <= 0 {
+		limit = DefaultMaxBackoff
+	}
+	for i := 1; i < restart && delay < limit; i++ {
+		delay *= 2
+	}
+	if delay > limit {
+		delay = limit
+	}
+	return delay
+}
+
+func (p *RestartPolicy) maxRestarts() int {
+	if p.MaxRestarts > 0 {
+		return p.MaxRestarts
+	}
+	return DefaultMaxRestarts
+}
+
+func (p *RestartPolicy) window() time.Duration {
+	if p.Window > 0 {
+		return p.Window
+	}
+	return DefaultRestartWindow
+}
+
+// RestartHistory tracks recent restarts of a server under a policy to pace
+// them and detect crash loops.
+type RestartHistory struct {
+	policy *RestartPolicy
+	times  []time.Time
+}
+
+// NewRestartHistory creates an empty history for a policy.
+func NewRestartHistory(policy *RestartPolicy) *RestartHistory {
+	return &RestartHistory{policy: policy}
+}
+
+// Next decides on a restart after an exit at now. It returns the delay
+// before restarting, or ok=false if the server should stay down. degraded
+// reports that it stays down because it is crash-looping.
+func (h *RestartHistory) Next(now time.Time, failed bool) (delay time.Duration, ok, degraded bool) {
+	if !h.policy.ShouldRestart(failed) {
+		return 0, false, false
+	}
+
+	// Forget restarts that fell out of the window.
+	cutoff := now.Add(-h.policy.window())
+	recent := h.times[:0]
+	for _, t := range h.times {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+	h.times = recent
+
+	if len(h.times) >= h.policy.maxRestarts() {
+		return 0, false, true
+	}
+	h.times = append(h.times, now)
+	return h.policy.Backoff(len(h.times)), true, false
+}
+
+// Stable reports whether an instance that stayed up for uptime shows the
+// server is not crash-looping: it outlasted the average interval between
+// the restarts the policy allows.
+func (h *RestartHistory) Stable(uptime time.Duration) bool {
+	if h.policy == nil {
+		return false
+	}
+	return uptime >= h.policy.window()/time.Duration(h.policy.maxRestarts())
+}
+
+// Reset forgets all restarts, as after a stable run, so that the server
+// gets its full budget and the initial backoff again.
+func (h *RestartHistory) Reset() {
+	h.times = nil
+}
+
+// Limit returns the number of restarts allowed within the window.
+func (h *RestartHistory) Limit() int {
+	return h.policy.maxRestarts()
+}
+
+// Window returns the period over which restarts are counted.
+func (h *RestartHistory) Window() time.Duration {
+	return h.policy.window()
+}
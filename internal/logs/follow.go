@@ -0,0 +1,84 @@
+package logs
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// followInterval is how often Follow checks the log for new lines.
+const followInterval = 250 * time.Millisecond
+
+// Follow calls fn with every line appended to the log in dir after it is
+// called, following the log across rotations, until ctx is done.
+func Follow(ctx context.Context, dir string, fn func(line string)) error {
+	path := filepath.Join(dir, CurrentFile)
+
+	f, err := os.Open(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if f != nil {
+		if _, err := f.Seek(0, io.SeekEnd); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	defer func() {
+		if f != nil {
+			f.Close()
+		}
+	}()
+
+	var partial string
+	ticker := time.NewTicker(followInterval)
+	defer ticker.Stop()
+	for {
+		if f != nil {
+			partial = readLines(f, partial, fn)
+
+			// Once the log has been rotated, finish the old file and
+			// continue with the new one from the start.
+			info, err := os.Stat(path)
+			if err == nil {
+				if open, err := f.Stat(); err != nil || !os.SameFile(info, open) {
+					partial = readLines(f, partial, fn)
+					f.Close()
+					f = nil
+					partial = ""
+				}
+			}
+		}
+		if f == nil {
+			if next, err := os.Open(path); err == nil {
+				f = next
+				partial = readLines(f, "", fn)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// readLines calls fn with each complete line read from r, following on
+// from a partial line, and returns the new partial line.
+func readLines(r io.Reader, partial string, fn func(line string)) string {
+	reader := bufio.NewReader(r)
+	for {
+		chunk, err := reader.ReadString('\n')
+		partial += chunk
+		if err != nil {
+			return partial
+		}
+		fn(strings.TrimSuffix(partial, "\n"))
+		partial = ""
+	}
+}
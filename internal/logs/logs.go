@@ -0,0 +1,419 @@
+// Package logs captures the output of MCP servers to per-server log files
+// with rotation, and reads them back.
+package logs
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rotation defaults.
+const (
+	DefaultMaxSize  = 10 << 20
+	DefaultMaxAge   = 24 * time.Hour
+	DefaultMaxFiles = 5
+)
+
+// CurrentFile is the name of the file being written in a server's log
+// directory. Rotated files are named after the time of their first entry.
+const CurrentFile = "current.log"
+
+// TimeLayout is the timestamp that starts every line of a log.
+const TimeLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// rotatedLayout names rotated files so that they sort chronologically.
+const rotatedLayout = "2006-01-02T15-04-05.000"
+
+// maxLineLength bounds a line buffered from a stream without a newline.
+const maxLineLength = 64 << 10
+
+// Dir returns the log directory of a server.
+func Dir(baseDir, server string) string {
+	return filepath.Join(baseDir, "logs", server)
+}
+
+// Options configures rotation.
+type Options struct {
+	// MaxSize is the size in bytes at which the current file is rotated.
+	MaxSize int64
+
+	// MaxAge is how long the current file is written before it is rotated.
+	MaxAge time.Duration
+
+	// MaxFiles is the number of rotated files to keep.
+	MaxFiles int
+}
+
+// Entry is one line of a server's log.
+type Entry struct {
+	Time time.Time
+
+	// Source is where the entry came from, such as "stderr", "stdout" or
+	// "mcp" for notifications/message.
+	Source string
+
+	// Level is the severity, if the source reports one.
+	Level string
+
+	Text string
+}
+
+// String formats the entry as a log line without the trailing newline.
+func (e Entry) String() string {
+	source := e.Source
+	if e.Level != "" {
+		source += "/" + e.Level
+	}
+	return fmt.Sprintf("%s [%s] %s", e.Time.UTC().Format(TimeLayout), source, e.Text)
+}
+
+// ParseTime returns the timestamp at the start of a log line.
+func ParseTime(line string) (time.Time, bool) {
+	stamp, _, _ := strings.Cut(line, " ")
+	t, err := time.Parse(TimeLayout, stamp)
+	return t, err == nil
+}
+
+// Writer appends entries to a server's log, rotating the current file when
+// it grows too large or too old. It is safe for concurrent use, and
+// tolerates other processes writing to and rotating the same log.
+type Writer struct {
+	dir  string
+	opts Options
+
+	mu      sync.Mutex
+	file    *os.File
+	size    int64
+	started time.Time
+}
+
+var (
+	writersMu sync.Mutex
+	writers   = make(map[string]*Writer)
+)
+
+// ForServer returns the process-wide writer for a server's log, opening it
+// with the default rotation options on first use.
+func ForServer(baseDir, server string) (*Writer, error) {
+	dir := Dir(baseDir, server)
+
+	writersMu.Lock()
+	defer writersMu.Unlock()
+	if w, ok := writers[dir]; ok {
+		return w, nil
+	}
+	w, err := Open(dir, nil)
+	if err != nil {
+		return nil, err
+	}
+	writers[dir] = w
+	return w, nil
+}
+
+// Open opens a log in dir for appending. Nil options use the defaults.
+func Open(dir string, opts *Options) (*Writer, error) {
+	w := &Writer{dir: dir}
+	if opts != nil {
+		w.opts = *opts
+	}
+	if w.opts.MaxSize <= 0 {
+		w.opts.MaxSize = DefaultMaxSize
+	}
+	if w.opts.MaxAge <= 0 {
+		w.opts.MaxAge = DefaultMaxAge
+	}
+	if w.opts.MaxFiles <= 0 {
+		w.opts.MaxFiles = DefaultMaxFiles
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write appends an entry to the log.
+func (w *Writer) Write(e Entry) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	line := e.String() + "\n"
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return os.ErrClosed
+	}
+	if err := w.reopenIfMoved(); err != nil {
+		return err
+	}
+	if w.size > 0 && (w.size+int64(len(line)) > w.opts.MaxSize || e.Time.Sub(w.started) >= w.opts.MaxAge) {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+	if w.size == 0 {
+		w.started = e.Time
+	}
+
+	n, err := w.file.WriteString(line)
+	w.size += int64(n)
+	return err
+}
+
+// Stream returns a writer that logs each line written to it as an entry
+// from source. It never fails, so that a full disk cannot break the
+// server's output; call Flush to log a final unterminated line.
+func (w *Writer) Stream(source string) *Stream {
+	return &Stream{log: w, source: source}
+}
+
+// Close closes the current file.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// open opens the current file and works out when it was started.
+func (w *Writer) open() error {
+	path := filepath.Join(w.dir, CurrentFile)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open log: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	w.file = f
+	w.size = info.Size()
+	w.started = time.Now()
+	if w.size > 0 {
+		if t, ok := firstTime(path); ok {
+			w.started = t
+		}
+	}
+	return nil
+}
+
+// reopenIfMoved switches to a new current file if another process rotated
+// the log.
+func (w *Writer) reopenIfMoved() error {
+	info, err := os.Stat(filepath.Join(w.dir, CurrentFile))
+	if err == nil {
+		if open, err := w.file.Stat(); err == nil && os.SameFile(info, open) {
+			return nil
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	w.file.Close()
+	return w.open()
+}
+
+// rotate renames the current file after its first entry, starts a new one
+// and removes the oldest rotated files.
+func (w *Writer) rotate() error {
+	w.file.Close()
+	w.file = nil
+
+	current := filepath.Join(w.dir, CurrentFile)
+	rotated := filepath.Join(w.dir, w.started.UTC().Format(rotatedLayout)+".log")
+	if err := os.Rename(current, rotated); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to rotate log: %w", err)
+	}
+
+	files, err := rotatedFiles(w.dir)
+	if err == nil && len(files) > w.opts.MaxFiles {
+		for _, name := range files[:len(files)-w.opts.MaxFiles] {
+			os.Remove(name)
+		}
+	}
+
+	return w.open()
+}
+
+// Stream logs lines written to it as entries. It is returned by
+// Writer.Stream.
+type Stream struct {
+	log    *Writer
+	source string
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+// Write logs every complete line in p and buffers the rest.
+func (s *Stream) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.buf = append(s.buf, p...)
+	for {
+		i := bytes.IndexByte(s.buf, '\n')
+		if i < 0 {
+			break
+		}
+		s.emit(s.buf[:i])
+		s.buf = s.buf[i+1:]
+	}
+	if len(s.buf) > maxLineLength {
+		s.emit(s.buf)
+		s.buf = nil
+	}
+	return len(p), nil
+}
+
+// Flush logs a buffered unterminated line.
+func (s *Stream) Flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.buf) > 0 {
+		s.emit(s.buf)
+		s.buf = nil
+	}
+}
+
+func (s *Stream) emit(line []byte) {
+	line = bytes.TrimSuffix(line, []byte("\r"))
+	s.log.Write(Entry{Source: s.source, Text: string(line)})
+}
+
+// TeeReader returns a reader that logs everything read from r to s, for
+// output that is also consumed by the caller. Closing it closes r and
+// flushes s.
+func TeeReader(r io.ReadCloser, s *Stream) io.ReadCloser {
+	return &teeReader{r: r, s: s}
+}
+
+type teeReader struct {
+	r io.ReadCloser
+	s *Stream
+}
+
+func (t *teeReader) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	if n > 0 {
+		t.s.Write(p[:n])
+	}
+	if err != nil {
+		t.s.Flush()
+	}
+	return n, err
+}
+
+func (t *teeReader) Close() error {
+	t.s.Flush()
+	return t.r.Close()
+}
+
+// Files returns the log files in dir, oldest first.
+func Files(dir string) ([]string, error) {
+	files, err := rotatedFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	current := filepath.Join(dir, CurrentFile)
+	if _, err := os.Stat(current); err == nil {
+		files = append(files, current)
+	}
+	return files, nil
+}
+
+// rotatedFiles returns the rotated files in dir, oldest first.
+func rotatedFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || name == CurrentFile || filepath.Ext(name) != ".log" {
+			continue
+		}
+		files = append(files, filepath.Join(dir, name))
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Read returns the lines logged in dir at or after since, limited to the
+// last tail lines if tail is not negative.
+func Read(dir string, since time.Time, tail int) ([]string, error) {
+	files, err := Files(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for _, name := range files {
+		f, err := os.Open(name)
+		if err != nil {
+			if os.IsNotExist(err) {
+				// Rotated away while listing.
+				continue
+			}
+			return nil, err
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 0, 64<<10), 2*maxLineLength)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if !since.IsZero() {
+				if t, ok := ParseTime(line); ok && t.Before(since) {
+					continue
+				}
+			}
+			lines = append(lines, line)
+			if tail >= 0 && len(lines) > 2*tail+1024 {
+				lines = append(lines[:0], lines[len(lines)-tail:]...)
+			}
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if tail >= 0 && len(lines) > tail {
+		lines = lines[len(lines)-tail:]
+	}
+	return lines, nil
+}
+
+// firstTime returns the timestamp of the first line of a log file.
+func firstTime(path string) (time.Time, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return time.Time{}, false
+	}
+	defer f.Close()
+
+	buf := make([]byte, len(TimeLayout)+8)
+	n, _ := io.ReadFull(f, buf)
+	return ParseTime(string(buf[:n]))
+}
@@ -0,0 +1,142 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriterRotatesBySize(t *testing.T) {
+	dir := t.TempDir()
+	w, err := Open(dir, &Options{MaxSize: 200, MaxFiles: 2})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer w.Close()
+
+	start := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	for i := 0; i < 20; i++ {
+		e := Entry{Time: start.Add(time.Duration(i) * time.Second), Source: "stderr", Text: fmt.Sprintf("line %02d", i)}
+		if err := w.Write(e); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	files, err := Files(dir)
+	if err != nil {
+		t.Fatalf("Files() error = %v", err)
+	}
+	if len(files) != 3 || !strings.HasSuffix(files[2], CurrentFile) {
+		t.Fatalf("Files() = %v, want 2 rotated files and the current one", files)
+	}
+
+	lines, err := Read(dir, time.Time{}, -1)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if last := lines[len(lines)-1]; last != "2025-01-02T03:04:24.000Z [stderr] line 19" {
+		t.Errorf("last line = %q", last)
+	}
+	for i := 1; i < len(lines); i++ {
+		if lines[i-1] >= lines[i] {
+			t.Fatalf("lines out of order: %q before %q", lines[i-1], lines[i])
+		}
+	}
+}
+
+func TestWriterRotatesByAge(t *testing.T) {
+	dir := t.TempDir()
+	w, err := Open(dir, &Options{MaxAge: time.Hour})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer w.Close()
+
+	start := time.Now()
+	w.Write(Entry{Time: start, Source: "stderr", Text: "first"})
+	w.Write(Entry{Time: start.Add(30 * time.Minute), Source: "stderr", Text: "second"})
+	w.Write(Entry{Time: start.Add(2 * time.Hour), Source: "stderr", Text: "third"})
+
+	files, _ := Files(dir)
+	if len(files) != 2 {
+		t.Errorf("Files() = %v, want one rotated file", files)
+	}
+}
+
+func TestReadSinceAndTail(t *testing.T) {
+	dir := t.TempDir()
+	w, err := Open(dir, nil)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer w.Close()
+
+	start := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		w.Write(Entry{Time: start.Add(time.Duration(i) * time.Minute), Source: "mcp", Level: "info", Text: fmt.Sprint(i)})
+	}
+
+	lines, _ := Read(dir, start.Add(5*time.Minute), -1)
+	if len(lines) != 5 || !strings.HasSuffix(lines[0], "[mcp/info] 5") {
+		t.Errorf("Read(since) = %v, want lines 5-9", lines)
+	}
+	lines, _ = Read(dir, time.Time{}, 3)
+	if len(lines) != 3 || !strings.HasSuffix(lines[0], " 7") {
+		t.Errorf("Read(tail 3) = %v, want lines 7-9", lines)
+	}
+}
+
+func TestStreamSplitsLines(t *testing.T) {
+	dir := t.TempDir()
+	w, err := Open(dir, nil)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer w.Close()
+
+	s := w.Stream("stderr")
+	s.Write([]byte("Server start"))
+	s.Write([]byte("ing\r\nready\nbye"))
+	s.Flush()
+
+	lines, _ := Read(dir, time.Time{}, -1)
+	var texts []string
+	for _, line := range lines {
+		_, text, _ := strings.Cut(line, " [stderr] ")
+		texts = append(texts, text)
+	}
+	if got := strings.Join(texts, "|"); got != "Server starting|ready|bye" {
+		t.Errorf("logged %q", got)
+	}
+}
+
+func TestFollowAcrossRotation(t *testing.T) {
+	dir := t.TempDir()
+	w, err := Open(dir, &Options{MaxSize: 100})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer w.Close()
+	w.Write(Entry{Source: "stderr", Text: "before"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lines := make(chan string, 10)
+	go Follow(ctx, dir, func(line string) { lines <- line })
+	time.Sleep(50 * time.Millisecond)
+
+	// Every line rotates the log.
+	for _, text := range []string{"one", "two", "three"} {
+		w.Write(Entry{Source: "stderr", Text: strings.Repeat(text, 10)})
+		select {
+		case line := <-lines:
+			if !strings.HasSuffix(line, strings.Repeat(text, 10)) {
+				t.Errorf("followed %q, want %s", line, text)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for %s", text)
+		}
+	}
+}
@@ -1,7 +1,12 @@
 // Package manifest provides types and parsing for MCP server manifests.
 package manifest
 
-import "fmt"
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/xenixo/mcp-adapter/internal/sandbox"
+)
 
 // Transport defines the MCP transport type.
 type Transport string
@@ -11,6 +16,26 @@ const (
 	TransportHTTP  Transport = "http"
 )
 
+// HTTPFlavor defines the HTTP transport variant spoken by an http server.
+type HTTPFlavor string
+
+const (
+	// HTTPFlavorStreamable is the single-endpoint Streamable HTTP transport.
+	HTTPFlavorStreamable HTTPFlavor = "streamable"
+	// HTTPFlavorSSE is the legacy two-endpoint HTTP+SSE transport.
+	HTTPFlavorSSE HTTPFlavor = "sse"
+)
+
+// Framing defines how messages are delimited on a stdio server's streams.
+type Framing string
+
+const (
+	// FramingNewline is newline-delimited JSON, as MCP specifies.
+	FramingNewline Framing = "newline"
+	// FramingContentLength is LSP-style Content-Length headers.
+	FramingContentLength Framing = "content-length"
+)
+
 // ServerType defines the type of MCP server.
 type ServerType string
 
@@ -18,6 +43,7 @@ const (
 	ServerTypeNode   ServerType = "node"
 	ServerTypePython ServerType = "python"
 	ServerTypeBinary ServerType = "binary"
+	ServerTypeRemote ServerType = "remote"
 )
 
 // Source defines where an MCP server is obtained from.
@@ -31,6 +57,9 @@ type Source struct {
 	// URL for binary download.
 	URL string `yaml:"url,omitempty"`
 
+	// Remote is the endpoint URL of a remote MCP server (for remote servers).
+	Remote string `yaml:"remote,omitempty"`
+
 	// Version of the package.
 	Version string `yaml:"version"`
 
@@ -55,7 +84,7 @@ type Server struct {
 	// Description provides a human-readable description.
 	Description string `yaml:"description"`
 
-	// Type indicates the server type (node, python, binary).
+	// Type indicates the server type (node, python, binary, remote).
 	Type ServerType `yaml:"type"`
 
 	// Source defines where to obtain the server.
@@ -67,6 +96,18 @@ type Server struct {
 	// Transport defines the MCP transport (stdio, http).
 	Transport Transport `yaml:"transport"`
 
+	// HTTPFlavor selects the HTTP transport variant (streamable, sse).
+	// Only valid for http transport; defaults to streamable.
+	HTTPFlavor HTTPFlavor `yaml:"http_flavor,omitempty"`
+
+	// Framing selects how messages are delimited (newline, content-length).
+	// Only valid for stdio transport; defaults to newline.
+	Framing Framing `yaml:"framing,omitempty"`
+
+	// Endpoint is the URL a launched http server serves MCP on. It is
+	// checked to tell when the server is ready.
+	Endpoint string `yaml:"endpoint,omitempty"`
+
 	// Runtime specifies version requirements.
 	Runtime RuntimeRequirements `yaml:"runtime,omitempty"`
 
@@ -75,6 +116,32 @@ type Server struct {
 
 	// Env defines environment variables for the server.
 	Env map[string]string `yaml:"env,omitempty"`
+
+	// Sandbox restricts what the server can access when launched. It can
+	// be overridden in config.yaml.
+	Sandbox *sandbox.Policy `yaml:"sandbox,omitempty"`
+
+	// Network restricts the network a launched stdio server can reach. It
+	// can be overridden in config.yaml.
+	Network *sandbox.NetworkPolicy `yaml:"network,omitempty"`
+
+	// Seccomp denies system calls to the launched server. It can be
+	// replaced in config.yaml.
+	Seccomp *sandbox.SeccompPolicy `yaml:"seccomp,omitempty"`
+}
+
+// EffectiveHTTPFlavor returns the HTTP flavor, applying the default.
+func (s *Server) EffectiveHTTPFlavor() HTTPFlavor {
+	if s.HTTPFlavor == "" {
+		return HTTPFlavorStreamable
+	}
+	return s.HTTPFlavor
+}
+
+// IsRemote reports whether the server is reached over the network rather
+// than installed and launched locally.
+func (s *Server) IsRemote() bool {
+	return s.Type == ServerTypeRemote
 }
 
 // Manifest represents the complete manifest file.
@@ -112,16 +179,27 @@ func (s *Server) Validate() error {
 		if s.Source.Checksum == "" {
 			return fmt.Errorf("checksum is required for binary server %q", s.Name)
 		}
+	case ServerTypeRemote:
+		u, err := url.Parse(s.Source.Remote)
+		if s.Source.Remote == "" || err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("remote source must be an http(s) URL for remote server %q", s.Name)
+		}
+		if s.Transport != TransportHTTP {
+			return fmt.Errorf("remote server %q must use http transport", s.Name)
+		}
 	default:
 		return fmt.Errorf("invalid server type %q for %q", s.Type, s.Name)
 	}
 
-	if s.Source.Version == "" {
-		return fmt.Errorf("version is required for server %q", s.Name)
-	}
+	// Remote servers are not installed, so they have no version or entrypoint.
+	if s.Type != ServerTypeRemote {
+		if s.Source.Version == "" {
+			return fmt.Errorf("version is required for server %q", s.Name)
+		}
 
-	if s.Entrypoint == "" {
-		return fmt.Errorf("entrypoint is required for server %q", s.Name)
+		if s.Entrypoint == "" {
+			return fmt.Errorf("entrypoint is required for server %q", s.Name)
+		}
 	}
 
 	switch s.Transport {
@@ -133,6 +211,65 @@ func (s *Server) Validate() error {
 		return fmt.Errorf("invalid transport %q for server %q", s.Transport, s.Name)
 	}
 
+	switch s.HTTPFlavor {
+	case "":
+		// default
+	case HTTPFlavorStreamable, HTTPFlavorSSE:
+		if s.Transport != TransportHTTP {
+			return fmt.Errorf("http_flavor is only valid for http transport on server %q", s.Name)
+		}
+	default:
+		return fmt.Errorf("invalid http_flavor %q for server %q", s.HTTPFlavor, s.Name)
+	}
+
+	if s.Endpoint != "" {
+		u, err := url.Parse(s.Endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("endpoint must be an http(s) URL for server %q", s.Name)
+		}
+		if s.Transport != TransportHTTP || s.Type == ServerTypeRemote {
+			return fmt.Errorf("endpoint is only valid for launched http servers on server %q", s.Name)
+		}
+	}
+
+	switch s.Framing {
+	case "":
+		// default
+	case FramingNewline, FramingContentLength:
+		if s.Transport != TransportStdio {
+			return fmt.Errorf("framing is only valid for stdio transport on server %q", s.Name)
+		}
+	default:
+		return fmt.Errorf("invalid framing %q for server %q", s.Framing, s.Name)
+	}
+
+	if s.Sandbox != nil {
+		if s.Type == ServerTypeRemote {
+			return fmt.Errorf("sandbox is not valid for remote server %q", s.Name)
+		}
+		if err := s.Sandbox.Validate(); err != nil {
+			return fmt.Errorf("%w on server %q", err, s.Name)
+		}
+	}
+
+	if s.Network != nil {
+		if s.Transport != TransportStdio {
+			return fmt.Errorf("network policy is only valid for stdio transport on server %q", s.Name)
+		}
+		if err := s.Network.Validate(); err != nil {
+			return fmt.Errorf("%w on server %q", err, s.Name)
+		}
+	}
+
+	if s.Seccomp != nil {
+		if s.Type == ServerTypeRemote {
+			return fmt.Errorf("seccomp is not valid for remote server %q", s.Name)
+		}
+		if err := s.Seccomp.Validate(); err != nil {
+			return fmt.Errorf("%w on server %q", err, s.Name)
+		}
+	}
+
 	return nil
 }
 
@@ -2,6 +2,8 @@ package manifest
 
 import (
 	"testing"
+
+	"github.com/xenixo/mcp-adapter/internal/sandbox"
 )
 
 func TestServerValidate(t *testing.T) {
@@ -202,6 +204,237 @@ func TestServerValidate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "valid remote server",
+			server: Server{
+				Name: "test-server",
+				Type: ServerTypeRemote,
+				Source: Source{
+					Remote: "https://mcp.example.com/mcp",
+				},
+				Transport: TransportHTTP,
+			},
+			wantErr: false,
+		},
+		{
+			name: "remote server with stdio transport",
+			server: Server{
+				Name: "test-server",
+				Type: ServerTypeRemote,
+				Source: Source{
+					Remote: "https://mcp.example.com/mcp",
+				},
+				Transport: TransportStdio,
+			},
+			wantErr: true,
+		},
+		{
+			name: "remote server with non-http url",
+			server: Server{
+				Name: "test-server",
+				Type: ServerTypeRemote,
+				Source: Source{
+					Remote: "file:///etc/passwd",
+				},
+				Transport: TransportHTTP,
+			},
+			wantErr: true,
+		},
+		{
+			name: "http server with sse flavor",
+			server: Server{
+				Name: "test-server",
+				Type: ServerTypeNode,
+				Source: Source{
+					NPM:     "@example/test-server",
+					Version: "1.0.0",
+				},
+				Entrypoint: "test-server",
+				Transport:  TransportHTTP,
+				HTTPFlavor: HTTPFlavorSSE,
+			},
+			wantErr: false,
+		},
+		{
+			name: "http flavor on stdio server",
+			server: Server{
+				Name: "test-server",
+				Type: ServerTypeNode,
+				Source: Source{
+					NPM:     "@example/test-server",
+					Version: "1.0.0",
+				},
+				Entrypoint: "test-server",
+				Transport:  TransportStdio,
+				HTTPFlavor: HTTPFlavorStreamable,
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid http flavor",
+			server: Server{
+				Name: "test-server",
+				Type: ServerTypeNode,
+				Source: Source{
+					NPM:     "@example/test-server",
+					Version: "1.0.0",
+				},
+				Entrypoint: "test-server",
+				Transport:  TransportHTTP,
+				HTTPFlavor: "websocket",
+			},
+			wantErr: true,
+		},
+		{
+			name: "stdio server with content-length framing",
+			server: Server{
+				Name: "test-server",
+				Type: ServerTypeNode,
+				Source: Source{
+					NPM:     "@example/test-server",
+					Version: "1.0.0",
+				},
+				Entrypoint: "test-server",
+				Transport:  TransportStdio,
+				Framing:    FramingContentLength,
+			},
+			wantErr: false,
+		},
+		{
+			name: "framing on http server",
+			server: Server{
+				Name: "test-server",
+				Type: ServerTypeNode,
+				Source: Source{
+					NPM:     "@example/test-server",
+					Version: "1.0.0",
+				},
+				Entrypoint: "test-server",
+				Transport:  TransportHTTP,
+				Framing:    FramingNewline,
+			},
+			wantErr: true,
+		},
+		{
+			name: "http server with endpoint",
+			server: Server{
+				Name: "test-server",
+				Type: ServerTypeNode,
+				Source: Source{
+					NPM:     "@example/test-server",
+					Version: "1.0.0",
+				},
+				Entrypoint: "test-server",
+				Transport:  TransportHTTP,
+				Endpoint:   "http://127.0.0.1:3001/mcp",
+			},
+			wantErr: false,
+		},
+		{
+			name: "endpoint on stdio server",
+			server: Server{
+				Name: "test-server",
+				Type: ServerTypeNode,
+				Source: Source{
+					NPM:     "@example/test-server",
+					Version: "1.0.0",
+				},
+				Entrypoint: "test-server",
+				Transport:  TransportStdio,
+				Endpoint:   "http://127.0.0.1:3001/mcp",
+			},
+			wantErr: true,
+		},
+		{
+			name: "sandbox with relative path",
+			server: Server{
+				Name: "test-server",
+				Type: ServerTypeNode,
+				Source: Source{
+					NPM:     "@example/test-server",
+					Version: "1.0.0",
+				},
+				Entrypoint: "test-server",
+				Transport:  TransportStdio,
+				Sandbox:    &sandbox.Policy{ReadWrite: []string{"data"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "allow-list network policy",
+			server: Server{
+				Name: "test-server",
+				Type: ServerTypeNode,
+				Source: Source{
+					NPM:     "@example/test-server",
+					Version: "1.0.0",
+				},
+				Entrypoint: "test-server",
+				Transport:  TransportStdio,
+				Network:    &sandbox.NetworkPolicy{Mode: sandbox.NetworkAllow, Allow: []string{"api.github.com"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "network policy on http server",
+			server: Server{
+				Name: "test-server",
+				Type: ServerTypeNode,
+				Source: Source{
+					NPM:     "@example/test-server",
+					Version: "1.0.0",
+				},
+				Entrypoint: "test-server",
+				Transport:  TransportHTTP,
+				Network:    &sandbox.NetworkPolicy{Mode: sandbox.NetworkNone},
+			},
+			wantErr: true,
+		},
+		{
+			name: "seccomp profile",
+			server: Server{
+				Name: "test-server",
+				Type: ServerTypeNode,
+				Source: Source{
+					NPM:     "@example/test-server",
+					Version: "1.0.0",
+				},
+				Entrypoint: "test-server",
+				Transport:  TransportStdio,
+				Seccomp:    &sandbox.SeccompPolicy{Profile: sandbox.SeccompNode, Deny: []string{"socket"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown seccomp profile",
+			server: Server{
+				Name: "test-server",
+				Type: ServerTypeNode,
+				Source: Source{
+					NPM:     "@example/test-server",
+					Version: "1.0.0",
+				},
+				Entrypoint: "test-server",
+				Transport:  TransportStdio,
+				Seccomp:    &sandbox.SeccompPolicy{Profile: "paranoid"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid framing",
+			server: Server{
+				Name: "test-server",
+				Type: ServerTypeNode,
+				Source: Source{
+					NPM:     "@example/test-server",
+					Version: "1.0.0",
+				},
+				Entrypoint: "test-server",
+				Transport:  TransportStdio,
+				Framing:    "length-prefixed",
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
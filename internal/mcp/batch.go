@@ -0,0 +1,178 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+// BatchTransport is implemented by transports that can send a JSON-RPC
+// batch: several messages encoded as a single JSON array. Messages that
+// arrive in a batch are still returned one at a time by Receive.
+type BatchTransport interface {
+	Transport
+	SendBatch(msgs []*Message) error
+}
+
+// batchGroup links the messages that arrived together in one batch.
+type batchGroup struct {
+	msgs []*Message
+}
+
+// first reports whether msg is the first message of its batch.
+func (g *batchGroup) first(msg *Message) bool {
+	return len(g.msgs) > 0 && g.msgs[0] == msg
+}
+
+// last reports whether msg is the last message of its batch.
+func (g *batchGroup) last(msg *Message) bool {
+	return len(g.msgs) > 0 && g.msgs[len(g.msgs)-1] == msg
+}
+
+// batchesAllowed reports whether a protocol revision permits JSON-RPC
+// batching. Batches were added in 2025-03-26 and removed again in
+// 2025-06-18.
+func batchesAllowed(version string) bool {
+	return version == "2025-03-26"
+}
+
+// decodeMessages decodes a single message or a batch.
+func decodeMessages(data []byte) ([]*Message, error) {
+	if data = bytes.TrimSpace(data); len(data) > 0 && data[0] == '[' {
+		return decodeBatch(data)
+	}
+	var msg Message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, err
+	}
+	return []*Message{&msg}, nil
+}
+
+// awaitedResponses returns the keys of the requests among msgs, whose
+// responses are still to come.
+func awaitedResponses(msgs []*Message) map[string]bool {
+	awaited := make(map[string]bool)
+	for _, msg := range msgs {
+		if msg.IsRequest() {
+			awaited[idKey(msg.ID)] = true
+		}
+	}
+	return awaited
+}
+
+// decodeBatch decodes a JSON array of messages.
+func decodeBatch(data []byte) ([]*Message, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 {
+		return nil, errors.New("empty batch")
+	}
+
+	group := &batchGroup{msgs: make([]*Message, 0, len(raw))}
+	for _, item := range raw {
+		var msg Message
+		if err := json.Unmarshal(item, &msg); err != nil {
+			return nil, err
+		}
+		msg.batch = group
+		group.msgs = append(group.msgs, &msg)
+	}
+	return group.msgs, nil
+}
+
+// batchResponder wraps the transport facing a client so that the responses
+// to a batch of requests go back together as one batch, as JSON-RPC
+// requires. All other messages pass straight through.
+type batchResponder struct {
+	BatchTransport
+
+	mu      sync.Mutex
+	pending map[string]*batchReply
+}
+
+type batchReply struct {
+	waiting   int
+	responses []*Message
+}
+
+// newBatchResponder wraps t if it can carry batches.
+func newBatchResponder(t Transport) Transport {
+	bt, ok := t.(BatchTransport)
+	if !ok {
+		return t
+	}
+	return &batchResponder{BatchTransport: bt, pending: make(map[string]*batchReply)}
+}
+
+// Receive receives the next message, noting the requests of a new batch.
+func (r *batchResponder) Receive() (*Message, error) {
+	msg, err := r.BatchTransport.Receive()
+	if err == nil && msg.batch != nil && msg.batch.first(msg) {
+		r.track(msg.batch.msgs)
+	}
+	return msg, err
+}
+
+func (r *batchResponder) track(msgs []*Message) {
+	reply := &batchReply{}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, msg := range msgs {
+		if msg.IsRequest() {
+			r.pending[idKey(msg.ID)] = reply
+			reply.waiting++
+		}
+	}
+}
+
+// Send holds responses to batched requests until the whole batch has been
+// answered and then sends them as one batch.
+func (r *batchResponder) Send(msg *Message) error {
+	if !msg.IsResponse() {
+		return r.BatchTransport.Send(msg)
+	}
+
+	key := idKey(msg.ID)
+	r.mu.Lock()
+	reply, ok := r.pending[key]
+	if !ok {
+		r.mu.Unlock()
+		return r.BatchTransport.Send(msg)
+	}
+	delete(r.pending, key)
+	reply.responses = append(reply.responses, msg)
+	reply.waiting--
+	done := reply.waiting == 0
+	r.mu.Unlock()
+
+	if !done {
+		return nil
+	}
+	return r.BatchTransport.SendBatch(reply.responses)
+}
+
+// abandon stops waiting for the response to a batched request that will
+// not be answered, such as one dropped by middleware. If it was the last
+// one its batch waited for, the responses collected so far are sent.
+func (r *batchResponder) abandon(id interface{}) error {
+	key := idKey(id)
+	r.mu.Lock()
+	reply, ok := r.pending[key]
+	if !ok {
+		// Already answered.
+		r.mu.Unlock()
+		return nil
+	}
+	delete(r.pending, key)
+	reply.waiting--
+	done := reply.waiting == 0
+	r.mu.Unlock()
+
+	if !done || len(reply.responses) == 0 {
+		return nil
+	}
+	return r.BatchTransport.SendBatch(reply.responses)
+}
@@ -0,0 +1,217 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rawPeer is the far end of a StdioTransport, read and written as lines.
+type rawPeer struct {
+	t     *testing.T
+	w     io.Writer
+	lines chan string
+}
+
+// newStdioPeer returns a StdioTransport and the raw peer it talks to.
+func newStdioPeer(t *testing.T) (*StdioTransport, *rawPeer) {
+	t.Helper()
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	t.Cleanup(func() {
+		inW.Close()
+		outR.Close()
+	})
+
+	peer := &rawPeer{t: t, w: inW, lines: make(chan string, 16)}
+	go func() {
+		scanner := bufio.NewScanner(outR)
+		for scanner.Scan() {
+			peer.lines <- scanner.Text()
+		}
+		close(peer.lines)
+	}()
+	return NewStdioTransport(inR, outW), peer
+}
+
+func (p *rawPeer) write(line string) {
+	p.t.Helper()
+	if _, err := io.WriteString(p.w, line+"\n"); err != nil {
+		p.t.Fatalf("write: %v", err)
+	}
+}
+
+func (p *rawPeer) read() string {
+	p.t.Helper()
+	select {
+	case line := <-p.lines:
+		return line
+	case <-time.After(2 * time.Second):
+		p.t.Fatal("timed out waiting for a line")
+		return ""
+	}
+}
+
+// responseIDs decodes a batch of responses and returns their sorted IDs.
+func responseIDs(t *testing.T, line string) []string {
+	t.Helper()
+	var batch []Message
+	if err := json.Unmarshal([]byte(line), &batch); err != nil {
+		t.Fatalf("expected a batch, got %s", line)
+	}
+	ids := make([]string, 0, len(batch))
+	for _, msg := range batch {
+		ids = append(ids, fmt.Sprint(msg.ID))
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestServerAnswersBatch(t *testing.T) {
+	transport, client := newStdioPeer(t)
+	srv := NewServer(transport, nil)
+	go srv.Serve()
+
+	client.write(`[{"jsonrpc":"2.0","id":1,"method":"ping"},{"jsonrpc":"2.0","method":"notifications/initialized"},{"jsonrpc":"2.0","id":2,"method":"ping"}]`)
+
+	if ids := responseIDs(t, client.read()); strings.Join(ids, ",") != "1,2" {
+		t.Errorf("batch response IDs = %v, want [1 2]", ids)
+	}
+
+	// Single messages still get single responses.
+	client.write(`{"jsonrpc":"2.0","id":3,"method":"ping"}`)
+	if line := client.read(); !strings.HasPrefix(line, "{") {
+		t.Errorf("response to a single request = %s, want an object", line)
+	}
+}
+
+func TestProxySplitsBatch(t *testing.T) {
+	clientSide, client := newStdioPeer(t)
+	proxyServer, server := NewPipe()
+	defer server.Close()
+	go NewProxy(clientSide, proxyServer).Run()
+
+	client.write(`[{"jsonrpc":"2.0","id":1,"method":"ping"},{"jsonrpc":"2.0","id":2,"method":"tools/list"}]`)
+
+	var received []*Message
+	for i := 0; i < 2; i++ {
+		msg, err := server.Receive()
+		if err != nil {
+			t.Fatalf("Receive() error = %v", err)
+		}
+		received = append(received, msg)
+	}
+	// Answer out of order.
+	for i := len(received) - 1; i >= 0; i-- {
+		server.Send(&Message{JSONRPC: "2.0", ID: received[i].ID, Result: json.RawMessage(`{}`)})
+	}
+
+	if ids := responseIDs(t, client.read()); strings.Join(ids, ",") != "1,2" {
+		t.Errorf("batch response IDs = %v, want [1 2]", ids)
+	}
+}
+
+func TestProxyBatchWithDroppedRequest(t *testing.T) {
+	clientSide, client := newStdioPeer(t)
+	proxyServer, server := NewPipe()
+	defer server.Close()
+	proxy := NewProxy(clientSide, proxyServer)
+	proxy.Use(MiddlewareFunc(func(x *Exchange, msg *Message) (*Message, error) {
+		if msg.Method == MethodToolsCall {
+			return nil, nil
+		}
+		return msg, nil
+	}))
+	go proxy.Run()
+
+	client.write(`[{"jsonrpc":"2.0","id":1,"method":"ping"},{"jsonrpc":"2.0","id":2,"method":"tools/call"}]`)
+	msg, err := server.Receive()
+	if err != nil {
+		t.Fatalf("Receive() error = %v", err)
+	}
+	server.Send(&Message{JSONRPC: "2.0", ID: msg.ID, Result: json.RawMessage(`{}`)})
+
+	if ids := responseIDs(t, client.read()); strings.Join(ids, ",") != "1" {
+		t.Errorf("batch response IDs = %v, want [1]", ids)
+	}
+}
+
+func TestProxyPassesBatchThrough(t *testing.T) {
+	clientSide, client := newStdioPeer(t)
+	serverSide, server := newStdioPeer(t)
+	go NewProxy(clientSide, serverSide).Run()
+
+	// Batches are only passed through once a revision that allows them
+	// has been negotiated.
+	client.write(`{"jsonrpc":"2.0","id":0,"method":"initialize","params":{"protocolVersion":"2025-03-26"}}`)
+	server.read()
+	server.write(`{"jsonrpc":"2.0","id":0,"result":{"protocolVersion":"2025-03-26","capabilities":{},"serverInfo":{"name":"s","version":"1"}}}`)
+	client.read()
+
+	client.write(`[{"jsonrpc":"2.0","id":1,"method":"ping"},{"jsonrpc":"2.0","id":2,"method":"ping"}]`)
+
+	line := server.read()
+	var batch []Message
+	if err := json.Unmarshal([]byte(line), &batch); err != nil || len(batch) != 2 {
+		t.Fatalf("server received %s, want the batch intact", line)
+	}
+
+	server.write(`[{"jsonrpc":"2.0","id":2,"result":{}},{"jsonrpc":"2.0","id":1,"result":{}}]`)
+	if ids := responseIDs(t, client.read()); strings.Join(ids, ",") != "1,2" {
+		t.Errorf("batch response IDs = %v, want [1 2]", ids)
+	}
+}
+
+func TestHTTPHandlerBatch(t *testing.T) {
+	handler := NewHTTPHandler(func(context.Context) (Transport, error) {
+		clientEnd, serverEnd := NewPipe()
+		go NewServer(serverEnd, nil).Serve()
+		return clientEnd, nil
+	})
+	defer handler.Close()
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	post := func(sessionID, body string) *http.Response {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		if sessionID != "" {
+			req.Header.Set(HeaderSessionID, sessionID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("POST error = %v", err)
+		}
+		return resp
+	}
+
+	resp := post("", `{"jsonrpc":"2.0","id":0,"method":"initialize","params":{"protocolVersion":"2025-03-26"}}`)
+	resp.Body.Close()
+	sessionID := resp.Header.Get(HeaderSessionID)
+
+	resp = post(sessionID, `[{"jsonrpc":"2.0","id":1,"method":"ping"},{"jsonrpc":"2.0","method":"notifications/initialized"},{"jsonrpc":"2.0","id":2,"method":"ping"}]`)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("batch response: %d %s %s", resp.StatusCode, resp.Header.Get("Content-Type"), body)
+	}
+	if ids := responseIDs(t, string(body)); strings.Join(ids, ",") != "1,2" {
+		t.Errorf("batch response IDs = %v, want [1 2]", ids)
+	}
+
+	resp = post(sessionID, `[{"jsonrpc":"2.0","id":3,"method":"initialize"}]`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("batched initialize status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,540 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrClientClosed is returned for requests made after the client session ended.
+var ErrClientClosed = errors.New("mcp client closed")
+
+// DefaultRequestTimeout is used for requests whose context has no deadline.
+const DefaultRequestTimeout = 60 * time.Second
+
+// NotificationHandler handles a server-initiated notification. Handlers run
+// on the client's receive loop and must not block or issue requests themselves.
+type NotificationHandler func(method string, params json.RawMessage)
+
+// RequestHandler handles a server-initiated request and returns its result.
+// Returning an *Error sends that error back to the server verbatim.
+type RequestHandler func(ctx context.Context, params json.RawMessage) (interface{}, error)
+
+// versionedTransport is implemented by transports that must carry the
+// negotiated protocol version, such as the HTTP transports.
+type versionedTransport interface {
+	SetProtocolVersion(version string)
+}
+
+// ClientOptions configures a Client.
+type ClientOptions struct {
+	// Info identifies the client to the server.
+	Info Implementation
+
+	// Capabilities are advertised to the server during initialization.
+	Capabilities ClientCapabilities
+
+	// RequestTimeout bounds requests whose context has no deadline.
+	// Zero means DefaultRequestTimeout; a negative value disables the timeout.
+	RequestTimeout time.Duration
+}
+
+// Client is an MCP client session over a Transport.
+type Client struct {
+	transport Transport
+	opts      ClientOptions
+	nextID    int64
+
+	mu              sync.Mutex
+	pending         map[string]chan *Message
+	notifyHandlers  map[string][]NotificationHandler
+	requestHandlers map[string]RequestHandler
+	progress        map[string]ProgressFunc
+	initResult      *InitializeResult
+
+	done      chan struct{}
+	err       error
+	closeOnce sync.Once
+}
+
+// NewClient creates a client session over the given transport and starts
+// receiving messages. Call Initialize before issuing other requests.
+func NewClient(t Transport, opts *ClientOptions) *Client {
+	c := &Client{
+		transport:       t,
+		pending:         make(map[string]chan *Message),
+		notifyHandlers:  make(map[string][]NotificationHandler),
+		requestHandlers: make(map[string]RequestHandler),
+		progress:        make(map[string]ProgressFunc),
+		done:            make(chan struct{}),
+	}
+	if opts != nil {
+		c.opts = *opts
+	}
+	if c.opts.Info.Name == "" {
+		c.opts.Info = Implementation{Name: "mcp-adapter", Version: "dev"}
+	}
+	if c.opts.RequestTimeout == 0 {
+		c.opts.RequestTimeout = DefaultRequestTimeout
+	}
+
+	go c.receiveLoop()
+
+	return c
+}
+
+// Initialize performs the initialize handshake and records the server's
+// protocol version, info and capabilities.
+func (c *Client) Initialize(ctx context.Context) (*InitializeResult, error) {
+	params := InitializeParams{
+		ProtocolVersion: LatestProtocolVersion,
+		Capabilities:    c.opts.Capabilities,
+		ClientInfo:      c.opts.Info,
+	}
+
+	var result InitializeResult
+	if err := c.Call(ctx, MethodInitialize, params, &result); err != nil {
+		return nil, fmt.Errorf("initialize failed: %w", err)
+	}
+
+	if !IsSupportedProtocolVersion(result.ProtocolVersion) {
+		return nil, fmt.Errorf("server negotiated unsupported protocol version %q", result.ProtocolVersion)
+	}
+
+	c.mu.Lock()
+	c.initResult = &result
+	c.mu.Unlock()
+
+	if vt, ok := c.transport.(versionedTransport); ok {
+		vt.SetProtocolVersion(result.ProtocolVersion)
+	}
+
+	if err := c.Notify(NotificationInitialized, nil); err != nil {
+		return nil, fmt.Errorf("failed to send initialized notification: %w", err)
+	}
+
+	return &result, nil
+}
+
+// InitializeResult returns the result of the handshake, or nil before Initialize succeeds.
+func (c *Client) InitializeResult() *InitializeResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.initResult
+}
+
+// ProtocolVersion returns the negotiated protocol version.
+func (c *Client) ProtocolVersion() string {
+	if r := c.InitializeResult(); r != nil {
+		return r.ProtocolVersion
+	}
+	return ""
+}
+
+// ServerInfo returns the server's implementation info.
+func (c *Client) ServerInfo() Implementation {
+	if r := c.InitializeResult(); r != nil {
+		return r.ServerInfo
+	}
+	return Implementation{}
+}
+
+// Capabilities returns the server's capabilities.
+func (c *Client) Capabilities() ServerCapabilities {
+	if r := c.InitializeResult(); r != nil {
+		return r.Capabilities
+	}
+	return ServerCapabilities{}
+}
+
+// OnNotification registers a handler for a server notification method.
+// An empty method registers a handler for all notifications.
+func (c *Client) OnNotification(method string, handler NotificationHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.notifyHandlers[method] = append(c.notifyHandlers[method], handler)
+}
+
+// HandleRequest registers a handler for a server-initiated request method.
+func (c *Client) HandleRequest(method string, handler RequestHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requestHandlers[method] = handler
+}
+
+// Call sends a request and waits for its response. The result, if non-nil,
+// is decoded from the response. JSON-RPC errors are returned as *Error.
+func (c *Client) Call(ctx context.Context, method string, params, result interface{}) error {
+	raw, err := marshalParams(params)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.roundTrip(ctx, &Message{JSONRPC: "2.0", Method: method, Params: raw})
+	if err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if result != nil && len(resp.Result) > 0 {
+		if err := json.Unmarshal(resp.Result, result); err != nil {
+			return fmt.Errorf("failed to decode %s result: %w", method, err)
+		}
+	}
+	return nil
+}
+
+// Notify sends a notification to the server.
+func (c *Client) Notify(method string, params interface{}) error {
+	raw, err := marshalParams(params)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-c.done:
+		return c.closedErr()
+	default:
+	}
+
+	return c.transport.Send(&Message{JSONRPC: "2.0", Method: method, Params: raw})
+}
+
+// Ping checks that the server is responsive.
+func (c *Client) Ping(ctx context.Context) error {
+	return c.Call(ctx, MethodPing, nil, nil)
+}
+
+// ListTools returns all tools offered by the server, following pagination cursors.
+func (c *Client) ListTools(ctx context.Context) ([]Tool, error) {
+	var tools []Tool
+	cursor := ""
+	for {
+		var page ListToolsResult
+		if err := c.Call(ctx, MethodToolsList, PaginatedParams{Cursor: cursor}, &page); err != nil {
+			return nil, err
+		}
+		tools = append(tools, page.Tools...)
+		if page.NextCursor == "" {
+			return tools, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// CallTool invokes a tool by name.
+func (c *Client) CallTool(ctx context.Context, name string, args map[string]interface{}) (*CallToolResult, error) {
+	var result CallToolResult
+	if err := c.Call(ctx, MethodToolsCall, CallToolParams{Name: name, Arguments: args}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListResources returns all resources offered by the server.
+func (c *Client) ListResources(ctx context.Context) ([]Resource, error) {
+	var resources []Resource
+	cursor := ""
+	for {
+		var page ListResourcesResult
+		if err := c.Call(ctx, MethodResourcesList, PaginatedParams{Cursor: cursor}, &page); err != nil {
+			return nil, err
+		}
+		resources = append(resources, page.Resources...)
+		if page.NextCursor == "" {
+			return resources, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// ListResourceTemplates returns all resource templates offered by the server.
+func (c *Client) ListResourceTemplates(ctx context.Context) ([]ResourceTemplate, error) {
+	var templates []ResourceTemplate
+	cursor := ""
+	for {
+		var page ListResourceTemplatesResult
+		if err := c.Call(ctx, MethodResourceTemplatesList, PaginatedParams{Cursor: cursor}, &page); err != nil {
+			return nil, err
+		}
+		templates = append(templates, page.ResourceTemplates...)
+		if page.NextCursor == "" {
+			return templates, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// ReadResource reads a resource by URI.
+func (c *Client) ReadResource(ctx context.Context, uri string) (*ReadResourceResult, error) {
+	var result ReadResourceResult
+	if err := c.Call(ctx, MethodResourcesRead, ResourceParams{URI: uri}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Subscribe subscribes to update notifications for a resource.
+func (c *Client) Subscribe(ctx context.Context, uri string) error {
+	return c.Call(ctx, MethodResourcesSubscribe, ResourceParams{URI: uri}, nil)
+}
+
+// Unsubscribe cancels a resource subscription.
+func (c *Client) Unsubscribe(ctx context.Context, uri string) error {
+	return c.Call(ctx, MethodResourcesUnsubscribe, ResourceParams{URI: uri}, nil)
+}
+
+// ListPrompts returns all prompts offered by the server.
+func (c *Client) ListPrompts(ctx context.Context) ([]Prompt, error) {
+	var prompts []Prompt
+	cursor := ""
+	for {
+		var page ListPromptsResult
+		if err := c.Call(ctx, MethodPromptsList, PaginatedParams{Cursor: cursor}, &page); err != nil {
+			return nil, err
+		}
+		prompts = append(prompts, page.Prompts...)
+		if page.NextCursor == "" {
+			return prompts, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// GetPrompt renders a prompt with the given arguments.
+func (c *Client) GetPrompt(ctx context.Context, name string, args map[string]string) (*GetPromptResult, error) {
+	var result GetPromptResult
+	if err := c.Call(ctx, MethodPromptsGet, GetPromptParams{Name: name, Arguments: args}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Done returns a channel that is closed when the session ends.
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns the reason the session ended, or nil while it is active.
+func (c *Client) Err() error {
+	select {
+	case <-c.done:
+		return c.err
+	default:
+		return nil
+	}
+}
+
+// Close ends the session and closes the underlying transport.
+func (c *Client) Close() error {
+	c.shutdown(ErrClientClosed)
+	return c.transport.Close()
+}
+
+func (c *Client) roundTrip(ctx context.Context, msg *Message) (*Message, error) {
+	id := atomic.AddInt64(&c.nextID, 1)
+	msg.ID = id
+	key := idKey(id)
+
+	if _, ok := ctx.Deadline(); !ok && c.opts.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.opts.RequestTimeout)
+		defer cancel()
+	}
+
+	// The request ID doubles as its progress token.
+	progress := progressFromContext(ctx)
+	if progress != nil && msg.Method != MethodInitialize {
+		params, err := setProgressToken(msg.Params, id)
+		if err != nil {
+			return nil, err
+		}
+		msg.Params = params
+	} else {
+		progress = nil
+	}
+
+	ch := make(chan *Message, 1)
+	c.mu.Lock()
+	c.pending[key] = ch
+	if progress != nil {
+		c.progress[key] = progress
+	}
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, key)
+		delete(c.progress, key)
+		c.mu.Unlock()
+	}()
+
+	select {
+	case <-c.done:
+		return nil, c.closedErr()
+	default:
+	}
+
+	if err := c.transport.Send(msg); err != nil {
+		return nil, fmt.Errorf("failed to send %s request: %w", msg.Method, err)
+	}
+
+	select {
+	case resp := <-ch:
+		return resp, nil
+	case <-ctx.Done():
+		// The initialize request must never be cancelled.
+		if msg.Method != MethodInitialize {
+			_ = c.Notify(NotificationCancelled, CancelledParams{RequestID: id, Reason: ctx.Err().Error()})
+		}
+		return nil, fmt.Errorf("%s request: %w", msg.Method, ctx.Err())
+	case <-c.done:
+		return nil, c.closedErr()
+	}
+}
+
+func (c *Client) receiveLoop() {
+	for {
+		msg, err := c.transport.Receive()
+		if IsRecoverable(err) {
+			continue
+		}
+		if err != nil {
+			c.shutdown(err)
+			return
+		}
+
+		switch {
+		case msg.IsResponse():
+			// A duplicate or late response finds no waiter and is dropped
+			// rather than blocking the loop.
+			c.mu.Lock()
+			key := idKey(msg.ID)
+			ch, ok := c.pending[key]
+			delete(c.pending, key)
+			c.mu.Unlock()
+			if ok {
+				select {
+				case ch <- msg:
+				default:
+				}
+			}
+		case msg.IsRequest():
+			go c.handleRequest(msg)
+		case msg.IsNotification():
+			c.dispatchNotification(msg)
+		}
+	}
+}
+
+func (c *Client) dispatchNotification(msg *Message) {
+	var progress ProgressParams
+	if msg.Method == NotificationProgress {
+		if err := json.Unmarshal(msg.Params, &progress); err != nil {
+			progress.ProgressToken = nil
+		}
+	}
+
+	c.mu.Lock()
+	handlers := append([]NotificationHandler{}, c.notifyHandlers[msg.Method]...)
+	handlers = append(handlers, c.notifyHandlers[""]...)
+	var onProgress ProgressFunc
+	if progress.ProgressToken != nil {
+		onProgress = c.progress[idKey(progress.ProgressToken)]
+	}
+	c.mu.Unlock()
+
+	if onProgress != nil {
+		onProgress(progress)
+	}
+
+	for _, h := range handlers {
+		h(msg.Method, msg.Params)
+	}
+}
+
+func (c *Client) handleRequest(msg *Message) {
+	resp := &Message{JSONRPC: "2.0", ID: msg.ID}
+
+	c.mu.Lock()
+	handler, ok := c.requestHandlers[msg.Method]
+	c.mu.Unlock()
+
+	switch {
+	case ok:
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			select {
+			case <-c.done:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		result, err := handler(ctx, msg.Params)
+		cancel()
+		if err != nil {
+			var rpcErr *Error
+			if errors.As(err, &rpcErr) {
+				resp.Error = rpcErr
+			} else {
+				resp.Error = &Error{Code: ErrInternal, Message: err.Error()}
+			}
+			break
+		}
+		raw, err := json.Marshal(result)
+		if err != nil {
+			resp.Error = &Error{Code: ErrInternal, Message: err.Error()}
+			break
+		}
+		resp.Result = raw
+	case msg.Method == MethodPing:
+		resp.Result = json.RawMessage(`{}`)
+	default:
+		resp.Error = &Error{Code: ErrMethodNotFound, Message: fmt.Sprintf("method not found: %s", msg.Method)}
+	}
+
+	_ = c.transport.Send(resp)
+}
+
+func (c *Client) shutdown(err error) {
+	c.closeOnce.Do(func() {
+		c.err = err
+		close(c.done)
+	})
+}
+
+func (c *Client) closedErr() error {
+	if c.err != nil && c.err != ErrClientClosed {
+		return fmt.Errorf("%w: %v", ErrClientClosed, c.err)
+	}
+	return ErrClientClosed
+}
+
+// idKey returns a canonical map key for a JSON-RPC ID, so that numeric IDs
+// decoded as float64 match the int64 IDs they were sent as.
+func idKey(id interface{}) string {
+	data, err := json.Marshal(id)
+	if err != nil {
+		return fmt.Sprint(id)
+	}
+	return string(data)
+}
+
+func marshalParams(params interface{}) (json.RawMessage, error) {
+	if params == nil {
+		return nil, nil
+	}
+	if raw, ok := params.(json.RawMessage); ok {
+		return raw, nil
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal params: %w", err)
+	}
+	return data, nil
+}
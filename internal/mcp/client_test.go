@@ -0,0 +1,362 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeServer answers requests received on a transport using per-method handlers.
+type fakeServer struct {
+	t        Transport
+	handlers map[string]func(msg *Message) (interface{}, *Error)
+	received chan *Message
+}
+
+func newFakeServer(t Transport) *fakeServer {
+	s := &fakeServer{
+		t:        t,
+		handlers: make(map[string]func(msg *Message) (interface{}, *Error)),
+		received: make(chan *Message, 64),
+	}
+	s.handlers[MethodInitialize] = func(msg *Message) (interface{}, *Error) {
+		return InitializeResult{
+			ProtocolVersion: LatestProtocolVersion,
+			Capabilities:    ServerCapabilities{Tools: &ToolsCapability{ListChanged: true}},
+			ServerInfo:      Implementation{Name: "fake", Version: "1.0.0"},
+		}, nil
+	}
+	s.handlers[MethodPing] = func(msg *Message) (interface{}, *Error) {
+		return struct{}{}, nil
+	}
+	return s
+}
+
+func (s *fakeServer) serve() {
+	for {
+		msg, err := s.t.Receive()
+		if err != nil {
+			return
+		}
+		s.received <- msg
+		if !msg.IsRequest() {
+			continue
+		}
+
+		resp := &Message{JSONRPC: "2.0", ID: msg.ID}
+		handler, ok := s.handlers[msg.Method]
+		if !ok {
+			resp.Error = &Error{Code: ErrMethodNotFound, Message: "method not found"}
+		} else if result, rpcErr := handler(msg); rpcErr != nil {
+			resp.Error = rpcErr
+		} else if result == nil {
+			// Handler will not answer.
+			continue
+		} else {
+			resp.Result, _ = json.Marshal(result)
+		}
+		s.t.Send(resp)
+	}
+}
+
+func (s *fakeServer) waitFor(t *testing.T, method string) *Message {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case msg := <-s.received:
+			if msg.Method == method {
+				return msg
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %s", method)
+			return nil
+		}
+	}
+}
+
+func newTestClient(t *testing.T) (*Client, *fakeServer) {
+	t.Helper()
+	clientEnd, serverEnd := NewPipe()
+	server := newFakeServer(serverEnd)
+	client := NewClient(clientEnd, &ClientOptions{RequestTimeout: 2 * time.Second})
+	t.Cleanup(func() { client.Close() })
+	return client, server
+}
+
+func TestClientInitialize(t *testing.T) {
+	client, server := newTestClient(t)
+	go server.serve()
+
+	result, err := client.Initialize(context.Background())
+	if err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	if result.ServerInfo.Name != "fake" {
+		t.Errorf("ServerInfo.Name = %q, want %q", result.ServerInfo.Name, "fake")
+	}
+	if client.ProtocolVersion() != LatestProtocolVersion {
+		t.Errorf("ProtocolVersion() = %q, want %q", client.ProtocolVersion(), LatestProtocolVersion)
+	}
+	if caps := client.Capabilities(); caps.Tools == nil || !caps.Tools.ListChanged {
+		t.Errorf("Capabilities().Tools = %+v, want listChanged", caps.Tools)
+	}
+
+	init := server.waitFor(t, MethodInitialize)
+	var params InitializeParams
+	if err := json.Unmarshal(init.Params, &params); err != nil {
+		t.Fatalf("failed to decode initialize params: %v", err)
+	}
+	if params.ClientInfo.Name != "mcp-adapter" {
+		t.Errorf("ClientInfo.Name = %q, want %q", params.ClientInfo.Name, "mcp-adapter")
+	}
+
+	server.waitFor(t, NotificationInitialized)
+}
+
+func TestClientInitializeUnsupportedVersion(t *testing.T) {
+	client, server := newTestClient(t)
+	server.handlers[MethodInitialize] = func(msg *Message) (interface{}, *Error) {
+		return InitializeResult{ProtocolVersion: "1999-01-01"}, nil
+	}
+	go server.serve()
+
+	if _, err := client.Initialize(context.Background()); err == nil {
+		t.Error("Initialize() should fail for unsupported protocol version")
+	}
+}
+
+func TestClientCallError(t *testing.T) {
+	client, server := newTestClient(t)
+	go server.serve()
+
+	err := client.Call(context.Background(), "does/not/exist", nil, nil)
+
+	var rpcErr *Error
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("Call() error = %v, want *Error", err)
+	}
+	if rpcErr.Code != ErrMethodNotFound {
+		t.Errorf("Code = %d, want %d", rpcErr.Code, ErrMethodNotFound)
+	}
+}
+
+func TestClientCallTimeoutSendsCancellation(t *testing.T) {
+	client, server := newTestClient(t)
+	server.handlers["slow"] = func(msg *Message) (interface{}, *Error) {
+		return nil, nil
+	}
+	go server.serve()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := client.Call(ctx, "slow", nil, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Call() error = %v, want deadline exceeded", err)
+	}
+
+	req := server.waitFor(t, "slow")
+	cancelled := server.waitFor(t, NotificationCancelled)
+
+	var params CancelledParams
+	if err := json.Unmarshal(cancelled.Params, &params); err != nil {
+		t.Fatalf("failed to decode cancel params: %v", err)
+	}
+	if idKey(params.RequestID) != idKey(req.ID) {
+		t.Errorf("cancelled requestId = %v, want %v", params.RequestID, req.ID)
+	}
+}
+
+func TestClientDuplicateResponses(t *testing.T) {
+	client, server := newTestClient(t)
+	server.handlers["echo"] = func(msg *Message) (interface{}, *Error) {
+		// Answer three times; only the first has a waiter.
+		for i := 0; i < 3; i++ {
+			server.t.Send(&Message{JSONRPC: "2.0", ID: msg.ID, Result: json.RawMessage(`{}`)})
+		}
+		return nil, nil
+	}
+	go server.serve()
+
+	if err := client.Call(context.Background(), "echo", nil, nil); err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+	// The receive loop must still be running.
+	if err := client.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping() after duplicate responses: %v", err)
+	}
+}
+
+func TestClientConcurrentCalls(t *testing.T) {
+	client, server := newTestClient(t)
+	server.handlers["echo"] = func(msg *Message) (interface{}, *Error) {
+		return msg.Params, nil
+	}
+	go server.serve()
+
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		go func(n int) {
+			var got int
+			if err := client.Call(context.Background(), "echo", n, &got); err != nil {
+				errs <- err
+				return
+			}
+			if got != n {
+				errs <- errors.New("response routed to wrong request")
+				return
+			}
+			errs <- nil
+		}(i)
+	}
+
+	for i := 0; i < 10; i++ {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestClientNotificationHandlers(t *testing.T) {
+	client, server := newTestClient(t)
+
+	got := make(chan string, 2)
+	client.OnNotification(NotificationToolsChanged, func(method string, params json.RawMessage) {
+		got <- "specific:" + method
+	})
+	client.OnNotification("", func(method string, params json.RawMessage) {
+		got <- "all:" + method
+	})
+
+	server.t.Send(&Message{JSONRPC: "2.0", Method: NotificationToolsChanged})
+
+	want := map[string]bool{
+		"specific:" + NotificationToolsChanged: true,
+		"all:" + NotificationToolsChanged:      true,
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case v := <-got:
+			if !want[v] {
+				t.Errorf("unexpected dispatch %q", v)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for notification")
+		}
+	}
+}
+
+func TestClientAnswersServerRequests(t *testing.T) {
+	client, server := newTestClient(t)
+	client.HandleRequest("roots/list", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return map[string]interface{}{"roots": []interface{}{}}, nil
+	})
+
+	server.t.Send(&Message{JSONRPC: "2.0", ID: "a", Method: MethodPing})
+	server.t.Send(&Message{JSONRPC: "2.0", ID: "b", Method: "roots/list"})
+	server.t.Send(&Message{JSONRPC: "2.0", ID: "c", Method: "sampling/createMessage"})
+
+	results := make(map[string]*Message)
+	for i := 0; i < 3; i++ {
+		msg, err := server.t.Receive()
+		if err != nil {
+			t.Fatalf("Receive() error = %v", err)
+		}
+		results[msg.ID.(string)] = msg
+	}
+
+	if results["a"].Error != nil {
+		t.Errorf("ping error = %v", results["a"].Error)
+	}
+	if results["b"].Error != nil || len(results["b"].Result) == 0 {
+		t.Errorf("roots/list response = %+v, want result", results["b"])
+	}
+	if results["c"].Error == nil || results["c"].Error.Code != ErrMethodNotFound {
+		t.Errorf("sampling response = %+v, want method not found", results["c"])
+	}
+}
+
+func TestClientListToolsPagination(t *testing.T) {
+	client, server := newTestClient(t)
+	server.handlers[MethodToolsList] = func(msg *Message) (interface{}, *Error) {
+		var params PaginatedParams
+		json.Unmarshal(msg.Params, &params)
+		if params.Cursor == "" {
+			return ListToolsResult{Tools: []Tool{{Name: "a"}}, NextCursor: "page2"}, nil
+		}
+		return ListToolsResult{Tools: []Tool{{Name: "b"}}}, nil
+	}
+	go server.serve()
+
+	tools, err := client.ListTools(context.Background())
+	if err != nil {
+		t.Fatalf("ListTools() error = %v", err)
+	}
+	if len(tools) != 2 || tools[0].Name != "a" || tools[1].Name != "b" {
+		t.Errorf("ListTools() = %+v, want [a b]", tools)
+	}
+}
+
+func TestClientClosedTransport(t *testing.T) {
+	client, server := newTestClient(t)
+	server.t.Close()
+
+	select {
+	case <-client.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("client did not observe closed transport")
+	}
+
+	if err := client.Ping(context.Background()); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("Ping() error = %v, want ErrClientClosed", err)
+	}
+}
+
+func TestClientProgress(t *testing.T) {
+	client, server := newTestClient(t)
+	server.handlers[MethodToolsCall] = func(msg *Message) (interface{}, *Error) {
+		var params struct {
+			Meta struct {
+				ProgressToken interface{} `json:"progressToken"`
+				Trace         string      `json:"trace"`
+			} `json:"_meta"`
+		}
+		json.Unmarshal(msg.Params, &params)
+		if params.Meta.Trace != "abc" {
+			return nil, &Error{Code: ErrInvalidParams, Message: "existing _meta was lost"}
+		}
+		for i := 1; i <= 2; i++ {
+			p, _ := json.Marshal(ProgressParams{ProgressToken: params.Meta.ProgressToken, Progress: float64(i), Total: 2})
+			server.t.Send(&Message{JSONRPC: "2.0", Method: NotificationProgress, Params: p})
+		}
+		// Progress for a request nobody is waiting on is ignored.
+		p, _ := json.Marshal(ProgressParams{ProgressToken: "other", Progress: 9})
+		server.t.Send(&Message{JSONRPC: "2.0", Method: NotificationProgress, Params: p})
+		return CallToolResult{}, nil
+	}
+	go server.serve()
+
+	var got []float64
+	ctx := WithProgress(context.Background(), func(p ProgressParams) {
+		got = append(got, p.Progress)
+	})
+	params := json.RawMessage(`{"name":"index","_meta":{"trace":"abc"}}`)
+	if err := client.Call(ctx, MethodToolsCall, params, nil); err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+	if len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Errorf("progress = %v, want [1 2]", got)
+	}
+
+	// Requests without a progress context carry no token.
+	if err := client.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+	if msg := server.waitFor(t, MethodPing); len(msg.Params) != 0 {
+		t.Errorf("ping params = %s, want none", msg.Params)
+	}
+}
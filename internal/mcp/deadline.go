@@ -0,0 +1,154 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrRequestTimeout is the error code MCP SDKs use for a request that timed
+// out.
+const ErrRequestTimeout = -32001
+
+// TimeoutPolicy sets deadlines for requests sent to a server.
+type TimeoutPolicy struct {
+	// Default applies to requests without a more specific deadline. Zero
+	// means no deadline.
+	Default time.Duration
+
+	// Methods maps method names to deadlines.
+	Methods map[string]time.Duration
+
+	// Tools maps tool name patterns (shell globs) to deadlines for
+	// tools/call. An exact name wins over a pattern, and longer patterns
+	// win over shorter ones.
+	Tools map[string]time.Duration
+}
+
+// Timeout returns the deadline for a request, or zero if it has none.
+func (p *TimeoutPolicy) Timeout(msg *Message) time.Duration {
+	if p == nil {
+		return 0
+	}
+
+	if msg.Method == MethodToolsCall && len(p.Tools) > 0 {
+		var params struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err == nil {
+			if d, ok := p.toolTimeout(params.Name); ok {
+				return d
+			}
+		}
+	}
+	if d, ok := p.Methods[msg.Method]; ok {
+		return d
+	}
+	return p.Default
+}
+
+func (p *TimeoutPolicy) toolTimeout(name string) (time.Duration, bool) {
+	if d, ok := p.Tools[name]; ok {
+		return d, true
+	}
+
+	patterns := make([]string, 0, len(p.Tools))
+	for pattern := range p.Tools {
+		patterns = append(patterns, pattern)
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		if len(patterns[i]) != len(patterns[j]) {
+			return len(patterns[i]) > len(patterns[j])
+		}
+		return patterns[i] < patterns[j]
+	})
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return p.Tools[pattern], true
+		}
+	}
+	return 0, false
+}
+
+// Deadlines returns middleware that enforces the policy's deadlines. When a
+// request runs out of time the server is sent notifications/cancelled, the
+// client gets an ErrRequestTimeout error, and the server's late response is
+// dropped. It belongs at the end of the chain, nearest the server, so that
+// requests other middleware answers are not timed.
+func Deadlines(policy *TimeoutPolicy) Middleware {
+	return &deadlineMiddleware{
+		policy:  policy,
+		timers:  make(map[string]*time.Timer),
+		expired: make(map[string]bool),
+	}
+}
+
+type deadlineMiddleware struct {
+	policy *TimeoutPolicy
+
+	mu      sync.Mutex
+	timers  map[string]*time.Timer
+	expired map[string]bool
+}
+
+func (m *deadlineMiddleware) Intercept(x *Exchange, msg *Message) (*Message, error) {
+	switch {
+	case x.Direction == ClientToServer && msg.IsRequest():
+		if d := m.policy.Timeout(msg); d > 0 {
+			m.start(x, msg, d)
+		}
+
+	case x.Direction == ClientToServer && msg.Method == NotificationCancelled:
+		var params CancelledParams
+		if err := json.Unmarshal(msg.Params, &params); err == nil {
+			m.stop(idKey(params.RequestID))
+		}
+
+	case x.Direction == ServerToClient && msg.IsResponse():
+		key := idKey(msg.ID)
+		m.mu.Lock()
+		late := m.expired[key]
+		delete(m.expired, key)
+		m.mu.Unlock()
+		if late {
+			return nil, nil
+		}
+		m.stop(key)
+	}
+	return msg, nil
+}
+
+func (m *deadlineMiddleware) start(x *Exchange, msg *Message, d time.Duration) {
+	key := idKey(msg.ID)
+	id, method := msg.ID, msg.Method
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.timers[key] = time.AfterFunc(d, func() {
+		m.mu.Lock()
+		if _, ok := m.timers[key]; !ok {
+			m.mu.Unlock()
+			return
+		}
+		delete(m.timers, key)
+		m.expired[key] = true
+		m.mu.Unlock()
+
+		reason := fmt.Sprintf("%s timed out after %s", method, d)
+		params, _ := json.Marshal(CancelledParams{RequestID: id, Reason: reason})
+		x.Inject(&Message{JSONRPC: "2.0", Method: NotificationCancelled, Params: params})
+		x.Reply(&Message{JSONRPC: "2.0", ID: id, Error: &Error{Code: ErrRequestTimeout, Message: reason}})
+	})
+}
+
+func (m *deadlineMiddleware) stop(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if timer, ok := m.timers[key]; ok {
+		timer.Stop()
+		delete(m.timers, key)
+	}
+}
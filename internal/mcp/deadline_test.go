@@ -0,0 +1,123 @@
+package mcp
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestTimeoutPolicy(t *testing.T) {
+	policy := &TimeoutPolicy{
+		Default: time.Minute,
+		Methods: map[string]time.Duration{MethodToolsCall: 2 * time.Minute},
+		Tools: map[string]time.Duration{
+			"browser_*":          5 * time.Minute,
+			"browser_screenshot": 10 * time.Second,
+			"browser_nav*":       3 * time.Minute,
+		},
+	}
+	call := func(name string) *Message {
+		params, _ := json.Marshal(map[string]string{"name": name})
+		return &Message{JSONRPC: "2.0", ID: 1, Method: MethodToolsCall, Params: params}
+	}
+
+	tests := []struct {
+		msg  *Message
+		want time.Duration
+	}{
+		{call("browser_screenshot"), 10 * time.Second},
+		{call("browser_navigate"), 3 * time.Minute},
+		{call("browser_click"), 5 * time.Minute},
+		{call("search"), 2 * time.Minute},
+		{&Message{JSONRPC: "2.0", ID: 1, Method: MethodPing}, time.Minute},
+	}
+	for _, tt := range tests {
+		if got := policy.Timeout(tt.msg); got != tt.want {
+			t.Errorf("Timeout(%s %s) = %v, want %v", tt.msg.Method, tt.msg.Params, got, tt.want)
+		}
+	}
+
+	if got := (*TimeoutPolicy)(nil).Timeout(call("x")); got != 0 {
+		t.Errorf("nil policy Timeout() = %v, want 0", got)
+	}
+}
+
+func TestDeadlinesCancelSlowRequest(t *testing.T) {
+	client, server := startProxy(t, Deadlines(&TimeoutPolicy{
+		Tools: map[string]time.Duration{"slow": 50 * time.Millisecond},
+	}))
+
+	client.Send(&Message{JSONRPC: "2.0", ID: 1, Method: MethodToolsCall, Params: json.RawMessage(`{"name":"slow"}`)})
+	if msg, _ := server.Receive(); msg.Method != MethodToolsCall {
+		t.Fatalf("server received %+v, want the tools/call", msg)
+	}
+
+	msg, _ := server.Receive()
+	var params CancelledParams
+	if msg.Method != NotificationCancelled || json.Unmarshal(msg.Params, &params) != nil || idKey(params.RequestID) != idKey(1) {
+		t.Fatalf("server received %+v, want notifications/cancelled for request 1", msg)
+	}
+
+	resp, _ := client.Receive()
+	if resp.Error == nil || resp.Error.Code != ErrRequestTimeout || idKey(resp.ID) != idKey(1) {
+		t.Fatalf("client received %+v, want a timeout error for request 1", resp)
+	}
+
+	// The server's late answer is dropped; later requests are unaffected.
+	server.Send(&Message{JSONRPC: "2.0", ID: 1, Result: json.RawMessage(`{}`)})
+	client.Send(&Message{JSONRPC: "2.0", ID: 2, Method: MethodPing})
+	ping, _ := server.Receive()
+	server.Send(&Message{JSONRPC: "2.0", ID: ping.ID, Result: json.RawMessage(`{}`)})
+	if resp, _ := client.Receive(); idKey(resp.ID) != idKey(2) {
+		t.Errorf("client received %+v, want the ping response", resp)
+	}
+}
+
+func TestProxyCancelsInflightOnDisconnect(t *testing.T) {
+	clientSide, client := newStdioPeer(t)
+	proxyServer, server := NewPipe()
+	defer server.Close()
+	go NewProxy(clientSide, proxyServer).Run()
+
+	client.write(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"hang"}}`)
+	client.write(`{"jsonrpc":"2.0","id":2,"method":"ping"}`)
+	server.Receive()
+	server.Receive()
+	server.Send(&Message{JSONRPC: "2.0", ID: 2, Result: json.RawMessage(`{}`)})
+	client.read()
+
+	client.w.(interface{ Close() error }).Close()
+
+	msg, err := server.Receive()
+	if err != nil {
+		t.Fatalf("Receive() error = %v", err)
+	}
+	var params CancelledParams
+	if msg.Method != NotificationCancelled || json.Unmarshal(msg.Params, &params) != nil || idKey(params.RequestID) != idKey(1) {
+		t.Errorf("server received %+v, want notifications/cancelled for request 1", msg)
+	}
+}
+
+func TestDeadlinesRetireInflightRequest(t *testing.T) {
+	client, proxyClient := NewPipe()
+	proxyServer, server := NewPipe()
+	defer client.Close()
+	defer server.Close()
+
+	proxy := NewProxy(proxyClient, proxyServer)
+	proxy.Use(Deadlines(&TimeoutPolicy{Default: 50 * time.Millisecond}))
+	go proxy.Run()
+
+	client.Send(&Message{JSONRPC: "2.0", ID: 1, Method: MethodToolsCall, Params: json.RawMessage(`{"name":"hang"}`)})
+	server.Receive()
+	server.Receive() // notifications/cancelled
+	if resp, _ := client.Receive(); resp.Error == nil || resp.Error.Code != ErrRequestTimeout {
+		t.Fatalf("client received %+v, want a timeout error", resp)
+	}
+
+	proxy.mu.Lock()
+	defer proxy.mu.Unlock()
+	if len(proxy.inflight) != 0 {
+		t.Errorf("proxy still tracks %d requests after the deadline", len(proxy.inflight))
+	}
+}
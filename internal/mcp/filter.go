@@ -0,0 +1,143 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sync"
+)
+
+// ToolFilter decides which tools a server may expose. Patterns use shell
+// glob syntax as in path.Match, e.g. "write_*".
+type ToolFilter struct {
+	// Allow lists the tools that may be used. Empty allows all tools.
+	Allow []string
+
+	// Deny lists tools that are hidden even when allowed.
+	Deny []string
+}
+
+// Allowed reports whether the named tool passes the filter.
+func (f *ToolFilter) Allowed(name string) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.Allow) > 0 && !matchAny(f.Allow, name) {
+		return false
+	}
+	return !matchAny(f.Deny, name)
+}
+
+// FilterTools returns middleware that hides tools rejected by the filter
+// from tools/list results and refuses calls to them.
+func FilterTools(f *ToolFilter) Middleware {
+	return &toolFilterMiddleware{
+		filter:    f,
+		toolLists: make(map[string]bool),
+	}
+}
+
+type toolFilterMiddleware struct {
+	filter *ToolFilter
+
+	mu        sync.Mutex
+	toolLists map[string]bool
+}
+
+func (m *toolFilterMiddleware) Intercept(x *Exchange, msg *Message) (*Message, error) {
+	if x.Direction == ServerToClient {
+		return m.fromServer(msg), nil
+	}
+	if !msg.IsRequest() {
+		return msg, nil
+	}
+
+	switch msg.Method {
+	case MethodToolsList:
+		m.mu.Lock()
+		m.toolLists[idKey(msg.ID)] = true
+		m.mu.Unlock()
+
+	case MethodToolsCall:
+		var params CallToolParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return msg, nil
+		}
+		if !m.filter.Allowed(params.Name) {
+			return nil, x.Reply(&Message{
+				JSONRPC: "2.0",
+				ID:      msg.ID,
+				Error:   &Error{Code: ErrInvalidParams, Message: fmt.Sprintf("tool %q is not allowed", params.Name)},
+			})
+		}
+	}
+
+	return msg, nil
+}
+
+func (m *toolFilterMiddleware) fromServer(msg *Message) *Message {
+	if !msg.IsResponse() {
+		return msg
+	}
+
+	key := idKey(msg.ID)
+	m.mu.Lock()
+	isToolList := m.toolLists[key]
+	delete(m.toolLists, key)
+	m.mu.Unlock()
+
+	if !isToolList || msg.Error != nil {
+		return msg
+	}
+
+	result, err := m.filter.filterToolList(msg.Result)
+	if err != nil {
+		return msg
+	}
+	filtered := *msg
+	filtered.Result = result
+	return &filtered
+}
+
+// filterToolList removes disallowed tools from a raw tools/list result,
+// leaving all other fields untouched.
+func (f *ToolFilter) filterToolList(raw json.RawMessage) (json.RawMessage, error) {
+	var result map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+
+	var tools []json.RawMessage
+	if err := json.Unmarshal(result["tools"], &tools); err != nil {
+		return nil, err
+	}
+
+	kept := make([]json.RawMessage, 0, len(tools))
+	for _, tool := range tools {
+		var t struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(tool, &t); err != nil {
+			return nil, err
+		}
+		if f.Allowed(t.Name) {
+			kept = append(kept, tool)
+		}
+	}
+
+	data, err := json.Marshal(kept)
+	if err != nil {
+		return nil, err
+	}
+	result["tools"] = data
+	return json.Marshal(result)
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
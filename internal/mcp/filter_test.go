@@ -0,0 +1,88 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestToolFilterAllowed(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter *ToolFilter
+		tool   string
+		want   bool
+	}{
+		{"nil filter", nil, "write_file", true},
+		{"empty filter", &ToolFilter{}, "write_file", true},
+		{"denied by glob", &ToolFilter{Deny: []string{"write_*"}}, "write_file", false},
+		{"not denied", &ToolFilter{Deny: []string{"write_*"}}, "read_file", true},
+		{"allowed", &ToolFilter{Allow: []string{"read_*"}}, "read_file", true},
+		{"not in allow list", &ToolFilter{Allow: []string{"read_*"}}, "write_file", false},
+		{"deny wins over allow", &ToolFilter{Allow: []string{"*"}, Deny: []string{"delete_branch"}}, "delete_branch", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Allowed(tt.tool); got != tt.want {
+				t.Errorf("Allowed(%q) = %v, want %v", tt.tool, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProxyToolFilter(t *testing.T) {
+	backendEnd, serverEnd := NewPipe()
+	srv := NewServer(serverEnd, &ServerOptions{Capabilities: ServerCapabilities{Tools: &ToolsCapability{}}})
+	called := make(chan string, 4)
+	srv.HandleRequest(MethodToolsList, func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return ListToolsResult{Tools: []Tool{
+			{Name: "read_file", InputSchema: json.RawMessage(`{}`)},
+			{Name: "write_file", InputSchema: json.RawMessage(`{}`)},
+			{Name: "delete_file", InputSchema: json.RawMessage(`{}`)},
+		}}, nil
+	})
+	srv.HandleRequest(MethodToolsCall, func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		var p CallToolParams
+		json.Unmarshal(params, &p)
+		called <- p.Name
+		return CallToolResult{Content: []Content{{Type: "text", Text: "ok"}}}, nil
+	})
+	go srv.Serve()
+
+	clientEnd, proxyEnd := NewPipe()
+	proxy := NewProxy(proxyEnd, backendEnd)
+	proxy.Use(FilterTools(&ToolFilter{Deny: []string{"write_*", "delete_*"}}))
+	go proxy.Run()
+
+	client := NewClient(clientEnd, &ClientOptions{RequestTimeout: 2 * time.Second})
+	defer client.Close()
+	ctx := context.Background()
+
+	if _, err := client.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	tools, err := client.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("ListTools() error = %v", err)
+	}
+	if len(tools) != 1 || tools[0].Name != "read_file" {
+		t.Errorf("ListTools() = %+v, want only read_file", tools)
+	}
+
+	var rpcErr *Error
+	_, err = client.CallTool(ctx, "write_file", nil)
+	if !errors.As(err, &rpcErr) || rpcErr.Code != ErrInvalidParams {
+		t.Errorf("CallTool(write_file) error = %v, want invalid params", err)
+	}
+
+	if _, err := client.CallTool(ctx, "read_file", nil); err != nil {
+		t.Errorf("CallTool(read_file) error = %v", err)
+	}
+	if name := <-called; name != "read_file" {
+		t.Errorf("server received call to %q, want read_file", name)
+	}
+}
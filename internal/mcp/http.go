@@ -0,0 +1,366 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HTTP header names used by the Streamable HTTP transport.
+const (
+	HeaderSessionID       = "Mcp-Session-Id"
+	HeaderProtocolVersion = "MCP-Protocol-Version"
+	HeaderLastEventID     = "Last-Event-ID"
+)
+
+// ErrSessionExpired is returned when the server no longer recognizes the session.
+var ErrSessionExpired = errors.New("mcp session expired")
+
+// defaultRetryDelay is the reconnection delay used when the server does not send one.
+const defaultRetryDelay = time.Second
+
+// maxStreamRetries bounds reconnection attempts for a single SSE stream.
+const maxStreamRetries = 5
+
+// HTTPTransportOptions configures HTTP-based client transports.
+type HTTPTransportOptions struct {
+	// Client is the HTTP client to use. Defaults to a client without a timeout,
+	// since SSE streams are long-lived.
+	Client *http.Client
+
+	// Headers are added to every request (e.g. Authorization).
+	Headers map[string]string
+}
+
+// StreamableHTTPTransport implements the MCP Streamable HTTP transport as a client.
+// Messages are POSTed to a single endpoint; responses arrive either as JSON
+// bodies or as SSE streams, and server-initiated messages are read from a
+// standalone GET stream once the session is initialized.
+type StreamableHTTPTransport struct {
+	endpoint string
+	client   *http.Client
+	headers  map[string]string
+
+	mu              sync.Mutex
+	sessionID       string
+	protocolVersion string
+
+	incoming  chan *Message
+	ctx       context.Context
+	cancel    context.CancelFunc
+	getOnce   sync.Once
+	closeOnce sync.Once
+}
+
+// NewStreamableHTTPTransport creates a Streamable HTTP transport for the given endpoint URL.
+func NewStreamableHTTPTransport(endpoint string, opts *HTTPTransportOptions) *StreamableHTTPTransport {
+	if opts == nil {
+		opts = &HTTPTransportOptions{}
+	}
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &StreamableHTTPTransport{
+		endpoint: endpoint,
+		client:   client,
+		headers:  opts.Headers,
+		incoming: make(chan *Message, 64),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// SessionID returns the session ID assigned by the server, if any.
+func (t *StreamableHTTPTransport) SessionID() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sessionID
+}
+
+// SetProtocolVersion sets the negotiated protocol version sent with subsequent requests.
+func (t *StreamableHTTPTransport) SetProtocolVersion(version string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.protocolVersion = version
+}
+
+// Send POSTs a message to the server.
+func (t *StreamableHTTPTransport) Send(msg *Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	return t.post(data, []*Message{msg})
+}
+
+// SendBatch POSTs messages to the server as a single JSON-RPC batch. The
+// responses may come back as a JSON array or on an SSE stream.
+func (t *StreamableHTTPTransport) SendBatch(msgs []*Message) error {
+	data, err := json.Marshal(msgs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal batch: %w", err)
+	}
+	return t.post(data, msgs)
+}
+
+// post POSTs an encoded message or batch and delivers the replies to msgs.
+func (t *StreamableHTTPTransport) post(data []byte, msgs []*Message) error {
+	req, err := http.NewRequestWithContext(t.ctx, http.MethodPost, t.endpoint, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	t.applyHeaders(req)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post message: %w", err)
+	}
+
+	if err := t.checkResponse(resp); err != nil {
+		resp.Body.Close()
+		return err
+	}
+
+	if id := resp.Header.Get(HeaderSessionID); id != "" {
+		t.mu.Lock()
+		t.sessionID = id
+		t.mu.Unlock()
+	}
+
+	if resp.StatusCode == http.StatusAccepted {
+		resp.Body.Close()
+		for _, msg := range msgs {
+			if msg.Method == NotificationInitialized {
+				t.getOnce.Do(func() { go t.runStandaloneStream() })
+			}
+		}
+		return nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/event-stream":
+		go t.readStream(resp.Body, awaitedResponses(msgs))
+		return nil
+	case "application/json":
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("failed to read response: %w", err)
+		}
+		replies, err := decodeMessages(body)
+		if err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+		for _, reply := range replies {
+			t.deliver(reply)
+		}
+		return nil
+	default:
+		resp.Body.Close()
+		return fmt.Errorf("unexpected response content type %q", mediaType)
+	}
+}
+
+// Receive returns the next message from the server.
+func (t *StreamableHTTPTransport) Receive() (*Message, error) {
+	select {
+	case msg := <-t.incoming:
+		return msg, nil
+	case <-t.ctx.Done():
+		return nil, io.EOF
+	}
+}
+
+// Close terminates the session and stops all streams.
+func (t *StreamableHTTPTransport) Close() error {
+	t.closeOnce.Do(func() {
+		if sessionID := t.SessionID(); sessionID != "" {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if req, err := http.NewRequestWithContext(ctx, http.MethodDelete, t.endpoint, nil); err == nil {
+				t.applyHeaders(req)
+				if resp, err := t.client.Do(req); err == nil {
+					resp.Body.Close()
+				}
+			}
+		}
+		t.cancel()
+	})
+	return nil
+}
+
+func (t *StreamableHTTPTransport) applyHeaders(req *http.Request) {
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.sessionID != "" {
+		req.Header.Set(HeaderSessionID, t.sessionID)
+	}
+	if t.protocolVersion != "" {
+		req.Header.Set(HeaderProtocolVersion, t.protocolVersion)
+	}
+}
+
+func (t *StreamableHTTPTransport) checkResponse(resp *http.Response) error {
+	if resp.StatusCode == http.StatusNotFound && t.SessionID() != "" {
+		return ErrSessionExpired
+	}
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("server returned HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	return nil
+}
+
+func (t *StreamableHTTPTransport) deliver(msg *Message) {
+	select {
+	case t.incoming <- msg:
+	case <-t.ctx.Done():
+	}
+}
+
+// readStream consumes an SSE stream. If the stream was opened for requests
+// and drops before all their responses arrive, it is resumed with
+// Last-Event-ID.
+func (t *StreamableHTTPTransport) readStream(body io.ReadCloser, awaited map[string]bool) {
+	lastEventID, retry := t.consumeStream(body, awaited)
+	if len(awaited) == 0 {
+		return
+	}
+
+	for attempt := 0; attempt < maxStreamRetries && lastEventID != ""; attempt++ {
+		if !t.sleep(retry) {
+			return
+		}
+		resp, err := t.openGetStream(lastEventID)
+		if err != nil {
+			continue
+		}
+		var id string
+		id, retry = t.consumeStream(resp.Body, awaited)
+		if len(awaited) == 0 {
+			return
+		}
+		if id != "" {
+			lastEventID = id
+		}
+	}
+}
+
+// runStandaloneStream keeps a GET stream open for server-initiated messages.
+func (t *StreamableHTTPTransport) runStandaloneStream() {
+	lastEventID := ""
+	retry := defaultRetryDelay
+
+	for failures := 0; failures < maxStreamRetries; {
+		resp, err := t.openGetStream(lastEventID)
+		if err != nil {
+			if errors.Is(err, errStreamUnsupported) || t.ctx.Err() != nil {
+				return
+			}
+			failures++
+		} else {
+			failures = 0
+			var id string
+			id, retry = t.consumeStream(resp.Body, nil)
+			if id != "" {
+				lastEventID = id
+			}
+		}
+
+		if !t.sleep(retry) {
+			return
+		}
+	}
+}
+
+var errStreamUnsupported = errors.New("server does not offer an SSE stream")
+
+func (t *StreamableHTTPTransport) openGetStream(lastEventID string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(t.ctx, http.MethodGet, t.endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if lastEventID != "" {
+		req.Header.Set(HeaderLastEventID, lastEventID)
+	}
+	t.applyHeaders(req)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusMethodNotAllowed {
+		resp.Body.Close()
+		return nil, errStreamUnsupported
+	}
+	if err := t.checkResponse(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp, nil
+}
+
+// consumeStream delivers messages from an SSE body until it ends, removing
+// the responses it sees from awaited. It returns the last event ID seen and
+// the server's retry delay.
+func (t *StreamableHTTPTransport) consumeStream(body io.ReadCloser, awaited map[string]bool) (string, time.Duration) {
+	defer body.Close()
+
+	lastEventID := ""
+	retry := defaultRetryDelay
+
+	reader := newSSEReader(body)
+	for {
+		ev, err := reader.Next()
+		if err != nil {
+			return lastEventID, retry
+		}
+		if ev.ID != "" {
+			lastEventID = ev.ID
+		}
+		if ev.Retry > 0 {
+			retry = ev.Retry
+		}
+		if ev.Event != "" && ev.Event != "message" {
+			continue
+		}
+
+		msgs, err := decodeMessages(ev.Data)
+		if err != nil {
+			continue
+		}
+		for _, msg := range msgs {
+			if msg.IsResponse() {
+				delete(awaited, idKey(msg.ID))
+			}
+			t.deliver(msg)
+		}
+	}
+}
+
+func (t *StreamableHTTPTransport) sleep(d time.Duration) bool {
+	select {
+	case <-time.After(d):
+		return true
+	case <-t.ctx.Done():
+		return false
+	}
+}
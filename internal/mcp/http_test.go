@@ -0,0 +1,273 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// streamableTestServer is a minimal Streamable HTTP MCP server for tests.
+type streamableTestServer struct {
+	mu          sync.Mutex
+	sessionHdrs []string
+	deleted     bool
+	lastEventID string
+	dropStream  bool
+	notify      chan *Message
+}
+
+func (s *streamableTestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.sessionHdrs = append(s.sessionHdrs, r.Method+" "+r.Header.Get(HeaderSessionID))
+	s.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodDelete:
+		s.mu.Lock()
+		s.deleted = true
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+		return
+
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		flusher := w.(http.Flusher)
+		flusher.Flush()
+
+		if id := r.Header.Get(HeaderLastEventID); id != "" {
+			s.mu.Lock()
+			s.lastEventID = id
+			s.mu.Unlock()
+			// Replay the response lost when the POST stream dropped.
+			fmt.Fprintf(w, "id: 2\ndata: {\"jsonrpc\":\"2.0\",\"id\":2,\"result\":{\"resumed\":true}}\n\n")
+			flusher.Flush()
+			return
+		}
+
+		for {
+			select {
+			case msg := <-s.notify:
+				data, _ := json.Marshal(msg)
+				fmt.Fprintf(w, "data: %s\n\n", data)
+				flusher.Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}
+
+	var msg Message
+	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case msg.Method == MethodInitialize:
+		w.Header().Set(HeaderSessionID, "session-1")
+		w.Header().Set("Content-Type", "application/json")
+		result, _ := json.Marshal(InitializeResult{
+			ProtocolVersion: LatestProtocolVersion,
+			ServerInfo:      Implementation{Name: "http-test", Version: "1.0.0"},
+		})
+		json.NewEncoder(w).Encode(&Message{JSONRPC: "2.0", ID: msg.ID, Result: result})
+
+	case !msg.IsRequest():
+		w.WriteHeader(http.StatusAccepted)
+
+	default:
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "id: 1\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/progress\"}\n\n")
+		if s.dropStream {
+			return
+		}
+		data, _ := json.Marshal(&Message{JSONRPC: "2.0", ID: msg.ID, Result: json.RawMessage(`{"ok":true}`)})
+		fmt.Fprintf(w, "id: 2\ndata: %s\n\n", data)
+	}
+}
+
+func TestStreamableHTTPTransport(t *testing.T) {
+	handler := &streamableTestServer{notify: make(chan *Message, 1)}
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	transport := NewStreamableHTTPTransport(srv.URL, &HTTPTransportOptions{
+		Headers: map[string]string{"Authorization": "Bearer test"},
+	})
+	client := NewClient(transport, &ClientOptions{RequestTimeout: 2 * time.Second})
+
+	progress := make(chan struct{}, 1)
+	client.OnNotification(NotificationProgress, func(string, json.RawMessage) { progress <- struct{}{} })
+	changed := make(chan struct{}, 1)
+	client.OnNotification(NotificationToolsChanged, func(string, json.RawMessage) { changed <- struct{}{} })
+
+	result, err := client.Initialize(context.Background())
+	if err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	if result.ServerInfo.Name != "http-test" {
+		t.Errorf("ServerInfo.Name = %q, want %q", result.ServerInfo.Name, "http-test")
+	}
+	if transport.SessionID() != "session-1" {
+		t.Errorf("SessionID() = %q, want %q", transport.SessionID(), "session-1")
+	}
+
+	var out struct{ OK bool }
+	if err := client.Call(context.Background(), "test/sse", nil, &out); err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+	if !out.OK {
+		t.Error("Call() did not decode SSE response")
+	}
+
+	select {
+	case <-progress:
+	case <-time.After(2 * time.Second):
+		t.Error("notification on POST stream was not delivered")
+	}
+
+	handler.notify <- &Message{JSONRPC: "2.0", Method: NotificationToolsChanged}
+	select {
+	case <-changed:
+	case <-time.After(2 * time.Second):
+		t.Error("notification on GET stream was not delivered")
+	}
+
+	client.Close()
+
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	if !handler.deleted {
+		t.Error("Close() did not terminate the session")
+	}
+	for _, hdr := range handler.sessionHdrs[1:] {
+		if !strings.HasSuffix(hdr, "session-1") {
+			t.Errorf("request %q missing session header", hdr)
+		}
+	}
+}
+
+func TestStreamableHTTPTransportResumption(t *testing.T) {
+	handler := &streamableTestServer{notify: make(chan *Message), dropStream: true}
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	transport := NewStreamableHTTPTransport(srv.URL, nil)
+	defer transport.Close()
+
+	if err := transport.Send(&Message{JSONRPC: "2.0", ID: 2, Method: "test/resume"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	deadline := time.After(5 * time.Second)
+	for {
+		msgCh := make(chan *Message, 1)
+		go func() {
+			msg, err := transport.Receive()
+			if err == nil {
+				msgCh <- msg
+			}
+		}()
+
+		select {
+		case msg := <-msgCh:
+			if !msg.IsResponse() {
+				continue
+			}
+			if string(msg.Result) != `{"resumed":true}` {
+				t.Errorf("Result = %s, want resumed response", msg.Result)
+			}
+			handler.mu.Lock()
+			if handler.lastEventID != "1" {
+				t.Errorf("Last-Event-ID = %q, want %q", handler.lastEventID, "1")
+			}
+			handler.mu.Unlock()
+			return
+		case <-deadline:
+			t.Fatal("timed out waiting for resumed response")
+		}
+	}
+}
+
+func TestStreamableHTTPTransportSessionExpired(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(HeaderSessionID) != "" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set(HeaderSessionID, "gone")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	transport := NewStreamableHTTPTransport(srv.URL, nil)
+	defer transport.Close()
+
+	if err := transport.Send(&Message{JSONRPC: "2.0", Method: "notifications/test"}); err != nil {
+		t.Fatalf("first Send() error = %v", err)
+	}
+	if err := transport.Send(&Message{JSONRPC: "2.0", Method: "notifications/test"}); err != ErrSessionExpired {
+		t.Errorf("second Send() error = %v, want ErrSessionExpired", err)
+	}
+}
+
+func TestStreamableHTTPTransportBatch(t *testing.T) {
+	for _, mode := range []string{"json", "sse"} {
+		t.Run(mode, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				msgs, err := decodeBatch(body)
+				if err != nil {
+					http.Error(w, "expected a batch: "+err.Error(), http.StatusBadRequest)
+					return
+				}
+				var responses []*Message
+				for _, msg := range msgs {
+					if msg.IsRequest() {
+						responses = append(responses, &Message{JSONRPC: "2.0", ID: msg.ID, Result: json.RawMessage(`{}`)})
+					}
+				}
+				data, _ := json.Marshal(responses)
+				if mode == "json" {
+					w.Header().Set("Content-Type", "application/json")
+					w.Write(data)
+					return
+				}
+				w.Header().Set("Content-Type", "text/event-stream")
+				fmt.Fprintf(w, "id: 1\ndata: %s\n\n", data)
+			}))
+			defer srv.Close()
+
+			transport := NewStreamableHTTPTransport(srv.URL, nil)
+			defer transport.Close()
+
+			err := transport.SendBatch([]*Message{
+				{JSONRPC: "2.0", ID: 1, Method: MethodPing},
+				{JSONRPC: "2.0", Method: "notifications/test"},
+				{JSONRPC: "2.0", ID: 2, Method: MethodPing},
+			})
+			if err != nil {
+				t.Fatalf("SendBatch() error = %v", err)
+			}
+
+			for _, want := range []string{"1", "2"} {
+				msg, err := transport.Receive()
+				if err != nil {
+					t.Fatalf("Receive() error = %v", err)
+				}
+				if !msg.IsResponse() || idKey(msg.ID) != want {
+					t.Errorf("Receive() = %+v, want response %s", msg, want)
+				}
+			}
+		})
+	}
+}
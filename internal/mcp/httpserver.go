@@ -0,0 +1,630 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SessionFactory creates the backend transport for a new HTTP session. The
+// context is that of the initialize request and must not be retained.
+// Closing the returned transport must release everything the session owns.
+type SessionFactory func(ctx context.Context) (Transport, error)
+
+// HTTPHandler serves MCP over the Streamable HTTP transport, relaying each
+// session to a backend transport created by a SessionFactory.
+type HTTPHandler struct {
+	newSession SessionFactory
+	opts       HTTPHandlerOptions
+
+	mu       sync.Mutex
+	sessions map[string]*httpSession
+}
+
+// HTTPHandlerOptions configures an HTTPHandler.
+type HTTPHandlerOptions struct {
+	// AllowedHosts lists host names, besides localhost and loopback
+	// addresses, that requests may be addressed to and that browser
+	// requests may come from. Others are rejected, so that a page whose
+	// name resolves to a loopback address cannot reach the handler.
+	AllowedHosts []string
+
+	// MaxMessageSize limits the size of one POST body, a message or a
+	// batch. Defaults to DefaultMaxMessageSize.
+	MaxMessageSize int
+
+	// IdleTimeout closes a session, and its backend, once it has gone this
+	// long without a request or an open stream. Defaults to
+	// DefaultSessionIdleTimeout.
+	IdleTimeout time.Duration
+}
+
+// DefaultSessionIdleTimeout is how long an HTTP session may go unused
+// before it is closed, for clients that never end their sessions.
+const DefaultSessionIdleTimeout = 30 * time.Minute
+
+// NewHTTPHandler creates a Streamable HTTP handler with default options.
+func NewHTTPHandler(newSession SessionFactory) *HTTPHandler {
+	return NewHTTPHandlerWithOptions(newSession, nil)
+}
+
+// NewHTTPHandlerWithOptions creates a Streamable HTTP handler with the given
+// options. A nil opts uses the defaults.
+func NewHTTPHandlerWithOptions(newSession SessionFactory, opts *HTTPHandlerOptions) *HTTPHandler {
+	h := &HTTPHandler{
+		newSession: newSession,
+		sessions:   make(map[string]*httpSession),
+	}
+	if opts != nil {
+		h.opts = *opts
+	}
+	if h.opts.MaxMessageSize <= 0 {
+		h.opts.MaxMessageSize = DefaultMaxMessageSize
+	}
+	if h.opts.IdleTimeout <= 0 {
+		h.opts.IdleTimeout = DefaultSessionIdleTimeout
+	}
+	return h
+}
+
+// httpSession relays one HTTP session to its backend transport.
+type httpSession struct {
+	id      string
+	backend Transport
+
+	mu      sync.Mutex
+	pending map[string]chan *Message
+
+	// active counts the requests and streams using the session; idle
+	// expires it once there have been none for the idle timeout.
+	active      int
+	idle        *time.Timer
+	idleTimeout time.Duration
+
+	// stream carries server-initiated messages not tied to a pending request.
+	stream    chan *Message
+	listening int32
+	eventID   int64
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// ServeHTTP implements http.Handler.
+func (h *HTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.allowedHost(r.Host) {
+		http.Error(w, "host not allowed", http.StatusForbidden)
+		return
+	}
+	if !h.allowedOrigin(r.Header.Get("Origin")) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		h.handlePost(w, r)
+	case http.MethodGet:
+		h.handleGet(w, r)
+	case http.MethodDelete:
+		h.handleDelete(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// SessionCount returns the number of active sessions.
+func (h *HTTPHandler) SessionCount() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.sessions)
+}
+
+// Close terminates all sessions.
+func (h *HTTPHandler) Close() error {
+	h.mu.Lock()
+	sessions := make([]*httpSession, 0, len(h.sessions))
+	for _, s := range h.sessions {
+		sessions = append(sessions, s)
+	}
+	h.sessions = make(map[string]*httpSession)
+	h.mu.Unlock()
+
+	for _, s := range sessions {
+		s.close()
+	}
+	return nil
+}
+
+func (h *HTTPHandler) handlePost(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, int64(h.opts.MaxMessageSize)))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeJSONError(w, http.StatusRequestEntityTooLarge, nil, ErrInvalidRequest,
+			fmt.Sprintf("message exceeds the limit of %d bytes", h.opts.MaxMessageSize))
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, nil, ErrParse, "failed to read request: "+err.Error())
+		return
+	}
+	if body = bytes.TrimSpace(body); len(body) > 0 && body[0] == '[' {
+		h.handleBatch(w, r, body)
+		return
+	}
+
+	var msg Message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		writeJSONError(w, http.StatusBadRequest, nil, ErrParse, "parse error: "+err.Error())
+		return
+	}
+
+	var session *httpSession
+	if msg.Method == MethodInitialize {
+		if r.Header.Get(HeaderSessionID) != "" {
+			writeJSONError(w, http.StatusBadRequest, msg.ID, ErrInvalidRequest, "initialize must not carry a session id")
+			return
+		}
+		s, err := h.createSession(r.Context())
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, msg.ID, ErrInternal, err.Error())
+			return
+		}
+		session = s
+		w.Header().Set(HeaderSessionID, session.id)
+	} else {
+		s, status := h.lookup(r)
+		if s == nil {
+			writeJSONError(w, status, msg.ID, ErrInvalidRequest, http.StatusText(status))
+			return
+		}
+		session = s
+	}
+	defer session.end()
+
+	if !msg.IsRequest() {
+		if err := session.backend.Send(&msg); err != nil {
+			writeJSONError(w, http.StatusBadGateway, nil, ErrInternal, err.Error())
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	session.relayRequest(w, r, &msg)
+}
+
+// handleBatch relays a JSON-RPC batch to an existing session and answers
+// with the responses to its requests as a JSON array.
+func (h *HTTPHandler) handleBatch(w http.ResponseWriter, r *http.Request, body []byte) {
+	msgs, err := decodeBatch(body)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, nil, ErrParse, "parse error: "+err.Error())
+		return
+	}
+	for _, msg := range msgs {
+		if msg.Method == MethodInitialize {
+			writeJSONError(w, http.StatusBadRequest, msg.ID, ErrInvalidRequest, "initialize must not be part of a batch")
+			return
+		}
+	}
+
+	session, status := h.lookup(r)
+	if session == nil {
+		writeJSONError(w, status, nil, ErrInvalidRequest, http.StatusText(status))
+		return
+	}
+	defer session.end()
+
+	responses, err := session.relayBatch(r.Context(), msgs)
+	if err != nil {
+		writeJSONError(w, http.StatusBadGateway, nil, ErrInternal, err.Error())
+		return
+	}
+	if len(responses) == 0 {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(responses)
+}
+
+func (h *HTTPHandler) handleGet(w http.ResponseWriter, r *http.Request) {
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		http.Error(w, "client must accept text/event-stream", http.StatusNotAcceptable)
+		return
+	}
+
+	session, status := h.lookup(r)
+	if session == nil {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	defer session.end()
+
+	if !atomic.CompareAndSwapInt32(&session.listening, 0, 1) {
+		http.Error(w, "stream already open for session", http.StatusConflict)
+		return
+	}
+	defer atomic.StoreInt32(&session.listening, 0)
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case msg := <-session.stream:
+			if err := session.writeEvent(w, msg); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		case <-session.done:
+			return
+		}
+	}
+}
+
+func (h *HTTPHandler) handleDelete(w http.ResponseWriter, r *http.Request) {
+	session, status := h.lookup(r)
+	if session == nil {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	defer session.end()
+
+	h.remove(session)
+	session.close()
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *HTTPHandler) createSession(ctx context.Context) (*httpSession, error) {
+	backend, err := h.newSession(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	id, err := newSessionID()
+	if err != nil {
+		backend.Close()
+		return nil, err
+	}
+
+	s := &httpSession{
+		id:          id,
+		backend:     backend,
+		pending:     make(map[string]chan *Message),
+		active:      1, // busy with the initialize request, as from lookup
+		idleTimeout: h.opts.IdleTimeout,
+		stream:      make(chan *Message, 256),
+		done:        make(chan struct{}),
+	}
+	s.idle = time.AfterFunc(s.idleTimeout, func() {
+		h.remove(s)
+		s.close()
+	})
+	s.idle.Stop() // until the initialize request ends
+
+	h.mu.Lock()
+	h.sessions[id] = s
+	h.mu.Unlock()
+
+	go func() {
+		s.receiveLoop()
+		h.remove(s)
+		s.close()
+	}()
+
+	return s, nil
+}
+
+// lookup returns the session a request belongs to, marked busy until its
+// end method is called.
+func (h *HTTPHandler) lookup(r *http.Request) (*httpSession, int) {
+	id := r.Header.Get(HeaderSessionID)
+	if id == "" {
+		return nil, http.StatusBadRequest
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.sessions[id]
+	if !ok {
+		return nil, http.StatusNotFound
+	}
+	s.begin()
+	return s, http.StatusOK
+}
+
+func (h *HTTPHandler) remove(s *httpSession) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.sessions[s.id] == s {
+		delete(h.sessions, s.id)
+	}
+}
+
+// relayRequest forwards a request to the backend and writes its response,
+// as an SSE stream if the client accepts one and JSON otherwise.
+func (s *httpSession) relayRequest(w http.ResponseWriter, r *http.Request, msg *Message) {
+	key := idKey(msg.ID)
+	ch := make(chan *Message, 1)
+
+	s.mu.Lock()
+	s.pending[key] = ch
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		if s.pending[key] == ch {
+			delete(s.pending, key)
+		}
+		s.mu.Unlock()
+	}()
+
+	if err := s.backend.Send(msg); err != nil {
+		writeJSONError(w, http.StatusBadGateway, msg.ID, ErrInternal, err.Error())
+		return
+	}
+
+	flusher, canStream := w.(http.Flusher)
+	if !canStream || !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		select {
+		case resp := <-ch:
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(resp)
+		case <-r.Context().Done():
+			s.cancel(msg.ID, "client disconnected")
+		case <-s.done:
+			writeJSONError(w, http.StatusBadGateway, msg.ID, ErrInternal, "session closed")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// Without a standalone GET stream, server-initiated messages are
+	// delivered on the stream of whichever request is in flight.
+	var stream chan *Message
+	if atomic.LoadInt32(&s.listening) == 0 {
+		stream = s.stream
+	}
+
+	for {
+		select {
+		case resp := <-ch:
+			s.writeEvent(w, resp)
+			flusher.Flush()
+			return
+		case note := <-stream:
+			if err := s.writeEvent(w, note); err != nil {
+				s.cancel(msg.ID, "client disconnected")
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			s.cancel(msg.ID, "client disconnected")
+			return
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// relayBatch forwards the messages of a batch to the backend and waits for
+// the responses to its requests.
+func (s *httpSession) relayBatch(ctx context.Context, msgs []*Message) ([]*Message, error) {
+	waiting := make(map[string]chan *Message)
+	s.mu.Lock()
+	for _, msg := range msgs {
+		if msg.IsRequest() {
+			ch := make(chan *Message, 1)
+			waiting[idKey(msg.ID)] = ch
+			s.pending[idKey(msg.ID)] = ch
+		}
+	}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		for key, ch := range waiting {
+			if s.pending[key] == ch {
+				delete(s.pending, key)
+			}
+		}
+		s.mu.Unlock()
+	}()
+
+	for _, msg := range msgs {
+		if err := s.backend.Send(msg); err != nil {
+			return nil, err
+		}
+	}
+
+	responses := make([]*Message, 0, len(waiting))
+	for i, msg := range msgs {
+		if !msg.IsRequest() {
+			continue
+		}
+		select {
+		case resp := <-waiting[idKey(msg.ID)]:
+			responses = append(responses, resp)
+		case <-ctx.Done():
+			// Requests from this one on are still unanswered.
+			for _, pending := range msgs[i:] {
+				if pending.IsRequest() {
+					s.cancel(pending.ID, "client disconnected")
+				}
+			}
+			return nil, ctx.Err()
+		case <-s.done:
+			return nil, fmt.Errorf("session closed")
+		}
+	}
+	return responses, nil
+}
+
+func (s *httpSession) receiveLoop() {
+	for {
+		msg, err := s.backend.Receive()
+		if IsRecoverable(err) {
+			continue
+		}
+		if err != nil {
+			return
+		}
+
+		if msg.IsResponse() {
+			// A duplicate or late response finds no waiter and is dropped
+			// rather than blocking the loop.
+			s.mu.Lock()
+			key := idKey(msg.ID)
+			ch, ok := s.pending[key]
+			delete(s.pending, key)
+			s.mu.Unlock()
+			if ok {
+				select {
+				case ch <- msg:
+				default:
+				}
+			}
+			continue
+		}
+
+		select {
+		case s.stream <- msg:
+		default:
+			// Nobody is listening and the buffer is full; drop the message.
+		}
+	}
+}
+
+func (s *httpSession) writeEvent(w http.ResponseWriter, msg *Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	id := strconv.FormatInt(atomic.AddInt64(&s.eventID, 1), 10)
+	return writeSSE(w, id, data)
+}
+
+func (s *httpSession) cancel(id interface{}, reason string) {
+	params, _ := json.Marshal(CancelledParams{RequestID: id, Reason: reason})
+	_ = s.backend.Send(&Message{JSONRPC: "2.0", Method: NotificationCancelled, Params: params})
+}
+
+// begin marks the session busy, which keeps it from expiring.
+func (s *httpSession) begin() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active++
+	s.idle.Stop()
+}
+
+// end marks a request or stream done, starting the idle timer once the
+// session is no longer in use.
+func (s *httpSession) end() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active--
+	if s.active > 0 {
+		return
+	}
+	select {
+	case <-s.done:
+	default:
+		s.idle.Reset(s.idleTimeout)
+	}
+}
+
+func (s *httpSession) close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.idle.Stop()
+		s.backend.Close()
+	})
+}
+
+func writeJSONError(w http.ResponseWriter, status int, id interface{}, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(&Message{
+		JSONRPC: "2.0",
+		ID:      id,
+		Error:   &Error{Code: code, Message: message},
+	})
+}
+
+// allowedOrigin reports whether a browser request may come from origin.
+// Requests without an Origin are not from a browser.
+func (h *HTTPHandler) allowedOrigin(origin string) bool {
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return h.allowedHost(u.Host)
+}
+
+// allowedHost guards against DNS rebinding: the host a request is addressed
+// to, or comes from, must be localhost, a loopback address or an allowed
+// host. Matching the Origin against the Host is not enough, as both name
+// the attacker's site in a rebinding attack.
+func (h *HTTPHandler) allowedHost(hostport string) bool {
+	host := hostport
+	if name, _, err := net.SplitHostPort(hostport); err == nil {
+		host = name
+	}
+	host = strings.TrimSuffix(strings.Trim(host, "[]"), ".")
+	if host == "" {
+		return false
+	}
+
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return true
+	}
+	for _, allowed := range h.opts.AllowedHosts {
+		if strings.EqualFold(host, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+func newSessionID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate session id: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
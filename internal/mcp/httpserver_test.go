@@ -0,0 +1,489 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHTTPHandlerPerSession(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		created int
+		servers []*fakeServer
+	)
+	handler := NewHTTPHandler(func(context.Context) (Transport, error) {
+		clientEnd, serverEnd := NewPipe()
+		server := newFakeServer(serverEnd)
+		go server.serve()
+
+		mu.Lock()
+		created++
+		servers = append(servers, server)
+		mu.Unlock()
+		return clientEnd, nil
+	})
+	defer handler.Close()
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	for i := 0; i < 2; i++ {
+		client := NewClient(NewStreamableHTTPTransport(srv.URL, nil), &ClientOptions{RequestTimeout: 2 * time.Second})
+		if _, err := client.Initialize(context.Background()); err != nil {
+			t.Fatalf("Initialize() error = %v", err)
+		}
+		if err := client.Ping(context.Background()); err != nil {
+			t.Errorf("Ping() error = %v", err)
+		}
+		client.Close()
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if created != 2 {
+		t.Errorf("sessions created = %d, want 2", created)
+	}
+	if handler.SessionCount() != 0 {
+		t.Errorf("SessionCount() = %d after clients closed, want 0", handler.SessionCount())
+	}
+}
+
+func TestHTTPHandlerRejectsUnknownSession(t *testing.T) {
+	handler := NewHTTPHandler(func(context.Context) (Transport, error) {
+		clientEnd, _ := NewPipe()
+		return clientEnd, nil
+	})
+	defer handler.Close()
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodDelete, srv.URL, nil)
+	req.Header.Set(HeaderSessionID, "missing")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("DELETE error = %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestHTTPHandlerRejectsForeignOrigin(t *testing.T) {
+	handler := NewHTTPHandler(nil)
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET error = %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+}
+
+func TestHTTPHandlerRejectsRebinding(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		host    string
+		origin  string
+		want    int
+	}{
+		{"matching origin and host", nil, "rebind.example.com:8080", "http://rebind.example.com:8080", http.StatusForbidden},
+		{"foreign host", nil, "rebind.example.com:8080", "", http.StatusForbidden},
+		{"loopback origin with foreign host", nil, "rebind.example.com", "http://localhost", http.StatusForbidden},
+		{"allowed host", []string{"mcp.internal"}, "mcp.internal:8080", "http://mcp.internal:8080", http.StatusBadRequest},
+		{"allowed host with foreign origin", []string{"mcp.internal"}, "mcp.internal:8080", "http://rebind.example.com", http.StatusForbidden},
+		{"localhost", nil, "localhost:8080", "http://localhost:3000", http.StatusBadRequest},
+		{"ipv6 loopback", nil, "[::1]:8080", "http://[::1]:3000", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHTTPHandlerWithOptions(nil, &HTTPHandlerOptions{AllowedHosts: tt.allowed})
+			srv := httptest.NewServer(handler)
+			defer srv.Close()
+
+			// Allowed requests get as far as the missing session id.
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			req.Host = tt.host
+			req.Header.Set("Accept", "text/event-stream")
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("GET error = %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPHandlerRejectsLargeBody(t *testing.T) {
+	handler := NewHTTPHandlerWithOptions(nil, &HTTPHandlerOptions{MaxMessageSize: 64})
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	body := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"padding":"` + strings.Repeat("x", 64) + `"}}`
+	resp, err := http.Post(srv.URL, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusRequestEntityTooLarge)
+	}
+	var msg Message
+	if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil || msg.Error == nil || msg.Error.Code != ErrInvalidRequest {
+		t.Errorf("response = %+v (%v), want an invalid request error", msg, err)
+	}
+}
+
+func TestHTTPHandlerDuplicateResponses(t *testing.T) {
+	handler := NewHTTPHandler(func(context.Context) (Transport, error) {
+		clientEnd, serverEnd := NewPipe()
+		server := newFakeServer(serverEnd)
+		server.handlers["echo"] = func(msg *Message) (interface{}, *Error) {
+			// Answer three times; only the first has a waiter.
+			for i := 0; i < 3; i++ {
+				server.t.Send(&Message{JSONRPC: "2.0", ID: msg.ID, Result: json.RawMessage(`{}`)})
+			}
+			return nil, nil
+		}
+		go server.serve()
+		return clientEnd, nil
+	})
+	defer handler.Close()
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	client := NewClient(NewStreamableHTTPTransport(srv.URL, nil), &ClientOptions{RequestTimeout: 2 * time.Second})
+	defer client.Close()
+	if _, err := client.Initialize(context.Background()); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	if err := client.Call(context.Background(), "echo", nil, nil); err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+	// The session's receive loop must still be running.
+	if err := client.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping() after duplicate responses: %v", err)
+	}
+}
+
+func TestMultiplexerSharesBackend(t *testing.T) {
+	backendEnd, serverEnd := NewPipe()
+	server := newFakeServer(serverEnd)
+	server.handlers["whoami"] = func(msg *Message) (interface{}, *Error) {
+		return msg.ID, nil
+	}
+	go server.serve()
+
+	mux := NewMultiplexer(backendEnd)
+	defer mux.Close()
+
+	a := NewClient(mux.Session(), &ClientOptions{RequestTimeout: 2 * time.Second})
+	b := NewClient(mux.Session(), &ClientOptions{RequestTimeout: 2 * time.Second})
+
+	for _, c := range []*Client{a, b} {
+		if _, err := c.Initialize(context.Background()); err != nil {
+			t.Fatalf("Initialize() error = %v", err)
+		}
+	}
+
+	// Both clients use ID 2 for their first call after initialize; the
+	// backend must see distinct IDs and each client its own response.
+	var idA, idB float64
+	if err := a.Call(context.Background(), "whoami", nil, &idA); err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+	if err := b.Call(context.Background(), "whoami", nil, &idB); err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+	if idA == idB {
+		t.Errorf("backend saw the same request ID %v for both sessions", idA)
+	}
+
+	inits := 0
+	initialized := 0
+	for len(server.received) > 0 {
+		switch (<-server.received).Method {
+		case MethodInitialize:
+			inits++
+		case NotificationInitialized:
+			initialized++
+		}
+	}
+	if inits != 1 || initialized != 1 {
+		t.Errorf("backend saw %d initialize and %d initialized, want 1 each", inits, initialized)
+	}
+}
+
+func TestMultiplexerPrivateSession(t *testing.T) {
+	backendEnd, serverEnd := NewPipe()
+	server := newFakeServer(serverEnd)
+	go server.serve()
+
+	mux := NewMultiplexer(backendEnd)
+	defer mux.Close()
+
+	private := NewClient(mux.PrivateSession(), &ClientOptions{RequestTimeout: 2 * time.Second})
+	public := mux.Session()
+	if _, err := private.Initialize(context.Background()); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	if err := private.Ping(context.Background()); err != nil {
+		t.Errorf("Ping() error = %v", err)
+	}
+
+	serverEnd.Send(&Message{JSONRPC: "2.0", ID: "s1", Method: "custom/ask"})
+	msg, err := public.Receive()
+	if err != nil || msg.Method != "custom/ask" {
+		t.Fatalf("public session received %+v, %v; want the server's request", msg, err)
+	}
+
+	// Had the private client been sent the request it would have answered
+	// it with an error before the public session could.
+	public.Send(&Message{JSONRPC: "2.0", ID: "s1", Result: json.RawMessage(`{}`)})
+	for {
+		select {
+		case msg := <-server.received:
+			if msg.IsResponse() {
+				if msg.Error != nil {
+					t.Errorf("server received error %+v, want the public session's answer", msg.Error)
+				}
+				return
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("server did not receive an answer")
+		}
+	}
+}
+
+func TestMultiplexerAnswersServerRequestsOnce(t *testing.T) {
+	backendEnd, serverEnd := NewPipe()
+	server := newFakeServer(serverEnd)
+	go server.serve()
+
+	mux := NewMultiplexer(backendEnd)
+	defer mux.Close()
+
+	a, b := mux.Session(), mux.Session()
+	serverEnd.Send(&Message{JSONRPC: "2.0", ID: "s1", Method: "custom/ask"})
+	for _, s := range []Transport{a, b} {
+		if msg, err := s.Receive(); err != nil || msg.Method != "custom/ask" {
+			t.Fatalf("session received %+v, %v; want the server's request", msg, err)
+		}
+		s.Send(&Message{JSONRPC: "2.0", ID: "s1", Result: json.RawMessage(`{}`)})
+	}
+	// A session answering a request the server never made.
+	a.Send(&Message{JSONRPC: "2.0", ID: "s2", Result: json.RawMessage(`{}`)})
+
+	select {
+	case msg := <-server.received:
+		if idKey(msg.ID) != idKey("s1") {
+			t.Errorf("server received answer to %v, want s1", msg.ID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("server did not receive an answer")
+	}
+	select {
+	case msg := <-server.received:
+		t.Errorf("server received a second answer %+v", msg)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	mux.mu.Lock()
+	defer mux.mu.Unlock()
+	if len(mux.unanswered) != 0 {
+		t.Errorf("multiplexer still tracks %d answered requests", len(mux.unanswered))
+	}
+}
+
+func TestMultiplexerCancelsOnlyOwnRequests(t *testing.T) {
+	backendEnd, serverEnd := NewPipe()
+	mux := NewMultiplexer(backendEnd)
+	defer mux.Close()
+
+	receive := func() *Message {
+		t.Helper()
+		msgs := make(chan *Message, 1)
+		go func() {
+			msg, _ := serverEnd.Receive()
+			msgs <- msg
+		}()
+		select {
+		case msg := <-msgs:
+			return msg
+		case <-time.After(2 * time.Second):
+			t.Fatal("server received nothing")
+			return nil
+		}
+	}
+	cancel := func(s Transport, id interface{}) {
+		params, _ := json.Marshal(CancelledParams{RequestID: id})
+		s.Send(&Message{JSONRPC: "2.0", Method: NotificationCancelled, Params: params})
+	}
+
+	a, b := mux.Session(), mux.Session()
+	a.Send(&Message{JSONRPC: "2.0", ID: 1, Method: "tools/call"})
+	idA := receive().ID
+	b.Send(&Message{JSONRPC: "2.0", ID: 1, Method: "tools/call"})
+	idB := receive().ID
+
+	// Session a has no request with b's backend ID, nor with an ID it
+	// never used; neither cancellation may reach the server.
+	cancel(a, idB)
+	cancel(a, 7)
+	// Its own request is cancelled once, however often it asks.
+	cancel(a, 1)
+	cancel(a, 1)
+	// A ping flushes anything that was wrongly sent before it.
+	a.Send(&Message{JSONRPC: "2.0", ID: 2, Method: MethodPing})
+
+	msg := receive()
+	var params CancelledParams
+	json.Unmarshal(msg.Params, &params)
+	if msg.Method != NotificationCancelled || idKey(params.RequestID) != idKey(idA) {
+		t.Fatalf("server received %s for %v, want a cancellation of %v", msg.Method, params.RequestID, idA)
+	}
+	if msg := receive(); msg.Method != MethodPing {
+		t.Errorf("server received %s %s, want only one cancellation", msg.Method, msg.Params)
+	}
+}
+
+func TestMultiplexerRoutesProgress(t *testing.T) {
+	backendEnd, serverEnd := NewPipe()
+	mux := NewMultiplexer(backendEnd)
+	defer mux.Close()
+
+	// Both sessions ask for progress with the same token.
+	params := json.RawMessage(`{"name":"slow","_meta":{"progressToken":"p"}}`)
+	a, b := mux.Session(), mux.Session()
+	tokens := make(map[Transport]interface{})
+	for _, s := range []Transport{a, b} {
+		s.Send(&Message{JSONRPC: "2.0", ID: 1, Method: "tools/call", Params: params})
+		msg, err := serverEnd.Receive()
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokens[s] = ProgressToken(msg.Params)
+		if idKey(tokens[s]) == idKey("p") {
+			t.Errorf("backend received the session's own progress token")
+		}
+	}
+	if idKey(tokens[a]) == idKey(tokens[b]) {
+		t.Fatalf("backend received progress token %v for both sessions", tokens[a])
+	}
+
+	progress := func(token interface{}) {
+		params, _ := json.Marshal(ProgressParams{ProgressToken: token, Progress: 1})
+		serverEnd.Send(&Message{JSONRPC: "2.0", Method: NotificationProgress, Params: params})
+	}
+	progress(tokens[b])
+	// Progress after the response is dropped.
+	serverEnd.Send(&Message{JSONRPC: "2.0", ID: tokens[a], Result: json.RawMessage(`{}`)})
+	progress(tokens[a])
+	serverEnd.Send(&Message{JSONRPC: "2.0", Method: "custom/marker"})
+
+	msg, err := b.Receive()
+	var p ProgressParams
+	if err == nil {
+		json.Unmarshal(msg.Params, &p)
+	}
+	if err != nil || msg.Method != NotificationProgress || idKey(p.ProgressToken) != idKey("p") {
+		t.Fatalf("session b received %+v, %v; want its progress with token p", msg, err)
+	}
+	if msg, err := a.Receive(); err != nil || !msg.IsResponse() {
+		t.Fatalf("session a received %+v, %v; want its response", msg, err)
+	}
+	if msg, err := a.Receive(); err != nil || msg.Method != "custom/marker" {
+		t.Errorf("session a received %+v, %v; want no progress", msg, err)
+	}
+}
+
+func TestHTTPHandlerExpiresIdleSessions(t *testing.T) {
+	closed := make(chan struct{})
+	handler := NewHTTPHandlerWithOptions(func(context.Context) (Transport, error) {
+		clientEnd, serverEnd := NewPipe()
+		go newFakeServer(serverEnd).serve()
+		return &closeNotifier{Transport: clientEnd, closed: closed}, nil
+	}, &HTTPHandlerOptions{IdleTimeout: 100 * time.Millisecond})
+	defer handler.Close()
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	// Plain requests, as a client that keeps no stream open and never ends
+	// its session.
+	post := func(sessionID, body string) *http.Response {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if sessionID != "" {
+			req.Header.Set(HeaderSessionID, sessionID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("POST error = %v", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+	resp := post("", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`)
+	sessionID := resp.Header.Get(HeaderSessionID)
+	if resp.StatusCode != http.StatusOK || sessionID == "" {
+		t.Fatalf("initialize status = %d, session = %q", resp.StatusCode, sessionID)
+	}
+
+	// Requests keep the session alive past the timeout.
+	for i := 0; i < 4; i++ {
+		time.Sleep(50 * time.Millisecond)
+		if resp := post(sessionID, `{"jsonrpc":"2.0","id":2,"method":"ping"}`); resp.StatusCode != http.StatusOK {
+			t.Fatalf("ping status = %d, want %d", resp.StatusCode, http.StatusOK)
+		}
+	}
+
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("idle session's backend was not closed")
+	}
+	if handler.SessionCount() != 0 {
+		t.Errorf("SessionCount() = %d after the idle timeout, want 0", handler.SessionCount())
+	}
+	if resp := post(sessionID, `{"jsonrpc":"2.0","id":3,"method":"ping"}`); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status after expiry = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+}
+
+// closeNotifier closes a channel when its transport is closed.
+type closeNotifier struct {
+	Transport
+	closed chan struct{}
+	once   sync.Once
+}
+
+func (c *closeNotifier) Close() error {
+	c.once.Do(func() { close(c.closed) })
+	return c.Transport.Close()
+}
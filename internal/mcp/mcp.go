@@ -2,10 +2,8 @@
 package mcp
 
 import (
-	"bufio"
 	"encoding/json"
 	"fmt"
-	"io"
 	"sync"
 )
 
@@ -17,6 +15,24 @@ type Message struct {
 	Params  json.RawMessage `json:"params,omitempty"`
 	Result  json.RawMessage `json:"result,omitempty"`
 	Error   *Error          `json:"error,omitempty"`
+
+	// batch links messages that were received together in one batch.
+	batch *batchGroup
+}
+
+// IsRequest reports whether the message is a request that expects a response.
+func (m *Message) IsRequest() bool {
+	return m.Method != "" && m.ID != nil
+}
+
+// IsNotification reports whether the message is a notification.
+func (m *Message) IsNotification() bool {
+	return m.Method != "" && m.ID == nil
+}
+
+// IsResponse reports whether the message is a response to a request.
+func (m *Message) IsResponse() bool {
+	return m.Method == "" && m.ID != nil
 }
 
 // Error represents a JSON-RPC error.
@@ -26,6 +42,11 @@ type Error struct {
 	Data    interface{} `json:"data,omitempty"`
 }
 
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return fmt.Sprintf("jsonrpc error %d: %s", e.Code, e.Message)
+}
+
 // Standard JSON-RPC error codes.
 const (
 	ErrParse          = -32700
@@ -42,109 +63,202 @@ type Transport interface {
 	Close() error
 }
 
-// StdioTransport implements MCP transport over stdio.
-type StdioTransport struct {
-	reader *bufio.Reader
-	writer io.Writer
-	mu     sync.Mutex
+// Proxy proxies messages between two transports, passing each message
+// through its middleware chain.
+//
+// Batches from the client are forwarded intact when the server transport
+// can send batches and the negotiated protocol revision allows them.
+// Otherwise their messages are forwarded one by one. Either way the
+// responses go back to the client as one batch.
+//
+// The proxy tracks the requests it has forwarded to the server. If the
+// client goes away while some are unanswered, the server is sent
+// notifications/cancelled for each of them.
+type Proxy struct {
+	client     Transport
+	server     Transport
+	middleware []Middleware
+
+	mu       sync.Mutex
+	initID   string
+	batching bool
+	inflight map[string]interface{}
 }
 
-// NewStdioTransport creates a new stdio transport.
-func NewStdioTransport(r io.Reader, w io.Writer) *StdioTransport {
-	return &StdioTransport{
-		reader: bufio.NewReader(r),
-		writer: w,
+// NewProxy creates a new message proxy.
+func NewProxy(client, server Transport) *Proxy {
+	return &Proxy{
+		client:   newBatchResponder(client),
+		server:   server,
+		inflight: make(map[string]interface{}),
 	}
 }
 
-// Send sends a message over the transport.
-func (t *StdioTransport) Send(msg *Message) error {
-	t.mu.Lock()
-	defer t.mu.Unlock()
+// Use appends middleware to the chain. Client messages pass through the
+// chain in order and server messages in reverse order. It must be called
+// before Run.
+func (p *Proxy) Use(mw ...Middleware) {
+	p.middleware = append(p.middleware, mw...)
+}
 
-	data, err := json.Marshal(msg)
-	if err != nil {
-		return fmt.Errorf("failed to marshal message: %w", err)
-	}
+// Run starts proxying messages bidirectionally.
+func (p *Proxy) Run() error {
+	errChan := make(chan error, 2)
 
-	// Write message followed by newline
-	if _, err := t.writer.Write(data); err != nil {
-		return fmt.Errorf("failed to write message: %w", err)
-	}
-	if _, err := t.writer.Write([]byte("\n")); err != nil {
-		return fmt.Errorf("failed to write newline: %w", err)
-	}
+	go p.pump(ClientToServer, errChan)
+	go p.pump(ServerToClient, errChan)
 
-	return nil
+	return <-errChan
 }
 
-// Receive receives a message from the transport.
-func (t *StdioTransport) Receive() (*Message, error) {
-	line, err := t.reader.ReadBytes('\n')
-	if err != nil {
-		return nil, err
+// pump forwards messages in one direction until either side fails.
+func (p *Proxy) pump(dir Direction, errChan chan<- error) {
+	from, to := p.client, p.server
+	if dir == ServerToClient {
+		from, to = p.server, p.client
 	}
+	x := &Exchange{Direction: dir, from: from, to: to, proxy: p}
 
-	var msg Message
-	if err := json.Unmarshal(line, &msg); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal message: %w", err)
-	}
+	// batch collects the messages of a client batch forwarded intact.
+	var batch []*Message
+
+	for {
+		msg, err := from.Receive()
+		if IsRecoverable(err) {
+			// The client is owed an error; the server's bad output is
+			// dropped.
+			if resp := errorResponse(err); resp != nil && dir == ClientToServer {
+				from.Send(resp)
+			}
+			continue
+		}
+		if err != nil {
+			if dir == ClientToServer {
+				p.cancelInflight("client disconnected")
+			}
+			errChan <- err
+			return
+		}
+		p.observe(dir, msg)
+
+		orig := msg
+		msg, err = p.intercept(x, msg)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		if msg == nil && dir == ClientToServer && orig.batch != nil && orig.IsRequest() {
+			// Middleware dropped the request, unless it replied; the rest
+			// of its batch must not wait for an answer that never comes.
+			if r, ok := p.client.(*batchResponder); ok {
+				if err := r.abandon(orig.ID); err != nil {
+					errChan <- err
+					return
+				}
+			}
+		}
 
-	return &msg, nil
+		if orig.batch != nil && dir == ClientToServer && p.passBatches() {
+			if msg != nil {
+				batch = append(batch, msg)
+			}
+			if !orig.batch.last(orig) || len(batch) == 0 {
+				continue
+			}
+			err = p.server.(BatchTransport).SendBatch(batch)
+			for _, m := range batch {
+				p.track(m)
+			}
+			batch = nil
+		} else if msg != nil {
+			if err = to.Send(msg); err == nil && dir == ClientToServer {
+				p.track(msg)
+			}
+		}
+		if err != nil {
+			errChan <- err
+			return
+		}
+	}
 }
 
-// Close closes the transport.
-func (t *StdioTransport) Close() error {
-	return nil
+// observe watches the initialize exchange for the negotiated protocol
+// revision, which decides whether batches can be passed through, and
+// retires in-flight requests that were answered or cancelled.
+func (p *Proxy) observe(dir Direction, msg *Message) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch {
+	case dir == ServerToClient && msg.IsResponse():
+		delete(p.inflight, idKey(msg.ID))
+	case dir == ClientToServer && msg.Method == NotificationCancelled:
+		var params CancelledParams
+		if err := json.Unmarshal(msg.Params, &params); err == nil {
+			delete(p.inflight, idKey(params.RequestID))
+		}
+	}
+
+	switch {
+	case dir == ClientToServer && msg.Method == MethodInitialize && msg.ID != nil:
+		p.initID = idKey(msg.ID)
+	case dir == ServerToClient && msg.IsResponse() && p.initID != "" && idKey(msg.ID) == p.initID:
+		var result InitializeResult
+		if err := json.Unmarshal(msg.Result, &result); err == nil {
+			p.batching = batchesAllowed(result.ProtocolVersion)
+		}
+		p.initID = ""
+	}
 }
 
-// Proxy proxies messages between two transports.
-type Proxy struct {
-	client Transport
-	server Transport
+// track records a request forwarded to the server.
+func (p *Proxy) track(msg *Message) {
+	if !msg.IsRequest() {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.inflight[idKey(msg.ID)] = msg.ID
 }
 
-// NewProxy creates a new message proxy.
-func NewProxy(client, server Transport) *Proxy {
-	return &Proxy{
-		client: client,
-		server: server,
+// cancelInflight tells the server to abandon every unanswered request.
+func (p *Proxy) cancelInflight(reason string) {
+	p.mu.Lock()
+	ids := make([]interface{}, 0, len(p.inflight))
+	for key, id := range p.inflight {
+		ids = append(ids, id)
+		delete(p.inflight, key)
+	}
+	p.mu.Unlock()
+
+	for _, id := range ids {
+		params, _ := json.Marshal(CancelledParams{RequestID: id, Reason: reason})
+		_ = p.server.Send(&Message{JSONRPC: "2.0", Method: NotificationCancelled, Params: params})
 	}
 }
 
-// Run starts proxying messages bidirectionally.
-func (p *Proxy) Run() error {
-	errChan := make(chan error, 2)
+// passBatches reports whether client batches are forwarded intact.
+func (p *Proxy) passBatches() bool {
+	if _, ok := p.server.(BatchTransport); !ok {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.batching
+}
 
-	// Client -> Server
-	go func() {
-		for {
-			msg, err := p.client.Receive()
-			if err != nil {
-				errChan <- err
-				return
-			}
-			if err := p.server.Send(msg); err != nil {
-				errChan <- err
-				return
-			}
-		}
-	}()
-
-	// Server -> Client
-	go func() {
-		for {
-			msg, err := p.server.Receive()
-			if err != nil {
-				errChan <- err
-				return
-			}
-			if err := p.client.Send(msg); err != nil {
-				errChan <- err
-				return
-			}
+func (p *Proxy) intercept(x *Exchange, msg *Message) (*Message, error) {
+	n := len(p.middleware)
+	for i := 0; i < n && msg != nil; i++ {
+		mw := p.middleware[i]
+		if x.Direction == ServerToClient {
+			mw = p.middleware[n-1-i]
 		}
-	}()
 
-	return <-errChan
+		var err error
+		if msg, err = mw.Intercept(x, msg); err != nil {
+			return nil, fmt.Errorf("middleware: %w", err)
+		}
+	}
+	return msg, nil
 }
@@ -0,0 +1,76 @@
+package mcp
+
+// Direction identifies which way a message travels through a Proxy.
+type Direction int
+
+const (
+	// ClientToServer is the direction of messages sent by the client.
+	ClientToServer Direction = iota
+	// ServerToClient is the direction of messages sent by the server.
+	ServerToClient
+)
+
+func (d Direction) String() string {
+	if d == ServerToClient {
+		return "server->client"
+	}
+	return "client->server"
+}
+
+// reverse returns the opposite direction.
+func (d Direction) reverse() Direction {
+	if d == ServerToClient {
+		return ClientToServer
+	}
+	return ServerToClient
+}
+
+// Middleware intercepts messages passing through a Proxy. Intercept returns
+// the message to forward, which may be modified or replaced, or nil to drop
+// it. A middleware can answer a request itself with Exchange.Reply and drop
+// the original. Returning an error stops the proxy.
+//
+// Intercept is called concurrently for the two directions, so middleware
+// that keeps state across messages must synchronize it.
+type Middleware interface {
+	Intercept(x *Exchange, msg *Message) (*Message, error)
+}
+
+// MiddlewareFunc adapts an ordinary function to the Middleware interface.
+type MiddlewareFunc func(x *Exchange, msg *Message) (*Message, error)
+
+// Intercept calls f(x, msg).
+func (f MiddlewareFunc) Intercept(x *Exchange, msg *Message) (*Message, error) {
+	return f(x, msg)
+}
+
+// Exchange describes the path a message is taking through a Proxy.
+type Exchange struct {
+	// Direction is the way the message travels.
+	Direction Direction
+
+	from Transport
+	to   Transport
+
+	// proxy, if set, is told of the messages middleware sends itself, such
+	// as answers to requests it tracks as in flight.
+	proxy *Proxy
+}
+
+// Reply sends a message back to the side the current message came from,
+// bypassing the rest of the chain.
+func (x *Exchange) Reply(msg *Message) error {
+	if x.proxy != nil {
+		x.proxy.observe(x.Direction.reverse(), msg)
+	}
+	return x.from.Send(msg)
+}
+
+// Inject sends an additional message onward to the side the current message
+// is headed for, bypassing the rest of the chain.
+func (x *Exchange) Inject(msg *Message) error {
+	if x.proxy != nil {
+		x.proxy.observe(x.Direction, msg)
+	}
+	return x.to.Send(msg)
+}
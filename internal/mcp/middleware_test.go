@@ -0,0 +1,108 @@
+package mcp
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+)
+
+// startProxy runs a proxy between two in-memory pipes and returns the
+// client-facing and server-facing ends.
+func startProxy(t *testing.T, mw ...Middleware) (client, server Transport) {
+	t.Helper()
+	client, proxyClient := NewPipe()
+	proxyServer, server := NewPipe()
+
+	proxy := NewProxy(proxyClient, proxyServer)
+	proxy.Use(mw...)
+	go proxy.Run()
+
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	return client, server
+}
+
+func TestProxyMiddlewareOrder(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	record := func(name string) Middleware {
+		return MiddlewareFunc(func(x *Exchange, msg *Message) (*Message, error) {
+			mu.Lock()
+			order = append(order, name+" "+x.Direction.String())
+			mu.Unlock()
+			return msg, nil
+		})
+	}
+
+	client, server := startProxy(t, record("a"), record("b"))
+
+	if err := client.Send(&Message{JSONRPC: "2.0", ID: 1, Method: MethodPing}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if _, err := server.Receive(); err != nil {
+		t.Fatalf("server Receive() error = %v", err)
+	}
+	if err := server.Send(&Message{JSONRPC: "2.0", ID: 1, Result: json.RawMessage(`{}`)}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if _, err := client.Receive(); err != nil {
+		t.Fatalf("client Receive() error = %v", err)
+	}
+
+	want := []string{"a client->server", "b client->server", "b server->client", "a server->client"}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(order) != len(want) {
+		t.Fatalf("order = %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Errorf("order[%d] = %q, want %q", i, order[i], want[i])
+		}
+	}
+}
+
+func TestProxyMiddlewareModifyDropReply(t *testing.T) {
+	mw := MiddlewareFunc(func(x *Exchange, msg *Message) (*Message, error) {
+		if x.Direction != ClientToServer {
+			return msg, nil
+		}
+		switch msg.Method {
+		case "drop":
+			return nil, nil
+		case "answer":
+			return nil, x.Reply(&Message{JSONRPC: "2.0", ID: msg.ID, Result: json.RawMessage(`"answered"`)})
+		case "rename":
+			renamed := *msg
+			renamed.Method = "renamed"
+			return &renamed, nil
+		}
+		return msg, nil
+	})
+
+	client, server := startProxy(t, mw)
+
+	client.Send(&Message{JSONRPC: "2.0", Method: "drop"})
+	client.Send(&Message{JSONRPC: "2.0", ID: 1, Method: "answer"})
+	client.Send(&Message{JSONRPC: "2.0", ID: 2, Method: "rename"})
+
+	reply, err := client.Receive()
+	if err != nil {
+		t.Fatalf("client Receive() error = %v", err)
+	}
+	if string(reply.Result) != `"answered"` {
+		t.Errorf("reply result = %s, want %q", reply.Result, "answered")
+	}
+
+	got, err := server.Receive()
+	if err != nil {
+		t.Fatalf("server Receive() error = %v", err)
+	}
+	if got.Method != "renamed" {
+		t.Errorf("server received %q, want only the renamed message", got.Method)
+	}
+}
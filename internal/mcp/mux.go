@@ -0,0 +1,374 @@
+package mcp
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// Multiplexer shares a single backend transport between several client
+// sessions. Request IDs and progress tokens are rewritten so each response
+// and progress report reaches the session that sent the request. The backend is initialized once; later sessions
+// receive the cached initialize result.
+type Multiplexer struct {
+	backend Transport
+
+	mu       sync.Mutex
+	nextID   int64
+	routes   map[string]muxRoute
+	progress map[string]muxRoute
+	sessions map[*muxSession]struct{}
+
+	initResult   *Message
+	initWaiters  []muxRoute
+	initializing bool
+	initialized  bool
+
+	// unanswered are the server-initiated requests no session has answered.
+	unanswered map[string]bool
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// muxInitID is the backend request ID of the shared initialize request.
+const muxInitID = "mcp-adapter-init"
+
+// muxRoute maps a rewritten request or progress token back to its
+// originating session.
+type muxRoute struct {
+	session *muxSession
+	id      interface{}
+}
+
+// NewMultiplexer creates a multiplexer over the backend transport and starts
+// reading from it.
+func NewMultiplexer(backend Transport) *Multiplexer {
+	m := &Multiplexer{
+		backend:    backend,
+		routes:     make(map[string]muxRoute),
+		progress:   make(map[string]muxRoute),
+		sessions:   make(map[*muxSession]struct{}),
+		unanswered: make(map[string]bool),
+		done:       make(chan struct{}),
+	}
+	go m.receiveLoop()
+	return m
+}
+
+// Session returns a new transport view onto the shared backend. Closing the
+// session detaches it without closing the backend.
+func (m *Multiplexer) Session() Transport {
+	s := &muxSession{
+		mux:      m,
+		incoming: make(chan *Message, 64),
+		done:     make(chan struct{}),
+	}
+
+	m.mu.Lock()
+	m.sessions[s] = struct{}{}
+	m.mu.Unlock()
+
+	return s
+}
+
+// PrivateSession returns a session that receives only the responses to its
+// own requests, not the server's notifications or requests. It suits
+// internal clients, such as health checks, that must not answer the
+// server's requests in place of real clients.
+func (m *Multiplexer) PrivateSession() Transport {
+	s := m.Session().(*muxSession)
+	s.private = true
+	return s
+}
+
+// Done returns a channel that is closed when the backend ends.
+func (m *Multiplexer) Done() <-chan struct{} {
+	return m.done
+}
+
+// Close closes the backend and all sessions.
+func (m *Multiplexer) Close() error {
+	err := m.backend.Close()
+	m.shutdown()
+	return err
+}
+
+func (m *Multiplexer) shutdown() {
+	m.closeOnce.Do(func() {
+		close(m.done)
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		for s := range m.sessions {
+			s.detach()
+		}
+		m.sessions = make(map[*muxSession]struct{})
+	})
+}
+
+func (m *Multiplexer) send(s *muxSession, msg *Message) error {
+	m.mu.Lock()
+
+	switch {
+	case msg.Method == MethodInitialize:
+		route := muxRoute{session: s, id: msg.ID}
+		if m.initResult != nil {
+			reply := *m.initResult
+			m.mu.Unlock()
+			reply.ID = msg.ID
+			s.deliver(&reply)
+			return nil
+		}
+		m.initWaiters = append(m.initWaiters, route)
+		if m.initializing {
+			m.mu.Unlock()
+			return nil
+		}
+		m.initializing = true
+		m.mu.Unlock()
+
+		rewritten := *msg
+		rewritten.ID = muxInitID
+		return m.backend.Send(&rewritten)
+
+	case msg.Method == NotificationInitialized:
+		if m.initialized {
+			m.mu.Unlock()
+			return nil
+		}
+		m.initialized = true
+		m.mu.Unlock()
+		return m.backend.Send(msg)
+
+	case msg.Method == NotificationCancelled:
+		// The client's request ID may be another session's backend ID, so
+		// a cancellation that matches none of this session's requests is
+		// dropped rather than passed on.
+		var params CancelledParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			m.mu.Unlock()
+			return nil
+		}
+		var backendID interface{}
+		for key, route := range m.routes {
+			if route.session == s && idKey(route.id) == idKey(params.RequestID) {
+				json.Unmarshal([]byte(key), &backendID)
+				delete(m.routes, key)
+				delete(m.progress, key)
+				break
+			}
+		}
+		m.mu.Unlock()
+		if backendID == nil {
+			return nil
+		}
+
+		params.RequestID = backendID
+		rewritten := *msg
+		rewritten.Params, _ = json.Marshal(params)
+		return m.backend.Send(&rewritten)
+
+	case msg.IsRequest():
+		m.nextID++
+		backendID := m.nextID
+		key := idKey(backendID)
+		m.routes[key] = muxRoute{session: s, id: msg.ID}
+
+		rewritten := *msg
+		rewritten.ID = backendID
+		// Progress tokens are only unique within a session, so the backend
+		// gets the request's backend ID as its token.
+		if token := ProgressToken(msg.Params); token != nil {
+			if params, err := setProgressToken(msg.Params, backendID); err == nil {
+				rewritten.Params = params
+				m.progress[key] = muxRoute{session: s, id: token}
+			}
+		}
+		m.mu.Unlock()
+		return m.backend.Send(&rewritten)
+
+	case msg.IsResponse():
+		// Server-initiated requests are broadcast; only the first answer counts.
+		key := idKey(msg.ID)
+		if !m.unanswered[key] {
+			m.mu.Unlock()
+			return nil
+		}
+		delete(m.unanswered, key)
+		m.mu.Unlock()
+		return m.backend.Send(msg)
+
+	default:
+		m.mu.Unlock()
+		return m.backend.Send(msg)
+	}
+}
+
+func (m *Multiplexer) receiveLoop() {
+	defer m.shutdown()
+
+	for {
+		msg, err := m.backend.Receive()
+		if IsRecoverable(err) {
+			continue
+		}
+		if err != nil {
+			return
+		}
+
+		if msg.Method == NotificationProgress {
+			m.routeProgress(msg)
+			continue
+		}
+
+		if !msg.IsResponse() {
+			m.mu.Lock()
+			if msg.IsRequest() {
+				m.unanswered[idKey(msg.ID)] = true
+			} else if msg.Method == NotificationCancelled {
+				// The server no longer wants an answer.
+				var params CancelledParams
+				if json.Unmarshal(msg.Params, &params) == nil {
+					delete(m.unanswered, idKey(params.RequestID))
+				}
+			}
+			m.mu.Unlock()
+			m.broadcast(msg)
+			continue
+		}
+
+		m.mu.Lock()
+		key := idKey(msg.ID)
+		route, ok := m.routes[key]
+		delete(m.routes, key)
+		delete(m.progress, key)
+
+		if key == idKey(muxInitID) {
+			waiters := m.initWaiters
+			m.initWaiters = nil
+			m.initializing = false
+			if msg.Error == nil {
+				cached := *msg
+				m.initResult = &cached
+			}
+			m.mu.Unlock()
+
+			for _, w := range waiters {
+				reply := *msg
+				reply.ID = w.id
+				w.session.deliver(&reply)
+			}
+			continue
+		}
+		m.mu.Unlock()
+
+		if ok {
+			reply := *msg
+			reply.ID = route.id
+			route.session.deliver(&reply)
+		}
+	}
+}
+
+// routeProgress passes a progress report to the session that asked for it
+// with the session's own token. Reports for requests that have completed,
+// or were never made, are dropped.
+func (m *Multiplexer) routeProgress(msg *Message) {
+	var params ProgressParams
+	if json.Unmarshal(msg.Params, &params) != nil {
+		return
+	}
+	m.mu.Lock()
+	route, ok := m.progress[idKey(params.ProgressToken)]
+	m.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	rewritten, err := replaceProgressToken(msg.Params, route.id)
+	if err != nil {
+		return
+	}
+	report := *msg
+	report.Params = rewritten
+	route.session.deliver(&report)
+}
+
+func (m *Multiplexer) broadcast(msg *Message) {
+	m.mu.Lock()
+	sessions := make([]*muxSession, 0, len(m.sessions))
+	for s := range m.sessions {
+		if !s.private {
+			sessions = append(sessions, s)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, s := range sessions {
+		copied := *msg
+		s.deliver(&copied)
+	}
+}
+
+func (m *Multiplexer) detach(s *muxSession) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, s)
+	for key, route := range m.routes {
+		if route.session == s {
+			delete(m.routes, key)
+		}
+	}
+	for key, route := range m.progress {
+		if route.session == s {
+			delete(m.progress, key)
+		}
+	}
+}
+
+// muxSession is one client's view onto a Multiplexer.
+type muxSession struct {
+	mux       *Multiplexer
+	private   bool
+	incoming  chan *Message
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Send sends a message to the shared backend.
+func (s *muxSession) Send(msg *Message) error {
+	select {
+	case <-s.done:
+		return io.ErrClosedPipe
+	default:
+	}
+	return s.mux.send(s, msg)
+}
+
+// Receive receives the next message addressed to this session.
+func (s *muxSession) Receive() (*Message, error) {
+	select {
+	case msg := <-s.incoming:
+		return msg, nil
+	case <-s.done:
+		return nil, io.EOF
+	}
+}
+
+// Close detaches the session from the multiplexer.
+func (s *muxSession) Close() error {
+	s.mux.detach(s)
+	s.detach()
+	return nil
+}
+
+func (s *muxSession) deliver(msg *Message) {
+	select {
+	case s.incoming <- msg:
+	case <-s.done:
+	}
+}
+
+func (s *muxSession) detach() {
+	s.closeOnce.Do(func() { close(s.done) })
+}
@@ -0,0 +1,59 @@
+package mcp
+
+import (
+	"io"
+	"sync"
+)
+
+// pipeTransport is one end of an in-memory transport pair.
+type pipeTransport struct {
+	in     <-chan *Message
+	out    chan<- *Message
+	closed chan struct{}
+	once   *sync.Once
+}
+
+// NewPipe returns two connected in-memory transports. Messages sent on one
+// are received on the other. Closing either end closes both.
+func NewPipe() (Transport, Transport) {
+	a := make(chan *Message, 16)
+	b := make(chan *Message, 16)
+	closed := make(chan struct{})
+	once := &sync.Once{}
+
+	return &pipeTransport{in: a, out: b, closed: closed, once: once},
+		&pipeTransport{in: b, out: a, closed: closed, once: once}
+}
+
+// Send sends a message to the other end of the pipe.
+func (t *pipeTransport) Send(msg *Message) error {
+	copied := *msg
+	select {
+	case <-t.closed:
+		return io.ErrClosedPipe
+	default:
+	}
+
+	select {
+	case t.out <- &copied:
+		return nil
+	case <-t.closed:
+		return io.ErrClosedPipe
+	}
+}
+
+// Receive receives a message from the other end of the pipe.
+func (t *pipeTransport) Receive() (*Message, error) {
+	select {
+	case msg := <-t.in:
+		return msg, nil
+	case <-t.closed:
+		return nil, io.EOF
+	}
+}
+
+// Close closes both ends of the pipe.
+func (t *pipeTransport) Close() error {
+	t.once.Do(func() { close(t.closed) })
+	return nil
+}
@@ -0,0 +1,80 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// ProgressFunc receives the progress a server reports for a request. It runs
+// on the client's receive loop and must not block.
+type ProgressFunc func(p ProgressParams)
+
+type progressKey struct{}
+
+// WithProgress returns a context that asks for progress reports on the
+// requests made with it. The Client gives each such request a progress
+// token and passes the server's notifications/progress for it to fn.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+func progressFromContext(ctx context.Context) ProgressFunc {
+	fn, _ := ctx.Value(progressKey{}).(ProgressFunc)
+	return fn
+}
+
+// ProgressToken returns the _meta.progressToken of a request's params, or
+// nil if it asks for no progress.
+func ProgressToken(params json.RawMessage) interface{} {
+	var p struct {
+		Meta struct {
+			ProgressToken interface{} `json:"progressToken"`
+		} `json:"_meta"`
+	}
+	if json.Unmarshal(params, &p) != nil {
+		return nil
+	}
+	return p.Meta.ProgressToken
+}
+
+// replaceProgressToken changes the token of a notifications/progress,
+// keeping its other params.
+func replaceProgressToken(params json.RawMessage, token interface{}) (json.RawMessage, error) {
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(params, &fields); err != nil {
+		return nil, err
+	}
+	var err error
+	if fields["progressToken"], err = json.Marshal(token); err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
+
+// setProgressToken adds _meta.progressToken to a request's params, keeping
+// any other _meta fields.
+func setProgressToken(params json.RawMessage, token interface{}) (json.RawMessage, error) {
+	fields := make(map[string]json.RawMessage)
+	if len(params) > 0 && string(params) != "null" {
+		if err := json.Unmarshal(params, &fields); err != nil {
+			return nil, fmt.Errorf("progress token needs object params: %w", err)
+		}
+	}
+
+	meta := make(map[string]json.RawMessage)
+	if raw, ok := fields["_meta"]; ok {
+		if err := json.Unmarshal(raw, &meta); err != nil {
+			return nil, fmt.Errorf("invalid _meta: %w", err)
+		}
+	}
+
+	var err error
+	if meta["progressToken"], err = json.Marshal(token); err != nil {
+		return nil, err
+	}
+	if fields["_meta"], err = json.Marshal(meta); err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
@@ -0,0 +1,99 @@
+package mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// MarshalText encodes the direction as in String.
+func (d Direction) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText decodes a direction encoded by MarshalText.
+func (d *Direction) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case ClientToServer.String():
+		*d = ClientToServer
+	case ServerToClient.String():
+		*d = ServerToClient
+	default:
+		return fmt.Errorf("unknown direction %q", text)
+	}
+	return nil
+}
+
+// RecordedMessage is one line of a session recording.
+type RecordedMessage struct {
+	Time      time.Time `json:"time"`
+	Direction Direction `json:"direction"`
+	Message   *Message  `json:"message"`
+}
+
+// Recorder is middleware that writes every message passing through a proxy
+// to a JSONL recording. Place it first in the chain to record exactly what
+// the client sent and received.
+type Recorder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+// NewRecorder creates a recorder writing to w.
+func NewRecorder(w io.Writer) *Recorder {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &Recorder{enc: enc}
+}
+
+// Intercept records the message and passes it on unchanged.
+func (r *Recorder) Intercept(x *Exchange, msg *Message) (*Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err == nil {
+		r.err = r.enc.Encode(RecordedMessage{
+			Time:      time.Now().UTC(),
+			Direction: x.Direction,
+			Message:   msg,
+		})
+	}
+	return msg, nil
+}
+
+// Err returns the first error encountered while writing the recording.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// ReadRecording reads a JSONL recording written by a Recorder.
+func ReadRecording(r io.Reader) ([]RecordedMessage, error) {
+	var messages []RecordedMessage
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var rec RecordedMessage
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if rec.Message == nil {
+			return nil, fmt.Errorf("line %d: missing message", line)
+		}
+		messages = append(messages, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
@@ -0,0 +1,109 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newEchoServer serves a single "echo" tool whose output carries a prefix,
+// so that a replay against a different prefix produces a diff.
+func newEchoServer(t *testing.T, version, prefix string) Transport {
+	t.Helper()
+	clientEnd, serverEnd := NewPipe()
+
+	srv := NewServer(serverEnd, &ServerOptions{
+		Info:         Implementation{Name: "echo", Version: version},
+		Capabilities: ServerCapabilities{Tools: &ToolsCapability{}},
+	})
+	srv.HandleRequest(MethodToolsCall, func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		var p CallToolParams
+		json.Unmarshal(params, &p)
+		return CallToolResult{Content: []Content{{Type: "text", Text: prefix + p.Arguments["text"].(string)}}}, nil
+	})
+	go srv.Serve()
+
+	t.Cleanup(func() { clientEnd.Close() })
+	return clientEnd
+}
+
+func recordSession(t *testing.T) []RecordedMessage {
+	t.Helper()
+	var buf bytes.Buffer
+	recorder := NewRecorder(&buf)
+
+	clientEnd, proxyEnd := NewPipe()
+	proxy := NewProxy(proxyEnd, newEchoServer(t, "1.0.0", "v1:"))
+	proxy.Use(recorder)
+	go proxy.Run()
+
+	client := NewClient(clientEnd, &ClientOptions{RequestTimeout: 2 * time.Second})
+	if _, err := client.Initialize(context.Background()); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	if _, err := client.CallTool(context.Background(), "echo", map[string]interface{}{"text": "hi"}); err != nil {
+		t.Fatalf("CallTool() error = %v", err)
+	}
+	client.Close()
+
+	if err := recorder.Err(); err != nil {
+		t.Fatalf("recorder error = %v", err)
+	}
+
+	recording, err := ReadRecording(&buf)
+	if err != nil {
+		t.Fatalf("ReadRecording() error = %v", err)
+	}
+	return recording
+}
+
+func TestRecorder(t *testing.T) {
+	recording := recordSession(t)
+
+	// initialize, its response, initialized, tools/call and its response.
+	if len(recording) != 5 {
+		t.Fatalf("recorded %d messages, want 5", len(recording))
+	}
+	if recording[0].Direction != ClientToServer || recording[0].Message.Method != MethodInitialize {
+		t.Errorf("first message = %s %s, want client initialize", recording[0].Direction, recording[0].Message.Method)
+	}
+	if recording[1].Direction != ServerToClient || !recording[1].Message.IsResponse() {
+		t.Errorf("second message = %s %+v, want server response", recording[1].Direction, recording[1].Message)
+	}
+	if recording[0].Time.IsZero() {
+		t.Error("recorded message has no timestamp")
+	}
+}
+
+func TestReplay(t *testing.T) {
+	recording := recordSession(t)
+
+	results, err := Replay(context.Background(), newEchoServer(t, "1.0.0", "v1:"), recording, nil)
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Replay() returned %d results, want 2", len(results))
+	}
+	for _, r := range results {
+		if len(r.Diffs) > 0 {
+			t.Errorf("%s: unexpected diffs %v", r.Method, r.Diffs)
+		}
+	}
+
+	results, err = Replay(context.Background(), newEchoServer(t, "2.0.0", "v2:"), recording, &ReplayOptions{
+		Ignore: []string{"result.serverInfo.version"},
+	})
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if len(results[0].Diffs) != 0 {
+		t.Errorf("initialize diffs = %v, want version difference ignored", results[0].Diffs)
+	}
+	if len(results[1].Diffs) != 1 || !strings.HasPrefix(results[1].Diffs[0], "result.content[0].text:") {
+		t.Errorf("tools/call diffs = %v, want a text difference", results[1].Diffs)
+	}
+}
@@ -0,0 +1,325 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ReplayOptions configures Replay.
+type ReplayOptions struct {
+	// Timeout bounds the wait for each response. Zero means DefaultRequestTimeout.
+	Timeout time.Duration
+
+	// Ignore lists paths, such as "result.serverInfo.version", whose
+	// differences are not reported. A path also covers everything below it.
+	Ignore []string
+}
+
+// ReplayResult compares one recorded response with the live one.
+type ReplayResult struct {
+	Method   string
+	ID       interface{}
+	Expected *Message
+	Actual   *Message
+
+	// Diffs describes each difference; it is empty when the responses match.
+	Diffs []string
+}
+
+// Replay sends the client side of a recording to a server in order, waiting
+// for the response to each request, and compares the responses with the
+// recorded ones. Server-initiated requests are answered with the client's
+// recorded answer to the same method, if there is one.
+func Replay(ctx context.Context, t Transport, recording []RecordedMessage, opts *ReplayOptions) ([]ReplayResult, error) {
+	var o ReplayOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Timeout == 0 {
+		o.Timeout = DefaultRequestTimeout
+	}
+
+	expected := make(map[string]*Message)
+	answers := recordedAnswers(recording)
+	for _, rec := range recording {
+		if rec.Direction == ServerToClient && rec.Message.IsResponse() {
+			expected[idKey(rec.Message.ID)] = rec.Message
+		}
+	}
+
+	r := &replayer{
+		transport: t,
+		answers:   answers,
+		pending:   make(map[string]chan *Message),
+		done:      make(chan struct{}),
+	}
+	go r.receiveLoop()
+
+	var results []ReplayResult
+	for _, rec := range recording {
+		msg := rec.Message
+		if rec.Direction != ClientToServer || msg.IsResponse() {
+			continue
+		}
+
+		if msg.IsNotification() {
+			if err := t.Send(msg); err != nil {
+				return results, fmt.Errorf("failed to send %s: %w", msg.Method, err)
+			}
+			continue
+		}
+
+		actual, err := r.roundTrip(ctx, msg, o.Timeout)
+		if err != nil && ctx.Err() != nil {
+			return results, err
+		}
+
+		result := ReplayResult{
+			Method:   msg.Method,
+			ID:       msg.ID,
+			Expected: expected[idKey(msg.ID)],
+			Actual:   actual,
+		}
+		switch {
+		case err != nil:
+			result.Diffs = []string{err.Error()}
+		case result.Expected == nil:
+			result.Diffs = []string{"no response was recorded"}
+		default:
+			result.Diffs = diffResponses(result.Expected, actual, o.Ignore)
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// recordedAnswers collects the client's answers to server-initiated
+// requests, queued by the method they answered.
+func recordedAnswers(recording []RecordedMessage) map[string][]*Message {
+	methods := make(map[string]string)
+	answers := make(map[string][]*Message)
+	for _, rec := range recording {
+		msg := rec.Message
+		switch {
+		case rec.Direction == ServerToClient && msg.IsRequest():
+			methods[idKey(msg.ID)] = msg.Method
+		case rec.Direction == ClientToServer && msg.IsResponse():
+			if method, ok := methods[idKey(msg.ID)]; ok {
+				answers[method] = append(answers[method], msg)
+			}
+		}
+	}
+	return answers
+}
+
+type replayer struct {
+	transport Transport
+
+	mu      sync.Mutex
+	answers map[string][]*Message
+	pending map[string]chan *Message
+	err     error
+	done    chan struct{}
+}
+
+func (r *replayer) roundTrip(ctx context.Context, msg *Message, timeout time.Duration) (*Message, error) {
+	key := idKey(msg.ID)
+	ch := make(chan *Message, 1)
+
+	r.mu.Lock()
+	r.pending[key] = ch
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		delete(r.pending, key)
+		r.mu.Unlock()
+	}()
+
+	if err := r.transport.Send(msg); err != nil {
+		return nil, fmt.Errorf("failed to send %s: %w", msg.Method, err)
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case resp := <-ch:
+		return resp, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("no response within %s", timeout)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-r.done:
+		return nil, fmt.Errorf("server closed the connection: %v", r.err)
+	}
+}
+
+func (r *replayer) receiveLoop() {
+	for {
+		msg, err := r.transport.Receive()
+		if IsRecoverable(err) {
+			continue
+		}
+		if err != nil {
+			r.err = err
+			close(r.done)
+			return
+		}
+
+		switch {
+		case msg.IsResponse():
+			r.mu.Lock()
+			ch, ok := r.pending[idKey(msg.ID)]
+			r.mu.Unlock()
+			if ok {
+				ch <- msg
+			}
+		case msg.IsRequest():
+			r.answer(msg)
+		}
+	}
+}
+
+// answer replies to a server-initiated request from the recording.
+func (r *replayer) answer(req *Message) {
+	r.mu.Lock()
+	queue := r.answers[req.Method]
+	var recorded *Message
+	if len(queue) > 0 {
+		recorded, r.answers[req.Method] = queue[0], queue[1:]
+	}
+	r.mu.Unlock()
+
+	reply := &Message{JSONRPC: "2.0", ID: req.ID}
+	switch {
+	case recorded != nil:
+		reply.Result = recorded.Result
+		reply.Error = recorded.Error
+	case req.Method == MethodPing:
+		reply.Result = json.RawMessage(`{}`)
+	default:
+		reply.Error = &Error{Code: ErrMethodNotFound, Message: "no recorded answer for " + req.Method}
+	}
+	_ = r.transport.Send(reply)
+}
+
+// diffResponses compares the result and error of two responses.
+func diffResponses(want, got *Message, ignore []string) []string {
+	return diffJSON("", responseBody(want), responseBody(got), ignore)
+}
+
+func responseBody(msg *Message) interface{} {
+	data, _ := json.Marshal(struct {
+		Result json.RawMessage `json:"result,omitempty"`
+		Error  *Error          `json:"error,omitempty"`
+	}{msg.Result, msg.Error})
+
+	var body interface{}
+	_ = json.Unmarshal(data, &body)
+	return body
+}
+
+// diffJSON lists the differences between two decoded JSON values.
+func diffJSON(path string, want, got interface{}, ignore []string) []string {
+	if ignoredPath(path, ignore) {
+		return nil
+	}
+
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make(map[string]struct{})
+		for k := range w {
+			keys[k] = struct{}{}
+		}
+		for k := range g {
+			keys[k] = struct{}{}
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+
+		var diffs []string
+		for _, k := range sorted {
+			child := k
+			if path != "" {
+				child = path + "." + k
+			}
+			wv, inWant := w[k]
+			gv, inGot := g[k]
+			switch {
+			case !inGot:
+				if !ignoredPath(child, ignore) {
+					diffs = append(diffs, fmt.Sprintf("%s: missing (want %s)", child, compactJSON(wv)))
+				}
+			case !inWant:
+				if !ignoredPath(child, ignore) {
+					diffs = append(diffs, fmt.Sprintf("%s: unexpected %s", child, compactJSON(gv)))
+				}
+			default:
+				diffs = append(diffs, diffJSON(child, wv, gv, ignore)...)
+			}
+		}
+		return diffs
+
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok {
+			break
+		}
+		var diffs []string
+		for i := 0; i < len(w) || i < len(g); i++ {
+			child := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(g):
+				diffs = append(diffs, fmt.Sprintf("%s: missing (want %s)", child, compactJSON(w[i])))
+			case i >= len(w):
+				diffs = append(diffs, fmt.Sprintf("%s: unexpected %s", child, compactJSON(g[i])))
+			default:
+				diffs = append(diffs, diffJSON(child, w[i], g[i], ignore)...)
+			}
+		}
+		return diffs
+	}
+
+	if reflect.DeepEqual(want, got) {
+		return nil
+	}
+	if path == "" {
+		path = "response"
+	}
+	return []string{fmt.Sprintf("%s: want %s, got %s", path, compactJSON(want), compactJSON(got))}
+}
+
+func ignoredPath(path string, ignore []string) bool {
+	for _, p := range ignore {
+		if path == p || strings.HasPrefix(path, p+".") || strings.HasPrefix(path, p+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+func compactJSON(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	const max = 80
+	if len(data) > max {
+		return string(data[:max]) + "..."
+	}
+	return string(data)
+}
@@ -0,0 +1,213 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// SchemaError lists the ways a value fails to match a JSON Schema.
+type SchemaError struct {
+	Problems []string
+}
+
+func (e *SchemaError) Error() string {
+	return "invalid arguments: " + strings.Join(e.Problems, "; ")
+}
+
+// ValidateArguments checks tool arguments against the tool's input schema.
+// It supports the subset of JSON Schema used by tool definitions in
+// practice: type, properties, required, additionalProperties, items and
+// enum. Unknown keywords are ignored. A nil or empty schema accepts anything.
+func ValidateArguments(schema json.RawMessage, args map[string]interface{}) error {
+	if len(schema) == 0 {
+		return nil
+	}
+
+	var s jsonSchema
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return fmt.Errorf("invalid input schema: %w", err)
+	}
+
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+
+	var problems []string
+	s.validate("arguments", args, &problems)
+	if len(problems) > 0 {
+		return &SchemaError{Problems: problems}
+	}
+	return nil
+}
+
+// SchemaPropertyType returns the declared type of a top-level property, or
+// "" if the schema does not declare one.
+func SchemaPropertyType(schema json.RawMessage, property string) string {
+	var s jsonSchema
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return ""
+	}
+	prop, ok := s.Properties[property]
+	if !ok {
+		return ""
+	}
+	if types := prop.types(); len(types) == 1 {
+		return types[0]
+	}
+	return ""
+}
+
+type jsonSchema struct {
+	Type                 interface{}            `json:"type"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	Required             []string               `json:"required"`
+	AdditionalProperties interface{}            `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+	Enum                 []interface{}          `json:"enum"`
+}
+
+func (s *jsonSchema) types() []string {
+	switch t := s.Type.(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		types := make([]string, 0, len(t))
+		for _, v := range t {
+			if name, ok := v.(string); ok {
+				types = append(types, name)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+func (s *jsonSchema) validate(path string, value interface{}, problems *[]string) {
+	if types := s.types(); len(types) > 0 && !matchesType(types, value) {
+		*problems = append(*problems, fmt.Sprintf("%s: expected %s, got %s", path, strings.Join(types, " or "), jsonTypeOf(value)))
+		return
+	}
+
+	if len(s.Enum) > 0 && !inEnum(s.Enum, value) {
+		*problems = append(*problems, fmt.Sprintf("%s: must be one of %s", path, compactJSON(s.Enum)))
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				*problems = append(*problems, fmt.Sprintf("%s.%s: required", path, name))
+			}
+		}
+
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if prop, ok := s.Properties[name]; ok {
+				prop.validate(path+"."+name, v[name], problems)
+			} else if s.AdditionalProperties == false {
+				*problems = append(*problems, fmt.Sprintf("%s.%s: unknown property", path, name))
+			}
+		}
+
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, problems)
+			}
+		}
+	}
+}
+
+func matchesType(types []string, value interface{}) bool {
+	for _, t := range types {
+		switch t {
+		case "object":
+			if _, ok := value.(map[string]interface{}); ok {
+				return true
+			}
+		case "array":
+			if _, ok := value.([]interface{}); ok {
+				return true
+			}
+		case "string":
+			if _, ok := value.(string); ok {
+				return true
+			}
+		case "boolean":
+			if _, ok := value.(bool); ok {
+				return true
+			}
+		case "null":
+			if value == nil {
+				return true
+			}
+		case "number":
+			if _, ok := toFloat(value); ok {
+				return true
+			}
+		case "integer":
+			if f, ok := toFloat(value); ok && f == math.Trunc(f) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func jsonTypeOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	}
+	if _, ok := toFloat(value); ok {
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, allowed := range enum {
+		if reflect.DeepEqual(allowed, value) {
+			return true
+		}
+		// Numbers decode as float64 from the schema but may be ints here.
+		a, aok := toFloat(allowed)
+		v, vok := toFloat(value)
+		if aok && vok && a == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateArguments(t *testing.T) {
+	schema := json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"path": {"type": "string"},
+			"depth": {"type": "integer"},
+			"mode": {"type": "string", "enum": ["fast", "slow"]},
+			"tags": {"type": "array", "items": {"type": "string"}},
+			"limit": {"type": ["number", "null"]}
+		},
+		"required": ["path"],
+		"additionalProperties": false
+	}`)
+
+	tests := []struct {
+		name    string
+		args    map[string]interface{}
+		wantErr string
+	}{
+		{"valid", map[string]interface{}{"path": "/tmp", "depth": float64(2), "tags": []interface{}{"a"}}, ""},
+		{"nullable", map[string]interface{}{"path": "/tmp", "limit": nil}, ""},
+		{"missing required", map[string]interface{}{}, "arguments.path: required"},
+		{"wrong type", map[string]interface{}{"path": float64(1)}, "arguments.path: expected string, got number"},
+		{"not an integer", map[string]interface{}{"path": "/", "depth": 1.5}, "arguments.depth: expected integer"},
+		{"enum", map[string]interface{}{"path": "/", "mode": "medium"}, "arguments.mode: must be one of"},
+		{"item type", map[string]interface{}{"path": "/", "tags": []interface{}{"a", true}}, "arguments.tags[1]: expected string"},
+		{"unknown property", map[string]interface{}{"path": "/", "extra": "x"}, "arguments.extra: unknown property"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateArguments(schema, tt.args)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateArguments() error = %v", err)
+				}
+				return
+			}
+			var schemaErr *SchemaError
+			if !errors.As(err, &schemaErr) {
+				t.Fatalf("ValidateArguments() error = %v, want *SchemaError", err)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateArguments() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateArgumentsEmptySchema(t *testing.T) {
+	if err := ValidateArguments(nil, map[string]interface{}{"anything": 1}); err != nil {
+		t.Errorf("ValidateArguments() with no schema error = %v", err)
+	}
+}
@@ -0,0 +1,270 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ServerOptions configures a Server.
+type ServerOptions struct {
+	// Info identifies the server to the client.
+	Info Implementation
+
+	// Capabilities are advertised to the client during initialization.
+	Capabilities ServerCapabilities
+
+	// Instructions are optional usage hints returned from initialize.
+	Instructions string
+}
+
+// Server is an MCP server session over a Transport. The initialize handshake
+// and ping are answered by the server itself; other requests are dispatched
+// to registered handlers, each on its own goroutine. A notifications/cancelled
+// from the client cancels the context of the matching handler.
+type Server struct {
+	transport Transport
+	opts      ServerOptions
+
+	mu             sync.Mutex
+	handlers       map[string]RequestHandler
+	notifyHandlers map[string][]NotificationHandler
+	inflight       map[string]context.CancelFunc
+	initParams     *InitializeParams
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewServer creates a server session over the given transport. Register
+// handlers before calling Serve.
+func NewServer(t Transport, opts *ServerOptions) *Server {
+	s := &Server{
+		transport:      newBatchResponder(t),
+		handlers:       make(map[string]RequestHandler),
+		notifyHandlers: make(map[string][]NotificationHandler),
+		inflight:       make(map[string]context.CancelFunc),
+		done:           make(chan struct{}),
+	}
+	if opts != nil {
+		s.opts = *opts
+	}
+	if s.opts.Info.Name == "" {
+		s.opts.Info = Implementation{Name: "mcp-adapter", Version: "dev"}
+	}
+	return s
+}
+
+// HandleRequest registers a handler for a client request method.
+func (s *Server) HandleRequest(method string, handler RequestHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[method] = handler
+}
+
+// OnNotification registers a handler for a client notification method.
+// An empty method registers a handler for all notifications.
+func (s *Server) OnNotification(method string, handler NotificationHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.notifyHandlers[method] = append(s.notifyHandlers[method], handler)
+}
+
+// InitializeParams returns the parameters the client initialized with, or
+// nil before initialization.
+func (s *Server) InitializeParams() *InitializeParams {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.initParams
+}
+
+// Notify sends a notification to the client.
+func (s *Server) Notify(method string, params interface{}) error {
+	raw, err := marshalParams(params)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-s.done:
+		return io.ErrClosedPipe
+	default:
+	}
+
+	return s.transport.Send(&Message{JSONRPC: "2.0", Method: method, Params: raw})
+}
+
+// Serve reads and dispatches messages until the transport ends. It returns
+// nil when the client disconnects cleanly.
+func (s *Server) Serve() error {
+	defer s.shutdown()
+
+	for {
+		msg, err := s.transport.Receive()
+		if IsRecoverable(err) {
+			if resp := errorResponse(err); resp != nil {
+				_ = s.transport.Send(resp)
+			}
+			continue
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			select {
+			case <-s.done:
+				return nil
+			default:
+				return err
+			}
+		}
+
+		switch {
+		case msg.IsRequest():
+			s.startRequest(msg)
+		case msg.Method == NotificationCancelled:
+			var params CancelledParams
+			if err := json.Unmarshal(msg.Params, &params); err == nil {
+				s.cancelRequest(params.RequestID)
+			}
+		case msg.IsNotification():
+			s.dispatchNotification(msg)
+		}
+	}
+}
+
+// Done returns a channel that is closed when the session ends.
+func (s *Server) Done() <-chan struct{} {
+	return s.done
+}
+
+// Close ends the session and closes the underlying transport.
+func (s *Server) Close() error {
+	s.shutdown()
+	return s.transport.Close()
+}
+
+func (s *Server) startRequest(msg *Message) {
+	ctx, cancel := context.WithCancel(context.Background())
+	key := idKey(msg.ID)
+
+	s.mu.Lock()
+	s.inflight[key] = cancel
+	s.mu.Unlock()
+
+	go func() {
+		defer func() {
+			s.mu.Lock()
+			delete(s.inflight, key)
+			s.mu.Unlock()
+			cancel()
+		}()
+
+		resp := s.handleRequest(ctx, msg)
+		if ctx.Err() != nil {
+			// Cancelled requests must not be answered.
+			return
+		}
+		_ = s.transport.Send(resp)
+	}()
+}
+
+func (s *Server) handleRequest(ctx context.Context, msg *Message) *Message {
+	resp := &Message{JSONRPC: "2.0", ID: msg.ID}
+
+	s.mu.Lock()
+	handler, ok := s.handlers[msg.Method]
+	s.mu.Unlock()
+
+	var (
+		result interface{}
+		err    error
+	)
+	switch {
+	case msg.Method == MethodInitialize:
+		result, err = s.initialize(msg.Params)
+	case ok:
+		result, err = handler(ctx, msg.Params)
+	case msg.Method == MethodPing:
+		result = struct{}{}
+	default:
+		err = &Error{Code: ErrMethodNotFound, Message: fmt.Sprintf("method not found: %s", msg.Method)}
+	}
+
+	if err != nil {
+		var rpcErr *Error
+		if errors.As(err, &rpcErr) {
+			resp.Error = rpcErr
+		} else {
+			resp.Error = &Error{Code: ErrInternal, Message: err.Error()}
+		}
+		return resp
+	}
+
+	raw, err := json.Marshal(result)
+	if err != nil {
+		resp.Error = &Error{Code: ErrInternal, Message: err.Error()}
+		return resp
+	}
+	resp.Result = raw
+	return resp
+}
+
+// initialize answers the handshake, echoing the client's protocol version
+// when supported and offering the latest one otherwise.
+func (s *Server) initialize(raw json.RawMessage) (*InitializeResult, error) {
+	var params InitializeParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, &Error{Code: ErrInvalidParams, Message: "invalid initialize params: " + err.Error()}
+	}
+
+	s.mu.Lock()
+	s.initParams = &params
+	s.mu.Unlock()
+
+	version := params.ProtocolVersion
+	if !IsSupportedProtocolVersion(version) {
+		version = LatestProtocolVersion
+	}
+
+	return &InitializeResult{
+		ProtocolVersion: version,
+		Capabilities:    s.opts.Capabilities,
+		ServerInfo:      s.opts.Info,
+		Instructions:    s.opts.Instructions,
+	}, nil
+}
+
+func (s *Server) cancelRequest(id interface{}) {
+	s.mu.Lock()
+	cancel, ok := s.inflight[idKey(id)]
+	s.mu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+func (s *Server) dispatchNotification(msg *Message) {
+	s.mu.Lock()
+	handlers := append([]NotificationHandler{}, s.notifyHandlers[msg.Method]...)
+	handlers = append(handlers, s.notifyHandlers[""]...)
+	s.mu.Unlock()
+
+	for _, h := range handlers {
+		h(msg.Method, msg.Params)
+	}
+}
+
+func (s *Server) shutdown() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for _, cancel := range s.inflight {
+			cancel()
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newServerPair(t *testing.T, setup func(*Server)) *Client {
+	t.Helper()
+	clientEnd, serverEnd := NewPipe()
+
+	srv := NewServer(serverEnd, &ServerOptions{Info: Implementation{Name: "test", Version: "1.0.0"}})
+	if setup != nil {
+		setup(srv)
+	}
+	go srv.Serve()
+
+	client := NewClient(clientEnd, &ClientOptions{RequestTimeout: 2 * time.Second})
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestServerInitialize(t *testing.T) {
+	client := newServerPair(t, nil)
+
+	result, err := client.Initialize(context.Background())
+	if err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	if result.ProtocolVersion != LatestProtocolVersion {
+		t.Errorf("ProtocolVersion = %q, want %q", result.ProtocolVersion, LatestProtocolVersion)
+	}
+	if result.ServerInfo.Name != "test" {
+		t.Errorf("ServerInfo.Name = %q, want %q", result.ServerInfo.Name, "test")
+	}
+
+	if err := client.Ping(context.Background()); err != nil {
+		t.Errorf("Ping() error = %v", err)
+	}
+
+	var rpcErr *Error
+	err = client.Call(context.Background(), "no/such/method", nil, nil)
+	if !errors.As(err, &rpcErr) || rpcErr.Code != ErrMethodNotFound {
+		t.Errorf("Call() error = %v, want method not found", err)
+	}
+}
+
+func TestServerCancelsHandler(t *testing.T) {
+	cancelled := make(chan struct{})
+	client := newServerPair(t, func(s *Server) {
+		s.HandleRequest("slow", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+			<-ctx.Done()
+			close(cancelled)
+			return nil, ctx.Err()
+		})
+	})
+
+	if _, err := client.Initialize(context.Background()); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := client.Call(ctx, "slow", nil, nil); err == nil {
+		t.Fatal("Call() should fail when its context expires")
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("handler context was not cancelled")
+	}
+}
@@ -0,0 +1,350 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sseEvent is a single server-sent event.
+type sseEvent struct {
+	ID    string
+	Event string
+	Data  []byte
+	Retry time.Duration
+}
+
+// sseReader parses a text/event-stream body.
+type sseReader struct {
+	r *bufio.Reader
+}
+
+func newSSEReader(r io.Reader) *sseReader {
+	return &sseReader{r: bufio.NewReader(r)}
+}
+
+// Next returns the next event with a non-empty data field.
+func (s *sseReader) Next() (*sseEvent, error) {
+	var (
+		ev      sseEvent
+		data    bytes.Buffer
+		hasData bool
+	)
+
+	for {
+		line, err := s.r.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		if line == "" {
+			if hasData {
+				ev.Data = data.Bytes()
+				return &ev, nil
+			}
+			// Events without data are not dispatched, but their ID still counts.
+			ev = sseEvent{ID: ev.ID}
+			continue
+		}
+
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "id":
+			ev.ID = value
+		case "event":
+			ev.Event = value
+		case "data":
+			if hasData {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+			hasData = true
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil {
+				ev.Retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+}
+
+// SSETransport implements the legacy HTTP+SSE transport (protocol revision
+// 2024-11-05) as a client. A GET request opens an event stream whose first
+// "endpoint" event names the URL that messages are POSTed to; all server
+// messages arrive as "message" events on that stream.
+type SSETransport struct {
+	url     string
+	client  *http.Client
+	headers map[string]string
+
+	mu              sync.Mutex
+	endpoint        string
+	protocolVersion string
+	err             error
+
+	incoming    chan *Message
+	ctx         context.Context
+	cancel      context.CancelFunc
+	connectOnce sync.Once
+	connectErr  error
+}
+
+// NewSSETransport creates a legacy SSE transport for the given stream URL.
+// The stream is opened on the first Send.
+func NewSSETransport(streamURL string, opts *HTTPTransportOptions) *SSETransport {
+	if opts == nil {
+		opts = &HTTPTransportOptions{}
+	}
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &SSETransport{
+		url:      streamURL,
+		client:   client,
+		headers:  opts.Headers,
+		incoming: make(chan *Message, 64),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Connect opens the event stream and waits for the server to announce its
+// message endpoint. It is called implicitly by Send.
+func (t *SSETransport) Connect(ctx context.Context) error {
+	t.connectOnce.Do(func() {
+		t.connectErr = t.connect(ctx)
+		if t.connectErr != nil {
+			t.fail(t.connectErr)
+		}
+	})
+	return t.connectErr
+}
+
+// SetProtocolVersion sets the negotiated protocol version sent with subsequent requests.
+func (t *SSETransport) SetProtocolVersion(version string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.protocolVersion = version
+}
+
+// Send POSTs a message to the server's message endpoint.
+func (t *SSETransport) Send(msg *Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	return t.post(data)
+}
+
+// SendBatch POSTs messages to the server's message endpoint as a single
+// JSON-RPC batch. The responses arrive on the event stream.
+func (t *SSETransport) SendBatch(msgs []*Message) error {
+	data, err := json.Marshal(msgs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal batch: %w", err)
+	}
+	return t.post(data)
+}
+
+func (t *SSETransport) post(data []byte) error {
+	if err := t.Connect(t.ctx); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	endpoint := t.endpoint
+	t.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(t.ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	t.applyHeaders(req)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("server returned HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+
+	return nil
+}
+
+// Receive returns the next message from the event stream.
+func (t *SSETransport) Receive() (*Message, error) {
+	select {
+	case msg := <-t.incoming:
+		return msg, nil
+	case <-t.ctx.Done():
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if t.err != nil {
+			return nil, t.err
+		}
+		return nil, io.EOF
+	}
+}
+
+// Close closes the event stream.
+func (t *SSETransport) Close() error {
+	t.cancel()
+	return nil
+}
+
+func (t *SSETransport) connect(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(t.ctx, http.MethodGet, t.url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	t.applyHeaders(req)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to open event stream: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return fmt.Errorf("failed to open event stream: HTTP %d", resp.StatusCode)
+	}
+
+	reader := newSSEReader(resp.Body)
+	endpointCh := make(chan error, 1)
+	go t.readStream(resp.Body, reader, endpointCh)
+
+	select {
+	case err := <-endpointCh:
+		return err
+	case <-ctx.Done():
+		resp.Body.Close()
+		return ctx.Err()
+	}
+}
+
+func (t *SSETransport) readStream(body io.ReadCloser, reader *sseReader, endpointCh chan<- error) {
+	defer body.Close()
+
+	announced := false
+	for {
+		ev, err := reader.Next()
+		if err != nil {
+			if !announced {
+				endpointCh <- fmt.Errorf("event stream closed before endpoint was announced: %w", err)
+			}
+			t.fail(io.EOF)
+			return
+		}
+
+		switch ev.Event {
+		case "endpoint":
+			endpoint, err := t.resolveEndpoint(string(ev.Data))
+			if err != nil {
+				if !announced {
+					endpointCh <- err
+				}
+				t.fail(err)
+				return
+			}
+			t.mu.Lock()
+			t.endpoint = endpoint
+			t.mu.Unlock()
+			if !announced {
+				announced = true
+				endpointCh <- nil
+			}
+
+		case "", "message":
+			msgs, err := decodeMessages(ev.Data)
+			if err != nil {
+				continue
+			}
+			for _, msg := range msgs {
+				select {
+				case t.incoming <- msg:
+				case <-t.ctx.Done():
+					return
+				}
+			}
+		}
+	}
+}
+
+// resolveEndpoint resolves the announced endpoint against the stream URL and
+// rejects endpoints on a different origin.
+func (t *SSETransport) resolveEndpoint(ref string) (string, error) {
+	base, err := url.Parse(t.url)
+	if err != nil {
+		return "", fmt.Errorf("invalid stream url: %w", err)
+	}
+	target, err := base.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return "", fmt.Errorf("invalid endpoint %q: %w", ref, err)
+	}
+	if target.Scheme != base.Scheme || target.Host != base.Host {
+		return "", fmt.Errorf("endpoint %q is not on the same origin as %q", ref, t.url)
+	}
+	return target.String(), nil
+}
+
+func (t *SSETransport) applyHeaders(req *http.Request) {
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.protocolVersion != "" {
+		req.Header.Set(HeaderProtocolVersion, t.protocolVersion)
+	}
+}
+
+func (t *SSETransport) fail(err error) {
+	t.mu.Lock()
+	if t.err == nil {
+		t.err = err
+	}
+	t.mu.Unlock()
+	t.cancel()
+}
+
+// writeSSE writes a single message event to an event stream.
+func writeSSE(w io.Writer, id string, data []byte) error {
+	var buf bytes.Buffer
+	if id != "" {
+		fmt.Fprintf(&buf, "id: %s\n", id)
+	}
+	buf.WriteString("event: message\n")
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		buf.WriteString("data: ")
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
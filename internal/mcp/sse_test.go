@@ -0,0 +1,178 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSSEReader(t *testing.T) {
+	input := ": comment\n" +
+		"event: endpoint\n" +
+		"data: /messages?sessionId=abc\n" +
+		"\n" +
+		"id: 7\n" +
+		"retry: 2500\n" +
+		"data: line one\n" +
+		"data: line two\r\n" +
+		"\r\n" +
+		"id: 8\n" +
+		"\n"
+
+	reader := newSSEReader(strings.NewReader(input))
+
+	ev, err := reader.Next()
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if ev.Event != "endpoint" || string(ev.Data) != "/messages?sessionId=abc" {
+		t.Errorf("first event = %+v, want endpoint event", ev)
+	}
+
+	ev, err = reader.Next()
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if ev.ID != "7" || ev.Retry != 2500*time.Millisecond || string(ev.Data) != "line one\nline two" {
+		t.Errorf("second event = %+v, want multi-line data with id and retry", ev)
+	}
+
+	if _, err := reader.Next(); err == nil {
+		t.Error("Next() should return an error at end of stream")
+	}
+}
+
+func newLegacySSEServer(t *testing.T, endpoint string) *httptest.Server {
+	t.Helper()
+	messages := make(chan []byte, 8)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sse", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		fmt.Fprintf(w, "event: endpoint\ndata: %s\n\n", endpoint)
+		flusher.Flush()
+
+		for {
+			select {
+			case data := <-messages:
+				fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+				flusher.Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	})
+	mux.HandleFunc("/messages", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("sessionId") != "abc" {
+			http.Error(w, "unknown session", http.StatusNotFound)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		msgs, err := decodeMessages(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+
+		var responses []*Message
+		for _, msg := range msgs {
+			if !msg.IsRequest() {
+				continue
+			}
+			resp := &Message{JSONRPC: "2.0", ID: msg.ID}
+			if msg.Method == MethodInitialize {
+				resp.Result, _ = json.Marshal(InitializeResult{
+					ProtocolVersion: "2024-11-05",
+					ServerInfo:      Implementation{Name: "legacy", Version: "0.1.0"},
+				})
+			} else {
+				resp.Result = json.RawMessage(`{}`)
+			}
+			responses = append(responses, resp)
+		}
+		switch {
+		case len(responses) == 0:
+		case body[0] == '[':
+			// A batch is answered with a batch.
+			data, _ := json.Marshal(responses)
+			messages <- data
+		default:
+			data, _ := json.Marshal(responses[0])
+			messages <- data
+		}
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func TestSSETransport(t *testing.T) {
+	srv := newLegacySSEServer(t, "/messages?sessionId=abc")
+	defer srv.Close()
+
+	client := NewClient(NewSSETransport(srv.URL+"/sse", nil), &ClientOptions{RequestTimeout: 2 * time.Second})
+	defer client.Close()
+
+	result, err := client.Initialize(context.Background())
+	if err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	if result.ServerInfo.Name != "legacy" {
+		t.Errorf("ServerInfo.Name = %q, want %q", result.ServerInfo.Name, "legacy")
+	}
+	if client.ProtocolVersion() != "2024-11-05" {
+		t.Errorf("ProtocolVersion() = %q, want %q", client.ProtocolVersion(), "2024-11-05")
+	}
+
+	if err := client.Ping(context.Background()); err != nil {
+		t.Errorf("Ping() error = %v", err)
+	}
+}
+
+func TestSSETransportBatch(t *testing.T) {
+	srv := newLegacySSEServer(t, "/messages?sessionId=abc")
+	defer srv.Close()
+
+	transport := NewSSETransport(srv.URL+"/sse", nil)
+	defer transport.Close()
+
+	err := transport.SendBatch([]*Message{
+		{JSONRPC: "2.0", ID: 1, Method: MethodPing},
+		{JSONRPC: "2.0", ID: 2, Method: MethodPing},
+	})
+	if err != nil {
+		t.Fatalf("SendBatch() error = %v", err)
+	}
+
+	for _, want := range []string{"1", "2"} {
+		msg, err := transport.Receive()
+		if err != nil {
+			t.Fatalf("Receive() error = %v", err)
+		}
+		if !msg.IsResponse() || idKey(msg.ID) != want {
+			t.Errorf("Receive() = %+v, want response %s", msg, want)
+		}
+	}
+}
+
+func TestSSETransportRejectsCrossOriginEndpoint(t *testing.T) {
+	srv := newLegacySSEServer(t, "https://evil.example.com/messages")
+	defer srv.Close()
+
+	transport := NewSSETransport(srv.URL+"/sse", nil)
+	defer transport.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := transport.Connect(ctx); err == nil {
+		t.Error("Connect() should reject an endpoint on another origin")
+	}
+}
@@ -0,0 +1,365 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+)
+
+// DefaultMaxMessageSize is the default limit on the size of one incoming
+// stdio message.
+const DefaultMaxMessageSize = 16 << 20
+
+// Framing selects how messages are delimited on a stdio stream.
+type Framing int
+
+const (
+	// FramingNewline delimits messages with newlines, as MCP specifies.
+	FramingNewline Framing = iota
+	// FramingContentLength precedes each message with LSP-style
+	// Content-Length headers.
+	FramingContentLength
+)
+
+// TransportErrorKind classifies errors returned by StdioTransport.Receive.
+type TransportErrorKind int
+
+const (
+	// TransportEOF means the stream ended.
+	TransportEOF TransportErrorKind = iota + 1
+	// TransportFrameTooLarge means a message exceeded the size limit. The
+	// message was discarded and the transport remains usable.
+	TransportFrameTooLarge
+	// TransportDecodeError means a frame was not a valid JSON-RPC message.
+	// The frame was discarded and the transport remains usable.
+	TransportDecodeError
+	// TransportIOError means reading from the stream failed.
+	TransportIOError
+)
+
+func (k TransportErrorKind) String() string {
+	switch k {
+	case TransportEOF:
+		return "eof"
+	case TransportFrameTooLarge:
+		return "frame too large"
+	case TransportDecodeError:
+		return "decode error"
+	case TransportIOError:
+		return "i/o error"
+	default:
+		return "unknown"
+	}
+}
+
+// TransportError describes why a message could not be received.
+type TransportError struct {
+	Kind TransportErrorKind
+
+	// Size is the size of an oversized frame and Limit the size limit.
+	Size  int
+	Limit int
+
+	// Frame is the start of a frame that could not be decoded.
+	Frame string
+
+	Err error
+}
+
+func (e *TransportError) Error() string {
+	switch e.Kind {
+	case TransportEOF:
+		if e.Err != nil && e.Err != io.EOF {
+			return fmt.Sprintf("transport closed: %v", e.Err)
+		}
+		return "transport closed"
+	case TransportFrameTooLarge:
+		return fmt.Sprintf("message of %d bytes exceeds the %d byte limit", e.Size, e.Limit)
+	case TransportDecodeError:
+		return fmt.Sprintf("failed to decode message %q: %v", e.Frame, e.Err)
+	default:
+		return fmt.Sprintf("failed to read message: %v", e.Err)
+	}
+}
+
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// Recoverable reports whether the transport can keep receiving after the
+// error, because only the offending message was lost.
+func (e *TransportError) Recoverable() bool {
+	return e.Kind == TransportFrameTooLarge || e.Kind == TransportDecodeError
+}
+
+// IsRecoverable reports whether err is a TransportError after which the
+// transport can keep receiving.
+func IsRecoverable(err error) bool {
+	var te *TransportError
+	return errors.As(err, &te) && te.Recoverable()
+}
+
+// errorResponse returns the response owed to a peer whose message could not
+// be received because of a recoverable error. Its ID is null because the
+// request's ID is unknown.
+func errorResponse(err error) *Message {
+	var te *TransportError
+	if !errors.As(err, &te) {
+		return nil
+	}
+
+	resp := &Message{JSONRPC: "2.0", ID: json.RawMessage("null")}
+	switch te.Kind {
+	case TransportDecodeError:
+		resp.Error = &Error{Code: ErrParse, Message: "Parse error"}
+	case TransportFrameTooLarge:
+		resp.Error = &Error{Code: ErrInvalidRequest, Message: te.Error()}
+	default:
+		return nil
+	}
+	return resp
+}
+
+// StdioOptions configures a StdioTransport.
+type StdioOptions struct {
+	// Framing selects how outgoing messages are delimited. Incoming
+	// messages are accepted in either framing.
+	Framing Framing
+
+	// MaxMessageSize limits the size of one incoming message. Defaults to
+	// DefaultMaxMessageSize.
+	MaxMessageSize int
+
+	// OnStrayLine is called with each incoming line that is skipped
+	// because it is not JSON, such as a banner a server prints on startup.
+	OnStrayLine func(line []byte)
+}
+
+// StdioTransport implements MCP transport over stdio.
+type StdioTransport struct {
+	reader *bufio.Reader
+	writer io.Writer
+	opts   StdioOptions
+	mu     sync.Mutex
+
+	// queue holds the rest of a received batch.
+	queue []*Message
+}
+
+// NewStdioTransport creates a new newline-delimited stdio transport.
+func NewStdioTransport(r io.Reader, w io.Writer) *StdioTransport {
+	return NewStdioTransportWithOptions(r, w, nil)
+}
+
+// NewStdioTransportWithOptions creates a new stdio transport.
+func NewStdioTransportWithOptions(r io.Reader, w io.Writer, opts *StdioOptions) *StdioTransport {
+	t := &StdioTransport{
+		reader: bufio.NewReader(r),
+		writer: w,
+	}
+	if opts != nil {
+		t.opts = *opts
+	}
+	if t.opts.MaxMessageSize <= 0 {
+		t.opts.MaxMessageSize = DefaultMaxMessageSize
+	}
+	return t
+}
+
+// Send sends a message over the transport.
+func (t *StdioTransport) Send(msg *Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	return t.writeFrame(data)
+}
+
+// SendBatch sends messages as a single JSON-RPC batch.
+func (t *StdioTransport) SendBatch(msgs []*Message) error {
+	data, err := json.Marshal(msgs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal batch: %w", err)
+	}
+	return t.writeFrame(data)
+}
+
+func (t *StdioTransport) writeFrame(data []byte) error {
+	var frame []byte
+	if t.opts.Framing == FramingContentLength {
+		frame = append([]byte(fmt.Sprintf("Content-Length: %d\r\n\r\n", len(data))), data...)
+	} else {
+		frame = append(data, '\n')
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, err := t.writer.Write(frame); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	return nil
+}
+
+// Receive receives a message from the transport. Blank and non-JSON lines
+// are skipped, and the messages of a batch are returned one at a time.
+// Errors are *TransportError values; after a recoverable one the caller may
+// call Receive again.
+func (t *StdioTransport) Receive() (*Message, error) {
+	if len(t.queue) > 0 {
+		msg := t.queue[0]
+		t.queue = t.queue[1:]
+		return msg, nil
+	}
+
+	for {
+		frame, err := t.readFrame()
+		if err != nil {
+			return nil, err
+		}
+		if len(frame) == 0 {
+			continue
+		}
+
+		if frame[0] == '[' {
+			msgs, err := decodeBatch(frame)
+			if err != nil {
+				return nil, &TransportError{Kind: TransportDecodeError, Frame: truncateFrame(frame), Err: err}
+			}
+			t.queue = msgs[1:]
+			return msgs[0], nil
+		}
+
+		var msg Message
+		if err := json.Unmarshal(frame, &msg); err != nil {
+			return nil, &TransportError{Kind: TransportDecodeError, Frame: truncateFrame(frame), Err: err}
+		}
+		return &msg, nil
+	}
+}
+
+// Close closes the transport.
+func (t *StdioTransport) Close() error {
+	return nil
+}
+
+// readFrame reads the next message frame. It returns nil without an error
+// for lines that carry no message.
+func (t *StdioTransport) readFrame() ([]byte, error) {
+	line, err := t.readLine()
+	if err != nil {
+		return nil, err
+	}
+
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return nil, nil
+	}
+
+	if n, ok, err := parseContentLength(line); ok {
+		if err != nil {
+			return nil, &TransportError{Kind: TransportDecodeError, Frame: truncateFrame(line), Err: err}
+		}
+		return t.readContentLengthBody(n)
+	}
+
+	if line[0] != '{' && line[0] != '[' {
+		if t.opts.OnStrayLine != nil {
+			t.opts.OnStrayLine(line)
+		}
+		return nil, nil
+	}
+	return line, nil
+}
+
+// readLine reads one newline-terminated line. A line longer than the size
+// limit is consumed and discarded. A final unterminated line is returned
+// as is.
+func (t *StdioTransport) readLine() ([]byte, error) {
+	var line []byte
+	size := 0
+	for {
+		chunk, err := t.reader.ReadSlice('\n')
+		size += len(chunk)
+		if size <= t.opts.MaxMessageSize+2 { // allow for "\r\n"
+			line = append(line, chunk...)
+		}
+
+		switch {
+		case err == bufio.ErrBufferFull:
+			continue
+		case err == io.EOF && size == 0:
+			return nil, &TransportError{Kind: TransportEOF, Err: io.EOF}
+		case err != nil && err != io.EOF:
+			return nil, &TransportError{Kind: TransportIOError, Err: err}
+		}
+
+		if size > t.opts.MaxMessageSize+2 {
+			return nil, &TransportError{Kind: TransportFrameTooLarge, Size: size, Limit: t.opts.MaxMessageSize}
+		}
+		return line, nil
+	}
+}
+
+// readContentLengthBody reads the rest of a Content-Length header block and
+// the n byte body that follows it.
+func (t *StdioTransport) readContentLengthBody(n int) ([]byte, error) {
+	for {
+		header, err := t.readLine()
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		if len(bytes.TrimSpace(header)) == 0 {
+			break
+		}
+	}
+
+	if n > t.opts.MaxMessageSize {
+		if _, err := io.CopyN(io.Discard, t.reader, int64(n)); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		return nil, &TransportError{Kind: TransportFrameTooLarge, Size: n, Limit: t.opts.MaxMessageSize}
+	}
+
+	body := make([]byte, n)
+	if _, err := io.ReadFull(t.reader, body); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return bytes.TrimSpace(body), nil
+}
+
+// parseContentLength recognises a "Content-Length: N" header line.
+func parseContentLength(line []byte) (int, bool, error) {
+	const prefix = "content-length:"
+	if len(line) < len(prefix) || !bytes.EqualFold(line[:len(prefix)], []byte(prefix)) {
+		return 0, false, nil
+	}
+	n, err := strconv.Atoi(string(bytes.TrimSpace(line[len(prefix):])))
+	if err != nil || n < 0 {
+		return 0, true, fmt.Errorf("invalid Content-Length header")
+	}
+	return n, true, nil
+}
+
+// unexpectedEOF reports a stream that ended in the middle of a frame.
+func unexpectedEOF(err error) error {
+	var te *TransportError
+	if errors.As(err, &te) && te.Kind != TransportEOF {
+		return err
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF || te != nil {
+		return &TransportError{Kind: TransportEOF, Err: io.ErrUnexpectedEOF}
+	}
+	return &TransportError{Kind: TransportIOError, Err: err}
+}
+
+func truncateFrame(frame []byte) string {
+	if len(frame) > 60 {
+		return string(frame[:57]) + "..."
+	}
+	return string(frame)
+}
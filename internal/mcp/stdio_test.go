@@ -0,0 +1,141 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func receiveAll(t *testing.T, tr *StdioTransport) ([]*Message, []error) {
+	t.Helper()
+	var msgs []*Message
+	var errs []error
+	for i := 0; i < 20; i++ {
+		msg, err := tr.Receive()
+		if err != nil {
+			errs = append(errs, err)
+			if !IsRecoverable(err) {
+				return msgs, errs
+			}
+			continue
+		}
+		msgs = append(msgs, msg)
+	}
+	t.Fatal("Receive() did not reach the end of the stream")
+	return nil, nil
+}
+
+func TestStdioTransportSkipsStrayLines(t *testing.T) {
+	input := "Server v1.2 starting...\n\n" +
+		`{"jsonrpc":"2.0","id":1,"result":{}}` + "\r\n" +
+		"ready\n" +
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`
+
+	var stray []string
+	tr := NewStdioTransportWithOptions(strings.NewReader(input), io.Discard, &StdioOptions{
+		OnStrayLine: func(line []byte) { stray = append(stray, string(line)) },
+	})
+
+	msgs, errs := receiveAll(t, tr)
+	if len(msgs) != 2 || msgs[1].Method != NotificationInitialized {
+		t.Errorf("messages = %+v, want a response and an unterminated notification", msgs)
+	}
+	if want := []string{"Server v1.2 starting...", "ready"}; strings.Join(stray, "|") != strings.Join(want, "|") {
+		t.Errorf("stray lines = %q, want %q", stray, want)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], io.EOF) {
+		t.Errorf("errors = %v, want only EOF", errs)
+	}
+}
+
+func TestStdioTransportRecoverableErrors(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,` + "\n" +
+		`{"jsonrpc":"2.0","id":2,"method":"ping","params":{"pad":"` + strings.Repeat("x", 100) + `"}}` + "\n" +
+		`{"jsonrpc":"2.0","id":3,"method":"ping"}` + "\n"
+
+	tr := NewStdioTransportWithOptions(strings.NewReader(input), io.Discard, &StdioOptions{MaxMessageSize: 64})
+	msgs, errs := receiveAll(t, tr)
+
+	if len(msgs) != 1 || msgs[0].Method != MethodPing {
+		t.Fatalf("messages = %+v, want the final ping", msgs)
+	}
+
+	kinds := make([]TransportErrorKind, 0, len(errs))
+	for _, err := range errs {
+		var te *TransportError
+		if !errors.As(err, &te) {
+			t.Fatalf("error %v is not a *TransportError", err)
+		}
+		kinds = append(kinds, te.Kind)
+	}
+	want := []TransportErrorKind{TransportDecodeError, TransportFrameTooLarge, TransportEOF}
+	if len(kinds) != len(want) {
+		t.Fatalf("error kinds = %v, want %v", kinds, want)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Errorf("error %d kind = %v, want %v", i, kinds[i], want[i])
+		}
+	}
+}
+
+func TestStdioTransportContentLength(t *testing.T) {
+	var out bytes.Buffer
+	tr := NewStdioTransportWithOptions(nil, &out, &StdioOptions{Framing: FramingContentLength})
+	if err := tr.Send(&Message{JSONRPC: "2.0", ID: 1, Method: MethodPing}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	body := `{"jsonrpc":"2.0","id":1,"method":"ping"}`
+	if want := "Content-Length: 40\r\n\r\n" + body; out.String() != want {
+		t.Errorf("Send() wrote %q, want %q", out.String(), want)
+	}
+
+	// Framed messages are accepted regardless of the configured framing,
+	// including bodies without a trailing newline.
+	input := out.String() + "content-length: 40\r\nContent-Type: application/json\r\n\r\n" + body +
+		"\n" + body + "\n"
+	tr = NewStdioTransport(strings.NewReader(input), io.Discard)
+	msgs, errs := receiveAll(t, tr)
+	if len(msgs) != 3 {
+		t.Errorf("received %d messages, want 3 (errors: %v)", len(msgs), errs)
+	}
+
+	tr = NewStdioTransportWithOptions(strings.NewReader(out.String()+body), io.Discard, &StdioOptions{MaxMessageSize: 30})
+	_, errs = receiveAll(t, tr)
+	var te *TransportError
+	if len(errs) == 0 || !errors.As(errs[0], &te) || te.Kind != TransportFrameTooLarge || te.Size != 40 {
+		t.Errorf("errors = %v, want an oversized frame of 40 bytes first", errs)
+	}
+}
+
+func TestServerRepliesToMalformedInput(t *testing.T) {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	srv := NewServer(NewStdioTransport(inR, outW), nil)
+	go srv.Serve()
+	defer inW.Close()
+
+	go io.WriteString(inW, "{not json\n"+`{"jsonrpc":"2.0","id":7,"method":"ping"}`+"\n")
+
+	out := bufio.NewReader(outR)
+	line, err := out.ReadString('\n')
+	if err != nil {
+		t.Fatalf("ReadString() error = %v", err)
+	}
+	if want := `{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"Parse error"}}`; strings.TrimSpace(line) != want {
+		t.Errorf("response to malformed input = %s, want %s", line, want)
+	}
+
+	line, err = out.ReadString('\n')
+	if err != nil {
+		t.Fatalf("ReadString() error = %v", err)
+	}
+	var resp Message
+	if err := json.Unmarshal([]byte(line), &resp); err != nil || resp.Error != nil || resp.ID != float64(7) {
+		t.Errorf("response = %s, want the ping result", line)
+	}
+}
@@ -141,6 +141,8 @@ func (d *Detector) DetectForServer(server *manifest.Server) (*Runtime, error) {
 		return d.DetectPython()
 	case manifest.ServerTypeBinary:
 		return &Runtime{Name: "binary", Path: "", Version: ""}, nil
+	case manifest.ServerTypeRemote:
+		return &Runtime{Name: "remote", Path: "", Version: ""}, nil
 	default:
 		return nil, fmt.Errorf("unknown server type: %s", server.Type)
 	}
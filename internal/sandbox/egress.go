@@ -0,0 +1,196 @@
+package sandbox
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// egressDialTimeout bounds connecting to an allowed host.
+const egressDialTimeout = 30 * time.Second
+
+// egressProxy is an HTTP proxy that lets a sandboxed server reach only the
+// allowed hosts. It handles CONNECT for TLS and other tunnels, and plain
+// HTTP requests with absolute URLs.
+type egressProxy struct {
+	allow   []string
+	blocked func(string)
+	server  *http.Server
+	dialer  net.Dialer
+	wg      sync.WaitGroup
+}
+
+// newEgressProxy creates a proxy for the allowed hosts, in the form of
+// NetworkPolicy.Allow. blocked, if set, is called with a description of
+// each refused request.
+func newEgressProxy(allow []string, blocked func(string)) *egressProxy {
+	p := &egressProxy{
+		allow:   allow,
+		blocked: blocked,
+		dialer:  net.Dialer{Timeout: egressDialTimeout},
+	}
+	p.server = &http.Server{Handler: p}
+	return p
+}
+
+// Serve serves proxy requests from l until the proxy is closed.
+func (p *egressProxy) Serve(l net.Listener) error {
+	err := p.server.Serve(l)
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+// Close stops the proxy and closes every tunnel.
+func (p *egressProxy) Close() error {
+	err := p.server.Close()
+	p.wg.Wait()
+	return err
+}
+
+// allowed reports whether the proxy lets a server connect to host:port.
+func (p *egressProxy) allowed(hostport string) bool {
+	host, port, err := net.SplitHostPort(hostport)
+	if err != nil {
+		return false
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	for _, entry := range p.allow {
+		allowedHost, allowedPort := strings.ToLower(entry), ""
+		if h, ap, err := net.SplitHostPort(allowedHost); err == nil {
+			allowedHost, allowedPort = h, ap
+		}
+		if allowedPort != "" && allowedPort != port {
+			continue
+		}
+		if suffix, ok := strings.CutPrefix(allowedHost, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+			continue
+		}
+		if host == allowedHost {
+			return true
+		}
+	}
+	return false
+}
+
+// ServeHTTP handles one proxy request.
+func (p *egressProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	target := r.Host
+	if r.Method != http.MethodConnect {
+		if !r.URL.IsAbs() {
+			http.Error(w, "this is a proxy: requests need an absolute URL", http.StatusBadRequest)
+			return
+		}
+		target = r.URL.Host
+	}
+	if _, _, err := net.SplitHostPort(target); err != nil {
+		port := "80"
+		if r.URL.Scheme == "https" {
+			port = "443"
+		}
+		target = net.JoinHostPort(strings.Trim(target, "[]"), port)
+	}
+
+	if !p.allowed(target) {
+		if p.blocked != nil {
+			p.blocked(fmt.Sprintf("blocked connection to %s: not allowed by the network policy", target))
+		}
+		http.Error(w, fmt.Sprintf("%s is not allowed by the sandbox's network policy", target), http.StatusForbidden)
+		return
+	}
+
+	if r.Method == http.MethodConnect {
+		p.tunnel(w, r, target)
+		return
+	}
+	p.forward(w, r)
+}
+
+// tunnel connects the client to target for CONNECT.
+func (p *egressProxy) tunnel(w http.ResponseWriter, r *http.Request, target string) {
+	upstream, err := p.dialer.DialContext(r.Context(), "tcp", target)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		upstream.Close()
+		http.Error(w, "tunnelling not supported", http.StatusInternalServerError)
+		return
+	}
+	client, buf, err := hijacker.Hijack()
+	if err != nil {
+		upstream.Close()
+		return
+	}
+	if _, err := io.WriteString(client, "HTTP/1.1 200 Connection Established\r\n\r\n"); err != nil {
+		client.Close()
+		upstream.Close()
+		return
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		// Bytes the client sent after the request are already buffered.
+		if n := buf.Reader.Buffered(); n > 0 {
+			data, _ := buf.Reader.Peek(n)
+			upstream.Write(data)
+		}
+		pipe(client, upstream)
+	}()
+}
+
+// forward relays a plain HTTP request.
+func (p *egressProxy) forward(w http.ResponseWriter, r *http.Request) {
+	out := r.Clone(r.Context())
+	out.RequestURI = ""
+	out.Header.Del("Proxy-Connection")
+	out.Header.Del("Proxy-Authorization")
+
+	transport := &http.Transport{DialContext: p.dialer.DialContext}
+	defer transport.CloseIdleConnections()
+	resp, err := transport.RoundTrip(out)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	for k, values := range resp.Header {
+		for _, v := range values {
+			w.Header().Add(k, v)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
+
+// pipe copies between a and b until either side is done, then closes
+// both.
+func pipe(a, b net.Conn) {
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(a, b)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(b, a)
+		done <- struct{}{}
+	}()
+	<-done
+	a.Close()
+	b.Close()
+	<-done
+}
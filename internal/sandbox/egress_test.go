@@ -0,0 +1,34 @@
+package sandbox
+
+import (
+	"testing"
+)
+
+func TestEgressProxyAllowed(t *testing.T) {
+	p := newEgressProxy([]string{"api.github.com", "*.example.com", "registry.npmjs.org:443", "10.0.0.1"}, nil)
+
+	tests := []struct {
+		target string
+		want   bool
+	}{
+		{"api.github.com:443", true},
+		{"API.GitHub.com.:80", true},
+		{"github.com:443", false},
+		{"docs.example.com:443", true},
+		{"a.b.example.com:8080", true},
+		{"example.com:443", false},
+		{"evilexample.com:443", false},
+		{"registry.npmjs.org:443", true},
+		{"registry.npmjs.org:80", false},
+		{"10.0.0.1:22", true},
+		{"api.github.com", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			if got := p.allowed(tt.target); got != tt.want {
+				t.Errorf("allowed(%q) = %v, want %v", tt.target, got, tt.want)
+			}
+		})
+	}
+}
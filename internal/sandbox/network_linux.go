@@ -0,0 +1,119 @@
+package sandbox
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"unsafe"
+)
+
+// egressAddr is where a sandboxed server reaches the egress proxy. The
+// server has a network namespace of its own, so the port is always free.
+const egressAddr = "127.0.0.1:3128"
+
+// egressSeq numbers the egress proxy sockets of this process.
+var egressSeq atomic.Uint64
+
+// startEgressProxy serves an egress proxy for the server on a Unix socket
+// in opts.RunDir, which the sandbox relays egressAddr to, and returns the
+// socket's path. The proxy is stopped when the sandbox is closed.
+func startEgressProxy(sb *Sandbox, opts *Options) (string, error) {
+	if opts.RunDir == "" {
+		return "", fmt.Errorf("no directory for the egress proxy socket")
+	}
+	if err := os.MkdirAll(opts.RunDir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", opts.RunDir, err)
+	}
+
+	path := filepath.Join(opts.RunDir, fmt.Sprintf("egress-%d-%d.sock", os.Getpid(), egressSeq.Add(1)))
+	os.Remove(path)
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return "", fmt.Errorf("failed to start egress proxy: %w", err)
+	}
+
+	var allow []string
+	if opts.Network != nil {
+		allow = opts.Network.Allow
+	}
+	var blocked func(string)
+	if opts.OnViolation != nil {
+		blocked = func(msg string) { opts.OnViolation("network", msg) }
+	}
+	proxy := newEgressProxy(allow, blocked)
+	go proxy.Serve(l)
+	sb.closers = append(sb.closers, proxy)
+	return path, nil
+}
+
+// proxyEnv points HTTP clients at the egress proxy.
+func proxyEnv() []string {
+	proxy := "http://" + egressAddr
+	noProxy := "localhost,127.0.0.1,::1"
+	return []string{
+		"HTTP_PROXY=" + proxy,
+		"HTTPS_PROXY=" + proxy,
+		"http_proxy=" + proxy,
+		"https_proxy=" + proxy,
+		"NO_PROXY=" + noProxy,
+		"no_proxy=" + noProxy,
+		// Node.js only honours the variables above when asked to.
+		"NODE_USE_ENV_PROXY=1",
+	}
+}
+
+// relayEgress forwards connections to egressAddr inside the namespace to
+// the egress proxy's socket outside it.
+func relayEgress(socket string) error {
+	l, err := net.Listen("tcp", egressAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen for egress: %w", err)
+	}
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				upstream, err := net.Dial("unix", socket)
+				if err != nil {
+					conn.Close()
+					return
+				}
+				pipe(conn, upstream)
+			}()
+		}
+	}()
+	return nil
+}
+
+// loopbackUp brings up the loopback interface of a new network namespace,
+// which starts down.
+func loopbackUp() error {
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return fmt.Errorf("failed to bring up loopback: %w", err)
+	}
+	defer syscall.Close(fd)
+
+	// struct ifreq with ifr_flags.
+	var req struct {
+		name  [syscall.IFNAMSIZ]byte
+		flags uint16
+		_     [22]byte
+	}
+	copy(req.name[:], "lo")
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), syscall.SIOCGIFFLAGS, uintptr(unsafe.Pointer(&req))); errno != 0 {
+		return fmt.Errorf("failed to bring up loopback: %w", errno)
+	}
+	req.flags |= syscall.IFF_UP
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), syscall.SIOCSIFFLAGS, uintptr(unsafe.Pointer(&req))); errno != 0 {
+		return fmt.Errorf("failed to bring up loopback: %w", errno)
+	}
+	return nil
+}
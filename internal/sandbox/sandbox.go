@@ -0,0 +1,362 @@
+// Package sandbox runs MCP servers with restricted access to the system.
+//
+// On Linux a sandboxed server runs in its own user, mount and PID
+// namespaces, with a private /proc and /tmp, and Landlock limits the files
+// it can reach to its install directory, its runtime, system directories
+// and the paths its policy grants. A network policy puts the server in its
+// own network namespace too, with at most a loopback interface and an
+// egress proxy for allowed hosts, and a seccomp profile denies it system
+// calls. Protections the kernel lacks are left out with a warning rather
+// than failing the launch.
+package sandbox
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Policy is a server's sandbox settings, declared in its manifest and
+// overridden in config.yaml.
+type Policy struct {
+	// Enabled turns the sandbox on. The sandbox is off unless the manifest
+	// or the configuration enables it, and the configuration wins.
+	Enabled *bool `yaml:"enabled,omitempty"`
+
+	// ReadOnly lists paths the server may read and execute, besides its
+	// install directory, its runtime and system directories.
+	ReadOnly []string `yaml:"readOnly,omitempty"`
+
+	// ReadWrite lists paths the server may read and write.
+	ReadWrite []string `yaml:"readWrite,omitempty"`
+}
+
+// IsEnabled reports whether the policy turns the sandbox on.
+func (p *Policy) IsEnabled() bool {
+	return p != nil && p.Enabled != nil && *p.Enabled
+}
+
+// Merge returns the policy with override applied: override decides
+// whether the sandbox is enabled if it says so, and the paths it grants
+// are added.
+func (p *Policy) Merge(override *Policy) *Policy {
+	if p == nil {
+		return override
+	}
+	if override == nil {
+		return p
+	}
+
+	merged := &Policy{
+		Enabled:   p.Enabled,
+		ReadOnly:  append(append([]string{}, p.ReadOnly...), override.ReadOnly...),
+		ReadWrite: append(append([]string{}, p.ReadWrite...), override.ReadWrite...),
+	}
+	if override.Enabled != nil {
+		merged.Enabled = override.Enabled
+	}
+	return merged
+}
+
+// Validate checks that every granted path is absolute, or starts with ~ or
+// an environment variable.
+func (p *Policy) Validate() error {
+	if p == nil {
+		return nil
+	}
+	for _, path := range append(append([]string{}, p.ReadOnly...), p.ReadWrite...) {
+		if !filepath.IsAbs(path) && !strings.HasPrefix(path, "~") && !strings.HasPrefix(path, "$") {
+			return fmt.Errorf("sandbox path %q must be absolute", path)
+		}
+	}
+	return nil
+}
+
+// NetworkMode selects the network a server can reach.
+type NetworkMode string
+
+const (
+	// NetworkFull leaves the network alone. It is the default.
+	NetworkFull NetworkMode = "full"
+	// NetworkNone cuts the server off from every network, loopback
+	// included.
+	NetworkNone NetworkMode = "none"
+	// NetworkLoopback lets the server use only its own loopback
+	// interface.
+	NetworkLoopback NetworkMode = "loopback"
+	// NetworkAllow lets the server reach the allowed hosts through an
+	// egress proxy, set in its HTTP_PROXY and HTTPS_PROXY.
+	NetworkAllow NetworkMode = "allow"
+)
+
+// NetworkPolicy is a server's network access, declared in its manifest and
+// overridden in config.yaml.
+type NetworkPolicy struct {
+	Mode NetworkMode `yaml:"policy"`
+
+	// Allow lists the hosts reachable with NetworkAllow, as "host" for
+	// any port or "host:port". "*.example.com" matches subdomains.
+	Allow []string `yaml:"allow,omitempty"`
+}
+
+// EffectiveMode returns the mode, applying the default.
+func (p *NetworkPolicy) EffectiveMode() NetworkMode {
+	if p == nil || p.Mode == "" {
+		return NetworkFull
+	}
+	return p.Mode
+}
+
+// Validate checks the mode and the allowed hosts.
+func (p *NetworkPolicy) Validate() error {
+	if p == nil {
+		return nil
+	}
+	switch p.EffectiveMode() {
+	case NetworkFull, NetworkNone, NetworkLoopback:
+		if len(p.Allow) > 0 {
+			return fmt.Errorf("network allow list needs policy %q", NetworkAllow)
+		}
+	case NetworkAllow:
+		if len(p.Allow) == 0 {
+			return fmt.Errorf("network policy %q needs an allow list", NetworkAllow)
+		}
+		for _, host := range p.Allow {
+			if host == "" || strings.ContainsAny(host, "/ ") {
+				return fmt.Errorf("invalid allowed host %q", host)
+			}
+		}
+	default:
+		return fmt.Errorf("invalid network policy %q (use full, none, loopback or allow)", p.Mode)
+	}
+	return nil
+}
+
+// SeccompProfile names a built-in list of system calls a server may not
+// make.
+type SeccompProfile string
+
+const (
+	// SeccompDefault denies system calls servers have no business making,
+	// such as loading kernel modules, rebooting, mounting and tracing
+	// other processes.
+	SeccompDefault SeccompProfile = "default"
+	// SeccompStrict also denies creating namespaces, device nodes and
+	// io_uring instances, and other rarely needed calls.
+	SeccompStrict SeccompProfile = "strict"
+	// SeccompNode is SeccompStrict but allows io_uring, which libuv uses
+	// for file I/O.
+	SeccompNode SeccompProfile = "node-compatible"
+	// SeccompPython is SeccompStrict but allows the NUMA memory policy
+	// calls made by NumPy and other native extensions.
+	SeccompPython SeccompProfile = "python-compatible"
+)
+
+var (
+	defaultDenied = []string{
+		"kexec_load", "kexec_file_load", "init_module", "finit_module",
+		"delete_module", "reboot", "swapon", "swapoff", "mount", "umount2",
+		"pivot_root", "fsopen", "fsmount", "fsconfig", "fspick",
+		"move_mount", "open_tree", "mount_setattr", "acct",
+		"settimeofday", "clock_settime", "clock_adjtime", "adjtimex",
+		"iopl", "ioperm", "ptrace", "process_vm_readv",
+		"process_vm_writev", "bpf", "perf_event_open", "userfaultfd",
+		"keyctl", "add_key", "request_key", "open_by_handle_at",
+		"name_to_handle_at", "quotactl", "lookup_dcookie", "syslog",
+		"uselib",
+	}
+	ioUringCalls = []string{"io_uring_setup", "io_uring_enter", "io_uring_register"}
+	numaCalls    = []string{"mbind", "set_mempolicy", "migrate_pages", "move_pages"}
+	strictDenied = concat(defaultDenied, ioUringCalls, numaCalls, []string{
+		"unshare", "setns", "mknod", "mknodat", "chroot", "personality",
+		"fanotify_init", "vhangup",
+	})
+)
+
+// seccompProfiles are the system calls each profile denies.
+var seccompProfiles = map[SeccompProfile][]string{
+	SeccompDefault: defaultDenied,
+	SeccompStrict:  strictDenied,
+	SeccompNode:    without(strictDenied, ioUringCalls),
+	SeccompPython:  without(strictDenied, numaCalls),
+}
+
+// helperCalls are made by the sandbox between installing the filter and
+// executing the server, so they cannot be denied.
+var helperCalls = []string{
+	"execve", "sendmsg", "close", "exit", "exit_group", "futex",
+	"rt_sigreturn", "rt_sigprocmask", "sched_yield", "nanosleep", "write",
+}
+
+var syscallName = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// SeccompPolicy is the system calls a server may not make, declared in its
+// manifest and replaced as a whole in config.yaml. A denied call fails
+// with EPERM and is reported in the server's log.
+type SeccompPolicy struct {
+	// Profile is a built-in list of denied calls. Without one, only Deny
+	// applies.
+	Profile SeccompProfile `yaml:"profile,omitempty"`
+
+	// Deny lists more system calls to deny, by name, such as "socket".
+	Deny []string `yaml:"deny,omitempty"`
+}
+
+// Denied returns the system calls the policy denies.
+func (p *SeccompPolicy) Denied() []string {
+	if p == nil {
+		return nil
+	}
+	return concat(seccompProfiles[p.Profile], p.Deny)
+}
+
+// Validate checks the profile and the names of the denied calls.
+func (p *SeccompPolicy) Validate() error {
+	if p == nil {
+		return nil
+	}
+	if _, ok := seccompProfiles[p.Profile]; p.Profile != "" && !ok {
+		return fmt.Errorf("invalid seccomp profile %q (use default, strict, node-compatible or python-compatible)", p.Profile)
+	}
+	for _, name := range p.Deny {
+		if !syscallName.MatchString(name) {
+			return fmt.Errorf("invalid system call name %q", name)
+		}
+		if slices.Contains(helperCalls, name) {
+			return fmt.Errorf("system call %q is needed to start the server and cannot be denied", name)
+		}
+	}
+	return nil
+}
+
+// concat joins lists into a new one.
+func concat(lists ...[]string) []string {
+	var joined []string
+	for _, list := range lists {
+		joined = append(joined, list...)
+	}
+	return joined
+}
+
+// without returns list less the names in remove.
+func without(list, remove []string) []string {
+	var kept []string
+	for _, name := range list {
+		if !slices.Contains(remove, name) {
+			kept = append(kept, name)
+		}
+	}
+	return kept
+}
+
+// Options describes the sandbox of one server process.
+type Options struct {
+	// Policy is the server's policy. Files are not restricted unless it
+	// enables the sandbox.
+	Policy *Policy
+
+	// InstallDir is the server's install directory, which it may read.
+	InstallDir string
+
+	// Executables are the programs the server runs as, such as its
+	// runtime and entrypoint. The installation each one belongs to may be
+	// read, following symlinks and script interpreters.
+	Executables []string
+
+	// Network restricts the server's network access.
+	Network *NetworkPolicy
+
+	// RunDir is where sockets for the sandbox are created. It must not be
+	// under /tmp, which is private to the sandbox.
+	RunDir string
+
+	// Seccomp denies system calls to the server.
+	Seccomp *SeccompPolicy
+
+	// Rlimits are resource limits set on the server before it runs.
+	Rlimits []Rlimit
+
+	// OnViolation, if set, is called with a description of each access
+	// the sandbox blocks and sees, such as connections refused by the
+	// egress proxy or denied system calls. source is "network" or
+	// "seccomp".
+	OnViolation func(source, msg string)
+}
+
+// Rlimit is a resource limit, such as syscall.RLIMIT_NOFILE, set as both
+// the soft and the hard limit.
+type Rlimit struct {
+	Resource int    `json:"resource"`
+	Limit    uint64 `json:"limit"`
+}
+
+// Sandbox is what Apply set up for one process.
+type Sandbox struct {
+	// Warnings describe protections the system could not provide.
+	Warnings []string
+
+	closers []io.Closer
+}
+
+// Close releases what the sandbox holds, such as its egress proxy, once the
+// process has exited.
+func (s *Sandbox) Close() error {
+	if s == nil {
+		return nil
+	}
+	var first error
+	for _, c := range s.closers {
+		if err := c.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	s.closers = nil
+	return first
+}
+
+// warn records a protection the sandbox lacks.
+func (s *Sandbox) warn(format string, args ...any) {
+	s.Warnings = append(s.Warnings, fmt.Sprintf(format, args...))
+}
+
+// Support describes the sandboxing the system provides.
+type Support struct {
+	// Namespaces is why servers cannot run in their own user, mount and
+	// PID namespaces, or nil if they can.
+	Namespaces error
+
+	// Landlock is why Landlock cannot restrict file access, or nil if it
+	// can.
+	Landlock error
+
+	// LandlockABI is the kernel's Landlock ABI version.
+	LandlockABI int
+
+	// Seccomp is why seccomp cannot filter system calls, or nil if it can.
+	Seccomp error
+
+	// SeccompNotify is why denied system calls cannot be reported, or nil
+	// if they can.
+	SeccompNotify error
+}
+
+// expandPath expands a leading ~ and environment variables in a granted
+// path.
+func expandPath(path string) (string, error) {
+	path = os.ExpandEnv(path)
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, path[1:])
+	}
+	if !filepath.IsAbs(path) {
+		return "", fmt.Errorf("sandbox path %q is not absolute", path)
+	}
+	return filepath.Clean(path), nil
+}
@@ -0,0 +1,468 @@
+package sandbox
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// A sandboxed server is started by running this binary again as a helper.
+// With namespaces the first stage sets up mounts and the network inside
+// them and stays on as the namespace's init process; the second stage
+// restricts itself and then executes the server. The helper recognises its
+// stage by argv[0].
+const (
+	probeArg = "mcp-adapter-sandbox-probe"
+	initArg  = "mcp-adapter-sandbox-init"
+	execArg  = "mcp-adapter-sandbox-exec"
+)
+
+// specEnv passes the sandbox spec to the helper. It is removed before the
+// server runs.
+const specEnv = "MCP_ADAPTER_SANDBOX"
+
+// selfExe runs the current binary even if it has been replaced on disk.
+const selfExe = "/proc/self/exe"
+
+// Capabilities the helper keeps inside its namespaces, to mount and to
+// bring up the loopback interface.
+const (
+	capNetAdmin = 12
+	capSysAdmin = 21
+)
+
+const (
+	prCapAmbient         = 47
+	prCapAmbientClearAll = 4
+)
+
+// systemPaths may be read by every sandboxed server, for shared libraries,
+// runtimes and configuration such as TLS certificates. Missing paths are
+// skipped.
+var systemPaths = []string{
+	"/bin", "/sbin", "/usr", "/lib", "/lib32", "/lib64", "/etc", "/opt",
+	"/proc", "/sys", "/nix/store", "/run/systemd/resolve",
+}
+
+// writablePaths may be written by every sandboxed server. /tmp is private
+// to the server when it runs in its own mount namespace.
+var writablePaths = []string{
+	"/tmp", "/dev/null", "/dev/zero", "/dev/full", "/dev/random",
+	"/dev/urandom", "/dev/tty", "/dev/pts", "/dev/ptmx", "/dev/shm",
+}
+
+// spec tells the helper what to set up and what to run.
+type spec struct {
+	Path string   `json:"path"`
+	Args []string `json:"args"`
+
+	// Network is the mode of the server's own network namespace, if it
+	// has one.
+	Network NetworkMode `json:"network,omitempty"`
+
+	// EgressSocket is where the egress proxy listens, for NetworkAllow.
+	EgressSocket string `json:"egressSocket,omitempty"`
+
+	Landlock *landlockRules `json:"landlock,omitempty"`
+	Seccomp  *seccompRules  `json:"seccomp,omitempty"`
+	Rlimits  []Rlimit       `json:"rlimits,omitempty"`
+}
+
+var (
+	checkOnce sync.Once
+	support   Support
+)
+
+// Check reports the sandboxing the system provides. The result is cached.
+func Check() Support {
+	checkOnce.Do(func() {
+		support.Namespaces = probeNamespaces()
+		support.LandlockABI, support.Landlock = landlockABI()
+		support.Seccomp, support.SeccompNotify = seccompSupport()
+	})
+	return support
+}
+
+// Apply rewrites cmd to run inside the sandbox if opts.Policy enables it,
+// opts.Network restricts the network or opts.Seccomp denies system calls,
+// and through the sandbox helper if it sets rlimits. Protections the
+// system does not support are left out, and each one is described in the
+// sandbox's warnings. The sandbox must be closed once the process has
+// exited.
+func Apply(cmd *exec.Cmd, opts *Options) (*Sandbox, error) {
+	restrictFiles := opts.Policy.IsEnabled()
+	network := opts.Network.EffectiveMode()
+	sb := &Sandbox{}
+	if !restrictFiles && network == NetworkFull && len(opts.Seccomp.Denied()) == 0 && len(opts.Rlimits) == 0 {
+		return sb, nil
+	}
+
+	sys := Check()
+	s := &spec{Path: cmd.Path, Args: cmd.Args, Rlimits: opts.Rlimits}
+
+	if restrictFiles {
+		if sys.Namespaces != nil {
+			sb.warn("namespaces unavailable (%v): the server shares /proc and /tmp with the system", sys.Namespaces)
+		}
+		if sys.Landlock != nil {
+			sb.warn("Landlock unavailable (%v): file access is not restricted", sys.Landlock)
+		} else {
+			rules, missing, err := fileRules(opts)
+			if err != nil {
+				return nil, err
+			}
+			for _, path := range missing {
+				sb.warn("granted path %s does not exist", path)
+			}
+			rules.ABI = sys.LandlockABI
+			s.Landlock = rules
+		}
+	}
+
+	if network != NetworkFull {
+		if sys.Namespaces != nil {
+			sb.warn("namespaces unavailable (%v): network policy %q is not enforced", sys.Namespaces, network)
+		} else {
+			s.Network = network
+		}
+	}
+
+	seccomp, err := applySeccomp(sb, cmd, opts, sys)
+	if err != nil {
+		return nil, err
+	}
+	s.Seccomp = seccomp
+
+	if sys.Namespaces != nil && s.Landlock == nil && s.Seccomp == nil && len(s.Rlimits) == 0 {
+		sb.warn("running the server without a sandbox")
+		return sb, nil
+	}
+
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	// With loopback only, the proxy allows nothing but still logs what
+	// the server tries to reach.
+	if s.Network == NetworkAllow || s.Network == NetworkLoopback {
+		socket, err := startEgressProxy(sb, opts)
+		if err != nil {
+			sb.Close()
+			return nil, err
+		}
+		s.EgressSocket = socket
+		cmd.Env = append(cmd.Env, proxyEnv()...)
+	}
+
+	encoded, err := json.Marshal(s)
+	if err != nil {
+		sb.Close()
+		return nil, err
+	}
+	cmd.Env = append(cmd.Env, specEnv+"="+string(encoded))
+	cmd.Path = selfExe
+	// A seccomp filter alone needs no namespaces.
+	if sys.Namespaces == nil && (restrictFiles || s.Network != "") {
+		cmd.Args = []string{initArg}
+		cmd.SysProcAttr = namespaceAttr(s.Network != "")
+	} else {
+		cmd.Args = []string{execArg}
+	}
+	return sb, nil
+}
+
+// Init runs the sandbox helper if the process was started as one, and
+// exits when it is done; otherwise it returns at once. Programs that
+// launch sandboxed servers must call it first thing in main.
+func Init() {
+	var run func(*spec) error
+	switch os.Args[0] {
+	case probeArg:
+		if err := setupMounts(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if err := loopbackUp(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case initArg:
+		run = runInit
+	case execArg:
+		run = runExec
+	default:
+		return
+	}
+
+	var s spec
+	if err := json.Unmarshal([]byte(os.Getenv(specEnv)), &s); err != nil {
+		fmt.Fprintf(os.Stderr, "mcp-adapter sandbox: invalid spec: %v\n", err)
+		os.Exit(126)
+	}
+	if err := run(&s); err != nil {
+		fmt.Fprintf(os.Stderr, "mcp-adapter sandbox: %v\n", err)
+		os.Exit(126)
+	}
+	os.Exit(0)
+}
+
+// namespaceAttr starts a process in new user, mount and PID namespaces,
+// and a network namespace if asked, as the same user, keeping the
+// capabilities to set them up.
+func namespaceAttr(network bool) *syscall.SysProcAttr {
+	uid, gid := os.Getuid(), os.Getgid()
+	attr := &syscall.SysProcAttr{
+		Cloneflags:  syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWPID,
+		UidMappings: []syscall.SysProcIDMap{{ContainerID: uid, HostID: uid, Size: 1}},
+		GidMappings: []syscall.SysProcIDMap{{ContainerID: gid, HostID: gid, Size: 1}},
+		AmbientCaps: []uintptr{capSysAdmin, capNetAdmin},
+	}
+	if network {
+		attr.Cloneflags |= syscall.CLONE_NEWNET
+	}
+	return attr
+}
+
+// probeNamespaces checks that a helper can be started in new namespaces
+// and set up its mounts and network there.
+func probeNamespaces() error {
+	cmd := exec.Command(selfExe)
+	cmd.Args = []string{probeArg}
+	cmd.SysProcAttr = namespaceAttr(true)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return errors.New(msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// setupMounts gives the namespace its own /proc, showing only its
+// processes, and an empty /tmp.
+func setupMounts() error {
+	if err := syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("failed to make mounts private: %w", err)
+	}
+	if err := syscall.Mount("proc", "/proc", "proc", syscall.MS_NOSUID|syscall.MS_NODEV|syscall.MS_NOEXEC, ""); err != nil {
+		return fmt.Errorf("failed to mount /proc: %w", err)
+	}
+	if err := syscall.Mount("tmpfs", "/tmp", "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV, "mode=1777"); err != nil {
+		return fmt.Errorf("failed to mount /tmp: %w", err)
+	}
+	return nil
+}
+
+// runInit is the first stage, the init process of the new PID namespace.
+// It sets up the namespaces, starts the second stage, forwards signals to
+// it and reaps orphans until it exits, then exits the same way.
+func runInit(s *spec) error {
+	if err := setupMounts(); err != nil {
+		return err
+	}
+	if s.Network == NetworkLoopback || s.Network == NetworkAllow {
+		if err := loopbackUp(); err != nil {
+			return err
+		}
+	}
+	if s.EgressSocket != "" {
+		if err := relayEgress(s.EgressSocket); err != nil {
+			return err
+		}
+	}
+
+	signals := make(chan os.Signal, 16)
+	signal.Notify(signals)
+
+	files := []*os.File{os.Stdin, os.Stdout, os.Stderr}
+	if s.Seccomp != nil && s.Seccomp.Notify {
+		// The seccomp socket keeps its descriptor.
+		for len(files) < s.Seccomp.FD {
+			files = append(files, nil)
+		}
+		files = append(files, os.NewFile(uintptr(s.Seccomp.FD), "seccomp"))
+	}
+	proc, err := os.StartProcess(selfExe, []string{execArg}, &os.ProcAttr{Files: files})
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for sig := range signals {
+			// SIGURG is the Go runtime's own.
+			if sig == syscall.SIGCHLD || sig == syscall.SIGURG {
+				continue
+			}
+			proc.Signal(sig)
+		}
+	}()
+
+	for {
+		var status syscall.WaitStatus
+		pid, err := syscall.Wait4(-1, &status, 0, nil)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if pid != proc.Pid {
+			continue
+		}
+		if status.Signaled() {
+			os.Exit(128 + int(status.Signal()))
+		}
+		os.Exit(status.ExitStatus())
+	}
+}
+
+// runExec is the last stage. It restricts the thread it runs on, which
+// the server inherits, and executes the server.
+func runExec(s *spec) error {
+	runtime.LockOSThread()
+
+	// Capabilities needed for mounting are not passed on.
+	syscall.Syscall6(syscall.SYS_PRCTL, prCapAmbient, prCapAmbientClearAll, 0, 0, 0, 0)
+
+	for _, r := range s.Rlimits {
+		if err := syscall.Setrlimit(r.Resource, &syscall.Rlimit{Cur: r.Limit, Max: r.Limit}); err != nil {
+			return fmt.Errorf("failed to set resource limit %d to %d: %w", r.Resource, r.Limit, err)
+		}
+	}
+
+	if s.Landlock != nil {
+		if err := s.Landlock.restrict(); err != nil {
+			return err
+		}
+	}
+	// The filter comes last, so that it cannot deny the calls above.
+	if s.Seccomp != nil {
+		listener, err := s.Seccomp.install()
+		if err != nil {
+			return err
+		}
+		if s.Seccomp.Notify {
+			if err := s.Seccomp.handOver(listener); err != nil {
+				return err
+			}
+		}
+	}
+
+	var env []string
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, specEnv+"=") {
+			env = append(env, kv)
+		}
+	}
+	if err := syscall.Exec(s.Path, s.Args, env); err != nil {
+		return fmt.Errorf("failed to run %s: %w", s.Path, err)
+	}
+	return nil
+}
+
+// fileRules works out the paths a server may read and write, returning
+// the granted paths that do not exist.
+func fileRules(opts *Options) (*landlockRules, []string, error) {
+	rules := &landlockRules{
+		ReadOnly:  append([]string{}, systemPaths...),
+		ReadWrite: append([]string{}, writablePaths...),
+	}
+	if opts.InstallDir != "" {
+		rules.ReadOnly = append(rules.ReadOnly, opts.InstallDir)
+	}
+	for _, exe := range opts.Executables {
+		rules.ReadOnly = append(rules.ReadOnly, installations(exe)...)
+	}
+
+	var missing []string
+	grant := func(paths []string, to *[]string) error {
+		for _, path := range paths {
+			expanded, err := expandPath(path)
+			if err != nil {
+				return err
+			}
+			if _, err := os.Stat(expanded); err != nil {
+				missing = append(missing, expanded)
+				continue
+			}
+			*to = append(*to, expanded)
+		}
+		return nil
+	}
+	if err := grant(opts.Policy.ReadOnly, &rules.ReadOnly); err != nil {
+		return nil, nil, err
+	}
+	if err := grant(opts.Policy.ReadWrite, &rules.ReadWrite); err != nil {
+		return nil, nil, err
+	}
+	return rules, missing, nil
+}
+
+// installations returns the directories a program is installed in: the
+// prefix of its resolved path, and that of its interpreter if it is a
+// script.
+func installations(program string) []string {
+	var dirs []string
+	for i := 0; i < 3 && program != ""; i++ {
+		resolved, err := filepath.EvalSymlinks(program)
+		if err != nil {
+			break
+		}
+		dirs = append(dirs, installPrefix(resolved))
+		program = interpreter(resolved)
+	}
+	return dirs
+}
+
+// installPrefix returns the directory a program is installed under, such
+// as /opt/node for /opt/node/bin/node. A program directly in / or the home
+// directory is granted on its own.
+func installPrefix(program string) string {
+	dir := filepath.Dir(program)
+	switch filepath.Base(dir) {
+	case "bin", "sbin", "shims":
+		dir = filepath.Dir(dir)
+	}
+	home, _ := os.UserHomeDir()
+	if dir == "/" || dir == home {
+		return program
+	}
+	return dir
+}
+
+// interpreter returns the program named by a script's #! line, looking up
+// the command run through env.
+func interpreter(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	line, _ := bufio.NewReader(f).ReadString('\n')
+	if !strings.HasPrefix(line, "#!") {
+		return ""
+	}
+	fields := strings.Fields(line[2:])
+	if len(fields) == 0 {
+		return ""
+	}
+	if filepath.Base(fields[0]) == "env" && len(fields) > 1 {
+		found, err := exec.LookPath(fields[1])
+		if err != nil {
+			return ""
+		}
+		return found
+	}
+	return fields[0]
+}
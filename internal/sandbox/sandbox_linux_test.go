@@ -0,0 +1,223 @@
+package sandbox
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestApplyRestrictsFiles(t *testing.T) {
+	if sys := Check(); sys.Landlock != nil {
+		t.Skipf("Landlock unavailable: %v", sys.Landlock)
+	}
+
+	// /tmp is private inside the sandbox, so files are kept elsewhere.
+	granted := varTempDir(t)
+	hidden := varTempDir(t)
+	os.WriteFile(filepath.Join(granted, "ok"), []byte("granted"), 0600)
+	os.WriteFile(filepath.Join(hidden, "secret"), []byte("secret"), 0600)
+
+	on := true
+	run := func(script string) (string, error) {
+		cmd := exec.Command("/bin/sh", "-c", script)
+		sb, err := Apply(cmd, &Options{
+			Policy:      &Policy{Enabled: &on, ReadWrite: []string{granted}},
+			Executables: []string{"/bin/sh"},
+		})
+		if err != nil {
+			t.Fatalf("Apply() error = %v", err)
+		}
+		for _, w := range sb.Warnings {
+			t.Log(w)
+		}
+		out, err := cmd.CombinedOutput()
+		return strings.TrimSpace(string(out)), err
+	}
+
+	if out, err := run("cat " + filepath.Join(granted, "ok")); err != nil || out != "granted" {
+		t.Errorf("reading a granted file: %q, %v", out, err)
+	}
+	if out, err := run("echo new > " + filepath.Join(granted, "new")); err != nil {
+		t.Errorf("writing to a granted path: %q, %v", out, err)
+	}
+	if out, err := run("cat " + filepath.Join(hidden, "secret")); err == nil || out == "secret" {
+		t.Errorf("reading a file outside the sandbox: %q, %v", out, err)
+	}
+	if out, err := run("echo x > " + filepath.Join(hidden, "new")); err == nil {
+		t.Errorf("writing outside the sandbox: %q", out)
+	}
+}
+
+func TestApplyUsesNamespaces(t *testing.T) {
+	sys := Check()
+	if sys.Namespaces != nil {
+		t.Skipf("namespaces unavailable: %v", sys.Namespaces)
+	}
+
+	marker, err := os.CreateTemp("/tmp", "sandbox-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	marker.Close()
+	defer os.Remove(marker.Name())
+
+	on := true
+	cmd := exec.Command("/bin/sh", "-c", "tr '\\0' ' ' < /proc/1/cmdline; echo; ls /tmp")
+	if _, err := Apply(cmd, &Options{Policy: &Policy{Enabled: &on}, Executables: []string{"/bin/sh"}}); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("sandboxed command failed: %v: %s", err, out)
+	}
+
+	// The helper is the init process of the server's PID namespace.
+	if init, _, _ := strings.Cut(string(out), "\n"); strings.TrimSpace(init) != initArg {
+		t.Errorf("PID 1 in the sandbox is %q, want the helper", init)
+	}
+	if strings.Contains(string(out), filepath.Base(marker.Name())) {
+		t.Errorf("sandboxed /tmp shows the host's files: %s", out)
+	}
+}
+
+func TestApplyNetworkPolicy(t *testing.T) {
+	if sys := Check(); sys.Namespaces != nil {
+		t.Skipf("namespaces unavailable: %v", sys.Namespaces)
+	}
+	curl, err := exec.LookPath("curl")
+	if err != nil {
+		t.Skip("curl not found")
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "reached")
+	}))
+	defer srv.Close()
+
+	run := func(network *NetworkPolicy, args ...string) (string, []string) {
+		var violations []string
+		cmd := exec.Command(curl, append([]string{"-sS", "-m", "5"}, args...)...)
+		sb, err := Apply(cmd, &Options{
+			Network: network,
+			RunDir:  varTempDir(t),
+			OnViolation: func(source, msg string) {
+				violations = append(violations, source+": "+msg)
+			},
+		})
+		if err != nil {
+			t.Fatalf("Apply() error = %v", err)
+		}
+		out, _ := cmd.CombinedOutput()
+		sb.Close()
+		return strings.TrimSpace(string(out)), violations
+	}
+
+	if out, _ := run(&NetworkPolicy{Mode: NetworkNone}, srv.URL); out == "reached" {
+		t.Errorf("policy none reached the host")
+	}
+	if out, _ := run(&NetworkPolicy{Mode: NetworkLoopback}, "--noproxy", "*", srv.URL); out == "reached" {
+		t.Errorf("policy loopback reached the host's loopback")
+	}
+
+	// curl skips the proxy for 127.0.0.1 unless told otherwise.
+	host := strings.TrimPrefix(srv.URL, "http://")
+	if out, _ := run(&NetworkPolicy{Mode: NetworkAllow, Allow: []string{host}}, "--noproxy", "", srv.URL); out != "reached" {
+		t.Errorf("allowed host through the proxy: %q", out)
+	}
+	out, violations := run(&NetworkPolicy{Mode: NetworkAllow, Allow: []string{"example.com"}}, "--noproxy", "", srv.URL)
+	if out == "reached" {
+		t.Errorf("policy allow reached a host not in the list")
+	}
+	if len(violations) != 1 || !strings.Contains(violations[0], host) {
+		t.Errorf("violations = %v, want the blocked connection to %s", violations, host)
+	}
+}
+
+func TestApplySeccomp(t *testing.T) {
+	sys := Check()
+	if sys.Seccomp != nil {
+		t.Skipf("seccomp unavailable: %v", sys.Seccomp)
+	}
+
+	var violations []string
+	cmd := exec.Command("/bin/sh", "-c", "uname; echo exit $?")
+	sb, err := Apply(cmd, &Options{
+		Seccomp: &SeccompPolicy{Profile: SeccompDefault, Deny: []string{"uname"}},
+		OnViolation: func(source, msg string) {
+			violations = append(violations, source+": "+msg)
+		},
+	})
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if cmd.SysProcAttr != nil {
+		t.Errorf("a seccomp filter alone put the server in namespaces")
+	}
+	out, err := cmd.CombinedOutput()
+	sb.Close()
+	if err != nil {
+		t.Fatalf("sandboxed command failed: %v: %s", err, out)
+	}
+	if !strings.Contains(string(out), "Operation not permitted") || strings.Contains(string(out), "exit 0") {
+		t.Errorf("denied call succeeded: %s", out)
+	}
+
+	if sys.SeccompNotify != nil {
+		return
+	}
+	if len(violations) != 1 || !strings.Contains(violations[0], "seccomp: denied system call uname") {
+		t.Errorf("violations = %v, want the denied uname", violations)
+	}
+}
+
+func TestApplyRlimits(t *testing.T) {
+	cmd := exec.Command("/bin/sh", "-c", "ulimit -n")
+	if _, err := Apply(cmd, &Options{Rlimits: []Rlimit{{Resource: syscall.RLIMIT_NOFILE, Limit: 100}}}); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("limited command failed: %v: %s", err, out)
+	}
+	if got := strings.TrimSpace(string(out)); got != "100" {
+		t.Errorf("open file limit = %s, want 100", got)
+	}
+}
+
+func TestSeccompProfileNames(t *testing.T) {
+	if runtime.GOARCH != "amd64" {
+		t.Skip("some calls in the profiles only exist on amd64")
+	}
+	for profile, names := range seccompProfiles {
+		for _, name := range names {
+			if _, ok := syscallNumbers[name]; !ok {
+				t.Errorf("profile %s denies unknown system call %s", profile, name)
+			}
+		}
+	}
+}
+
+func TestApplyDisabled(t *testing.T) {
+	cmd := exec.Command("/bin/true")
+	sb, err := Apply(cmd, &Options{})
+	if err != nil || len(sb.Warnings) > 0 || cmd.Path != "/bin/true" {
+		t.Errorf("Apply() without a policy changed the command: %v, %v, %s", sb, err, cmd.Path)
+	}
+}
+
+// varTempDir creates a directory in /var/tmp that is removed after the test.
+func varTempDir(t *testing.T) string {
+	dir, err := os.MkdirTemp("/var/tmp", "sandbox-test")
+	if err != nil {
+		t.Skipf("cannot create a directory outside /tmp: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
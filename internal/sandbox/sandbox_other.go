@@ -0,0 +1,36 @@
+//go:build !linux
+
+package sandbox
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+var errUnsupported = fmt.Errorf("not supported on %s", runtime.GOOS)
+
+// Check reports the sandboxing the system provides: none, off Linux.
+func Check() Support {
+	return Support{
+		Namespaces:    errUnsupported,
+		Landlock:      errUnsupported,
+		Seccomp:       errUnsupported,
+		SeccompNotify: errUnsupported,
+	}
+}
+
+// Apply leaves cmd alone, warning if opts asks for a sandbox.
+func Apply(cmd *exec.Cmd, opts *Options) (*Sandbox, error) {
+	sb := &Sandbox{}
+	if opts.Policy.IsEnabled() || opts.Network.EffectiveMode() != NetworkFull || len(opts.Seccomp.Denied()) > 0 {
+		sb.warn("sandboxing is %v: running the server without a sandbox", errUnsupported)
+	}
+	if len(opts.Rlimits) > 0 {
+		sb.warn("resource limits are %v", errUnsupported)
+	}
+	return sb, nil
+}
+
+// Init returns at once; there is no sandbox helper off Linux.
+func Init() {}
@@ -0,0 +1,122 @@
+package sandbox
+
+import (
+	"os"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// Sandboxed commands in the tests run this binary as the helper.
+	Init()
+	os.Exit(m.Run())
+}
+
+func TestPolicyMerge(t *testing.T) {
+	on, off := true, false
+	manifest := &Policy{Enabled: &on, ReadOnly: []string{"/data"}}
+
+	tests := []struct {
+		name        string
+		base        *Policy
+		override    *Policy
+		wantEnabled bool
+		wantRO      []string
+	}{
+		{"no override", manifest, nil, true, []string{"/data"}},
+		{"no manifest policy", nil, &Policy{Enabled: &on}, true, nil},
+		{"override disables", manifest, &Policy{Enabled: &off}, false, []string{"/data"}},
+		{"override adds paths", manifest, &Policy{ReadOnly: []string{"~/notes"}}, true, []string{"/data", "~/notes"}},
+		{"neither", nil, nil, false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.base.Merge(tt.override)
+			if got.IsEnabled() != tt.wantEnabled {
+				t.Errorf("IsEnabled() = %v, want %v", got.IsEnabled(), tt.wantEnabled)
+			}
+			if got != nil && !reflect.DeepEqual(got.ReadOnly, tt.wantRO) {
+				t.Errorf("ReadOnly = %v, want %v", got.ReadOnly, tt.wantRO)
+			}
+		})
+	}
+	if len(manifest.ReadOnly) != 1 {
+		t.Errorf("Merge() modified the base policy: %v", manifest.ReadOnly)
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		paths   []string
+		wantErr bool
+	}{
+		{"absolute", []string{"/srv/data"}, false},
+		{"home", []string{"~/projects"}, false},
+		{"environment variable", []string{"$XDG_DATA_HOME/app"}, false},
+		{"relative", []string{"data"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&Policy{ReadWrite: tt.paths}).Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSeccompPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  SeccompPolicy
+		wantErr bool
+	}{
+		{"profile", SeccompPolicy{Profile: SeccompStrict}, false},
+		{"deny list only", SeccompPolicy{Deny: []string{"socket", "connect"}}, false},
+		{"unknown profile", SeccompPolicy{Profile: "paranoid"}, true},
+		{"invalid name", SeccompPolicy{Deny: []string{"Socket"}}, true},
+		{"needed to start", SeccompPolicy{Deny: []string{"execve"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSeccompProfiles(t *testing.T) {
+	denies := func(profile SeccompProfile, name string) bool {
+		return slices.Contains((&SeccompPolicy{Profile: profile}).Denied(), name)
+	}
+
+	for _, profile := range []SeccompProfile{SeccompDefault, SeccompStrict, SeccompNode, SeccompPython} {
+		if !denies(profile, "kexec_load") || !denies(profile, "ptrace") {
+			t.Errorf("profile %s does not deny the default calls", profile)
+		}
+		for _, name := range helperCalls {
+			if denies(profile, name) {
+				t.Errorf("profile %s denies %s, which starting the server needs", profile, name)
+			}
+		}
+	}
+	if denies(SeccompDefault, "unshare") || !denies(SeccompStrict, "unshare") {
+		t.Errorf("only the strict profiles should deny unshare")
+	}
+	if denies(SeccompNode, "io_uring_setup") || !denies(SeccompNode, "mbind") {
+		t.Errorf("node-compatible should allow io_uring only")
+	}
+	if denies(SeccompPython, "mbind") || !denies(SeccompPython, "io_uring_setup") {
+		t.Errorf("python-compatible should allow the NUMA calls only")
+	}
+	if got := (&SeccompPolicy{Profile: SeccompDefault, Deny: []string{"socket"}}).Denied(); !slices.Contains(got, "socket") {
+		t.Errorf("Denied() = %v, missing the custom deny list", got)
+	}
+}
@@ -0,0 +1,351 @@
+package sandbox
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const (
+	seccompSetModeFilter         = 1
+	seccompGetActionAvail        = 2
+	seccompFilterFlagNewListener = 1 << 3
+
+	seccompRetAllow     = 0x7fff0000
+	seccompRetUserNotif = 0x7fc00000
+	seccompRetErrno     = 0x00050000
+
+	prGetSeccomp = 21
+
+	// ioctls on the listener: _IOWR('!', 0, struct seccomp_notif) and
+	// _IOWR('!', 1, struct seccomp_notif_resp).
+	seccompIoctlNotifRecv = 0xc0502100
+	seccompIoctlNotifSend = 0xc0182101
+)
+
+// Offsets of the fields of struct seccomp_data the filter looks at.
+const (
+	seccompDataNr   = 0
+	seccompDataArch = 4
+)
+
+const (
+	pollIn   = 0x1
+	pollErr  = 0x8
+	pollHup  = 0x10
+	pollNval = 0x20
+)
+
+// seccompPollInterval is how often the watcher checks that it is still
+// wanted while the server makes no denied calls.
+const seccompPollInterval = 250 * time.Millisecond
+
+// seccompRules is the filter the last stage of the helper installs.
+type seccompRules struct {
+	// Numbers are the denied system calls.
+	Numbers []uint32 `json:"numbers"`
+
+	// Notify hands denied calls to a watcher in the launching process,
+	// which fails and reports them, instead of failing them in the kernel.
+	Notify bool `json:"notify,omitempty"`
+
+	// FD is the socket the helper sends the filter's listener to, if
+	// Notify.
+	FD int `json:"fd,omitempty"`
+}
+
+// seccompSupport reports whether seccomp filters can be installed, and
+// whether their denials can be reported.
+func seccompSupport() (filter, notify error) {
+	if len(syscallNumbers) == 0 {
+		err := fmt.Errorf("not supported on linux/%s", runtime.GOARCH)
+		return err, err
+	}
+	if _, _, errno := syscall.Syscall6(syscall.SYS_PRCTL, prGetSeccomp, 0, 0, 0, 0, 0); errno != 0 {
+		err := fmt.Errorf("kernel does not support seccomp")
+		return err, err
+	}
+	if err := seccompActionAvail(seccompRetErrno); err != nil {
+		return err, err
+	}
+	return nil, seccompActionAvail(seccompRetUserNotif)
+}
+
+// seccompActionAvail checks that the kernel supports a filter action.
+func seccompActionAvail(action uint32) error {
+	_, _, errno := syscall.Syscall(sysSeccomp, seccompGetActionAvail, 0, uintptr(unsafe.Pointer(&action)))
+	switch errno {
+	case 0:
+		return nil
+	case syscall.ENOSYS:
+		return fmt.Errorf("kernel does not support seccomp filters")
+	case syscall.EOPNOTSUPP:
+		return fmt.Errorf("kernel does not support user notification")
+	default:
+		return errno
+	}
+}
+
+// applySeccomp works out the filter for opts.Seccomp, and starts a watcher
+// for its denials if the kernel can hand them over. It returns nil if
+// nothing is filtered.
+func applySeccomp(sb *Sandbox, cmd *exec.Cmd, opts *Options, sys Support) (*seccompRules, error) {
+	denied := opts.Seccomp.Denied()
+	if len(denied) == 0 {
+		return nil, nil
+	}
+	if sys.Seccomp != nil {
+		sb.warn("seccomp unavailable (%v): system calls are not filtered", sys.Seccomp)
+		return nil, nil
+	}
+
+	rules := &seccompRules{}
+	seen := make(map[uint32]bool)
+	for _, name := range denied {
+		nr, ok := syscallNumbers[name]
+		if !ok {
+			sb.warn("system call %s does not exist on linux/%s", name, runtime.GOARCH)
+			continue
+		}
+		if !seen[nr] {
+			seen[nr] = true
+			rules.Numbers = append(rules.Numbers, nr)
+		}
+	}
+	sort.Slice(rules.Numbers, func(i, j int) bool { return rules.Numbers[i] < rules.Numbers[j] })
+	// Each denied call is a jump to the end of the filter, which classic
+	// BPF limits to 255 instructions.
+	if len(rules.Numbers) > 250 {
+		return nil, fmt.Errorf("too many denied system calls (%d, at most 250)", len(rules.Numbers))
+	}
+
+	if sys.SeccompNotify != nil {
+		sb.warn("seccomp notification unavailable (%v): denied system calls are not reported", sys.SeccompNotify)
+		return rules, nil
+	}
+
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_SEQPACKET|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create seccomp socket: %w", err)
+	}
+	child := os.NewFile(uintptr(fds[1]), "seccomp")
+	parent := os.NewFile(uintptr(fds[0]), "seccomp")
+	conn, err := net.FileConn(parent)
+	parent.Close()
+	if err != nil {
+		child.Close()
+		return nil, fmt.Errorf("failed to create seccomp socket: %w", err)
+	}
+
+	var report func(string)
+	if opts.OnViolation != nil {
+		report = func(msg string) { opts.OnViolation("seccomp", msg) }
+	}
+	w := &seccompWatcher{
+		conn:   conn.(*net.UnixConn),
+		child:  child,
+		report: report,
+		done:   make(chan struct{}),
+	}
+	w.wg.Add(1)
+	go w.run()
+	sb.closers = append(sb.closers, w)
+
+	rules.Notify = true
+	rules.FD = 3 + len(cmd.ExtraFiles)
+	cmd.ExtraFiles = append(cmd.ExtraFiles, child)
+	return rules, nil
+}
+
+// program compiles the rules to classic BPF: calls of another architecture
+// fail, as do the denied calls, and everything else is allowed.
+func (r *seccompRules) program() []syscall.SockFilter {
+	deny := uint32(seccompRetErrno | uint32(syscall.EPERM))
+	if r.Notify {
+		deny = seccompRetUserNotif
+	}
+	stmt := func(code uint16, k uint32) syscall.SockFilter {
+		return syscall.SockFilter{Code: code, K: k}
+	}
+	jump := func(code uint16, k uint32, jt, jf uint8) syscall.SockFilter {
+		return syscall.SockFilter{Code: code, Jt: jt, Jf: jf, K: k}
+	}
+
+	prog := []syscall.SockFilter{
+		stmt(syscall.BPF_LD|syscall.BPF_W|syscall.BPF_ABS, seccompDataArch),
+		jump(syscall.BPF_JMP|syscall.BPF_JEQ|syscall.BPF_K, auditArch, 1, 0),
+		stmt(syscall.BPF_RET|syscall.BPF_K, seccompRetErrno|uint32(syscall.EPERM)),
+		stmt(syscall.BPF_LD|syscall.BPF_W|syscall.BPF_ABS, seccompDataNr),
+	}
+	if x32SyscallBit != 0 {
+		prog = append(prog,
+			jump(syscall.BPF_JMP|syscall.BPF_JGE|syscall.BPF_K, x32SyscallBit, 0, 1),
+			stmt(syscall.BPF_RET|syscall.BPF_K, seccompRetErrno|uint32(syscall.EPERM)),
+		)
+	}
+	n := len(r.Numbers)
+	for i, nr := range r.Numbers {
+		// Past the remaining comparisons and the allow.
+		prog = append(prog, jump(syscall.BPF_JMP|syscall.BPF_JEQ|syscall.BPF_K, nr, uint8(n-i), 0))
+	}
+	return append(prog,
+		stmt(syscall.BPF_RET|syscall.BPF_K, seccompRetAllow),
+		stmt(syscall.BPF_RET|syscall.BPF_K, deny),
+	)
+}
+
+// install filters the calling thread, and the programs it executes, and
+// returns the filter's listener if Notify.
+func (r *seccompRules) install() (int, error) {
+	prog := r.program()
+	fprog := syscall.SockFprog{Len: uint16(len(prog)), Filter: &prog[0]}
+
+	if _, _, errno := syscall.Syscall6(syscall.SYS_PRCTL, prSetNoNewPrivs, 1, 0, 0, 0, 0); errno != 0 {
+		return -1, fmt.Errorf("failed to set no_new_privs: %w", errno)
+	}
+	var flags uintptr
+	if r.Notify {
+		flags = seccompFilterFlagNewListener
+	}
+	listener, _, errno := syscall.Syscall(sysSeccomp, seccompSetModeFilter, flags, uintptr(unsafe.Pointer(&fprog)))
+	if errno != 0 {
+		return -1, fmt.Errorf("failed to install seccomp filter: %w", errno)
+	}
+	if !r.Notify {
+		return -1, nil
+	}
+	return int(listener), nil
+}
+
+// handOver sends the filter's listener to the watcher.
+func (r *seccompRules) handOver(listener int) error {
+	defer syscall.Close(listener)
+	defer syscall.Close(r.FD)
+	if err := syscall.Sendmsg(r.FD, []byte{0}, syscall.UnixRights(listener), nil, 0); err != nil {
+		return fmt.Errorf("failed to hand over seccomp listener: %w", err)
+	}
+	return nil
+}
+
+// seccompWatcher fails the system calls a server's filter hands over, and
+// reports each one.
+type seccompWatcher struct {
+	conn   *net.UnixConn
+	child  *os.File
+	report func(string)
+	done   chan struct{}
+	once   sync.Once
+	wg     sync.WaitGroup
+}
+
+// Close stops the watcher.
+func (w *seccompWatcher) Close() error {
+	w.once.Do(func() {
+		close(w.done)
+		w.child.Close()
+		w.conn.Close()
+	})
+	w.wg.Wait()
+	return nil
+}
+
+// run receives the listener from the helper, then answers notifications
+// until the server and its children have exited or the watcher is closed.
+func (w *seccompWatcher) run() {
+	defer w.wg.Done()
+
+	buf := make([]byte, 1)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	_, oobn, _, _, err := w.conn.ReadMsgUnix(buf, oob)
+	if err != nil {
+		return
+	}
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil || len(msgs) == 0 {
+		return
+	}
+	fds, err := syscall.ParseUnixRights(&msgs[0])
+	if err != nil || len(fds) == 0 {
+		return
+	}
+	listener := fds[0]
+	defer syscall.Close(listener)
+
+	for {
+		select {
+		case <-w.done:
+			return
+		default:
+		}
+
+		pfd := struct {
+			fd      int32
+			events  int16
+			revents int16
+		}{fd: int32(listener), events: pollIn}
+		ts := syscall.NsecToTimespec(int64(seccompPollInterval))
+		n, _, errno := syscall.Syscall6(syscall.SYS_PPOLL, uintptr(unsafe.Pointer(&pfd)), 1, uintptr(unsafe.Pointer(&ts)), 0, 0, 0)
+		if errno == syscall.EINTR || n == 0 {
+			continue
+		}
+		if errno != 0 {
+			return
+		}
+		if pfd.revents&pollIn != 0 {
+			w.answer(listener)
+			continue
+		}
+		if pfd.revents&(pollHup|pollErr|pollNval) != 0 {
+			// No process uses the filter any more.
+			return
+		}
+	}
+}
+
+// answer fails one denied call with EPERM and reports it.
+func (w *seccompWatcher) answer(listener int) {
+	// struct seccomp_notif, with its struct seccomp_data.
+	var notif struct {
+		id    uint64
+		pid   uint32
+		flags uint32
+		nr    int32
+		arch  uint32
+		ip    uint64
+		args  [6]uint64
+	}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(listener), seccompIoctlNotifRecv, uintptr(unsafe.Pointer(&notif))); errno != 0 {
+		// The caller may have been killed meanwhile.
+		return
+	}
+
+	// struct seccomp_notif_resp.
+	resp := struct {
+		id    uint64
+		val   int64
+		error int32
+		flags uint32
+	}{id: notif.id, error: -int32(syscall.EPERM)}
+	syscall.Syscall(syscall.SYS_IOCTL, uintptr(listener), seccompIoctlNotifSend, uintptr(unsafe.Pointer(&resp)))
+
+	if w.report != nil {
+		w.report(fmt.Sprintf("denied system call %s to process %d", syscallNameOf(uint32(notif.nr)), notif.pid))
+	}
+}
+
+// syscallNameOf returns the name of a system call number.
+func syscallNameOf(nr uint32) string {
+	for name, n := range syscallNumbers {
+		if n == nr {
+			return name
+		}
+	}
+	return fmt.Sprintf("#%d", nr)
+}
@@ -0,0 +1,13 @@
+//go:build linux && !amd64 && !arm64
+
+package sandbox
+
+// Seccomp filters are only built for amd64 and arm64; without system call
+// numbers Check reports seccomp as unsupported.
+const (
+	auditArch     = 0
+	x32SyscallBit = 0
+	sysSeccomp    = 0
+)
+
+var syscallNumbers map[string]uint32
@@ -0,0 +1,268 @@
+package supervisor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SocketName is the file name of the control socket in the base directory.
+const SocketName = "supervisor.sock"
+
+// ErrDaemonNotRunning is returned by Client when nothing is listening on
+// the control socket.
+var ErrDaemonNotRunning = errors.New("supervisor daemon is not running")
+
+// SocketPath returns the control socket path for a base directory.
+func SocketPath(baseDir string) string {
+	return filepath.Join(baseDir, SocketName)
+}
+
+// Listen listens on the control socket at path, which only the current user
+// can connect to. A stale socket left by a daemon that died is replaced; a
+// live one is an error.
+func Listen(path string) (net.Listener, error) {
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("a supervisor daemon is already listening on %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// Serve serves the control API on l until ctx is cancelled or a client asks
+// the daemon to shut down. Every server is stopped before it returns.
+func (s *Supervisor) Serve(ctx context.Context, l net.Listener) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	httpServer := &http.Server{
+		Handler:           s.handler(cancel),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errChan := make(chan error, 1)
+	go func() { errChan <- httpServer.Serve(l) }()
+
+	var err error
+	select {
+	case <-ctx.Done():
+	case err = <-errChan:
+	}
+
+	s.StopAll()
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+	httpServer.Shutdown(shutdownCtx)
+
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// handler routes the control API. shutdown asks Serve to return.
+func (s *Supervisor) handler(shutdown func()) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /servers", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, s.List())
+	})
+
+	mux.HandleFunc("POST /servers", func(w http.ResponseWriter, r *http.Request) {
+		var spec Spec
+		if err := json.NewDecoder(r.Body).Decode(&spec); err != nil || spec.Server == "" {
+			writeError(w, http.StatusBadRequest, errors.New("invalid server spec"))
+			return
+		}
+		status, err := s.Start(r.Context(), &spec)
+		writeResult(w, status, err)
+	})
+
+	mux.HandleFunc("GET /servers/{name}", func(w http.ResponseWriter, r *http.Request) {
+		status, err := s.Status(r.PathValue("name"))
+		writeResult(w, status, err)
+	})
+
+	mux.HandleFunc("POST /servers/{name}/stop", func(w http.ResponseWriter, r *http.Request) {
+		status, err := s.Stop(r.PathValue("name"))
+		writeResult(w, status, err)
+	})
+
+	mux.HandleFunc("POST /servers/{name}/restart", func(w http.ResponseWriter, r *http.Request) {
+		status, err := s.Restart(r.Context(), r.PathValue("name"))
+		writeResult(w, status, err)
+	})
+
+	mux.HandleFunc("POST /shutdown", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		shutdown()
+	})
+
+	return mux
+}
+
+// apiError is the body of an error response.
+type apiError struct {
+	Error  string  `json:"error"`
+	Status *Status `json:"status,omitempty"`
+}
+
+func writeResult(w http.ResponseWriter, status *Status, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, ErrAlreadyRunning):
+		writeError(w, http.StatusConflict, err)
+	case err != nil:
+		writeJSON(w, http.StatusInternalServerError, apiError{Error: err.Error(), Status: status})
+	default:
+		writeJSON(w, http.StatusOK, status)
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, apiError{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+// Client talks to a supervisor daemon over its control socket.
+type Client struct {
+	http *http.Client
+}
+
+// NewClient creates a client for the daemon listening on the socket at path.
+func NewClient(path string) *Client {
+	return &Client{
+		http: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, "unix", path)
+				},
+			},
+		},
+	}
+}
+
+// List returns the status of every supervised server.
+func (c *Client) List(ctx context.Context) ([]Status, error) {
+	var result []Status
+	if err := c.do(ctx, http.MethodGet, "/servers", nil, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Start asks the daemon to start a server.
+func (c *Client) Start(ctx context.Context, spec *Spec) (*Status, error) {
+	var status Status
+	if err := c.do(ctx, http.MethodPost, "/servers", spec, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Status returns the status of one server.
+func (c *Client) Status(ctx context.Context, name string) (*Status, error) {
+	var status Status
+	if err := c.do(ctx, http.MethodGet, "/servers/"+name, nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Stop asks the daemon to stop a server.
+func (c *Client) Stop(ctx context.Context, name string) (*Status, error) {
+	var status Status
+	if err := c.do(ctx, http.MethodPost, "/servers/"+name+"/stop", nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Restart asks the daemon to restart a server.
+func (c *Client) Restart(ctx context.Context, name string) (*Status, error) {
+	var status Status
+	if err := c.do(ctx, http.MethodPost, "/servers/"+name+"/restart", nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Shutdown asks the daemon to stop every server and exit.
+func (c *Client) Shutdown(ctx context.Context) error {
+	return c.do(ctx, http.MethodPost, "/shutdown", nil, nil)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, result interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, "http://supervisor"+path, reqBody)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			return ErrDaemonNotRunning
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var apiErr apiError
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err != nil || apiErr.Error == "" {
+			return fmt.Errorf("supervisor returned %s", resp.Status)
+		}
+		return errors.New(apiErr.Error)
+	}
+
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
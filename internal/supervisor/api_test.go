@@ -0,0 +1,68 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestControlAPI(t *testing.T) {
+	path := filepath.Join(t.TempDir(), SocketName)
+	client := NewClient(path)
+	ctx := context.Background()
+
+	if _, err := client.List(ctx); !errors.Is(err, ErrDaemonNotRunning) {
+		t.Fatalf("List() before the daemon started: error = %v, want ErrDaemonNotRunning", err)
+	}
+
+	l, err := Listen(path)
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	if _, err := Listen(path); err == nil {
+		t.Fatal("second Listen() succeeded, want an error while the daemon is live")
+	}
+
+	s := New(newFakeRunner().run, zap.NewNop())
+	served := make(chan error, 1)
+	go func() { served <- s.Serve(ctx, l) }()
+
+	status, err := client.Start(ctx, &Spec{Server: "fs", Addr: "127.0.0.1:9000", Path: "/mcp"})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if status.State != StateRunning || status.URL != "http://127.0.0.1:9000/mcp" {
+		t.Errorf("Start() status = %+v", status)
+	}
+
+	if _, err := client.Start(ctx, &Spec{Server: "fs"}); err == nil {
+		t.Error("starting a running server succeeded, want an error")
+	}
+	if _, err := client.Restart(ctx, "missing"); err == nil {
+		t.Error("restarting an unknown server succeeded, want an error")
+	}
+
+	list, err := client.List(ctx)
+	if err != nil || len(list) != 1 || list[0].Name != "fs" {
+		t.Fatalf("List() = %+v, %v", list, err)
+	}
+
+	if err := client.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Errorf("Serve() error = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Serve() did not return after shutdown")
+	}
+	if status, _ := s.Status("fs"); status.State != StateStopped {
+		t.Errorf("state after shutdown = %s, want stopped", status.State)
+	}
+}
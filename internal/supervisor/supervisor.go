@@ -0,0 +1,491 @@
+// Package supervisor keeps MCP servers running in the background on behalf
+// of a long-lived daemon, and provides the control API used to manage them.
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/xenixo/mcp-adapter/internal/launcher"
+)
+
+// State is the lifecycle state of a supervised server.
+type State string
+
+const (
+	StateStarting State = "starting"
+	StateRunning  State = "running"
+	StateStopping State = "stopping"
+	StateStopped  State = "stopped"
+	StateFailed   State = "failed"
+
+	// StateBackoff is a crashed server waiting to be restarted.
+	StateBackoff State = "backoff"
+
+	// StateDegraded is a server left down after crashing repeatedly.
+	StateDegraded State = "degraded"
+)
+
+// DefaultStartTimeout bounds how long Start waits for a server to come up.
+const DefaultStartTimeout = 30 * time.Second
+
+var (
+	// ErrNotFound is returned for servers the supervisor does not know.
+	ErrNotFound = errors.New("server not found")
+
+	// ErrAlreadyRunning is returned when starting a server that is running.
+	ErrAlreadyRunning = errors.New("server is already running")
+)
+
+// Spec describes a server to keep running and where to expose it.
+type Spec struct {
+	// Server is the name of the installed server.
+	Server string `json:"server"`
+
+	// Args are additional arguments to pass to the server.
+	Args []string `json:"args,omitempty"`
+
+	// Env are additional environment variables (KEY=VALUE).
+	Env []string `json:"env,omitempty"`
+
+	// Addr is the address the server is exposed on over Streamable HTTP.
+	// A zero port is replaced by the port picked on first start, so that
+	// the URL survives restarts.
+	Addr string `json:"addr"`
+
+	// Path is the URL path of the MCP endpoint.
+	Path string `json:"path"`
+
+	// AllowedHosts are host names, besides loopback, the endpoint may be
+	// reached by.
+	AllowedHosts []string `json:"allowedHosts,omitempty"`
+
+	// Restart decides whether the server is restarted when it exits. Nil
+	// means it is never restarted.
+	Restart *launcher.RestartPolicy `json:"restart,omitempty"`
+}
+
+// Status is a point-in-time view of a supervised server.
+type Status struct {
+	Name      string     `json:"name"`
+	State     State      `json:"state"`
+	PID       int        `json:"pid,omitempty"`
+	URL       string     `json:"url,omitempty"`
+	StartTime *time.Time `json:"startTime,omitempty"`
+	StopTime  *time.Time `json:"stopTime,omitempty"`
+
+	// NextRestart is when a server in backoff will be restarted.
+	NextRestart *time.Time `json:"nextRestart,omitempty"`
+
+	// Health is the result of the server's health checks while it runs:
+	// "ready" once it has answered MCP, or "unhealthy".
+	Health string `json:"health,omitempty"`
+
+	ExitCode int    `json:"exitCode"`
+	Restarts int    `json:"restarts"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Uptime returns how long the server has been running, or zero if it is
+// not running.
+func (s *Status) Uptime() time.Duration {
+	if s.State != StateRunning || s.StartTime == nil {
+		return 0
+	}
+	return time.Since(*s.StartTime)
+}
+
+// Instance describes a started server.
+type Instance struct {
+	// Process is the server process.
+	Process *launcher.Process
+
+	// Addr is the address the server is being served on.
+	Addr string
+}
+
+// RunFunc runs one instance of a server until ctx is cancelled or the
+// server exits. It calls started once the server is up and serving.
+// Returning nil after ctx is cancelled means the server stopped cleanly.
+type RunFunc func(ctx context.Context, spec *Spec, started func(Instance)) error
+
+// Supervisor runs servers in the background and tracks their state.
+type Supervisor struct {
+	run    RunFunc
+	logger *zap.Logger
+
+	mu       sync.Mutex
+	services map[string]*service
+}
+
+type service struct {
+	spec     Spec
+	state    State
+	proc     *launcher.Process
+	started  time.Time
+	stopped  time.Time
+	exitCode int
+	err      error
+	restarts int
+
+	history     *launcher.RestartHistory
+	nextRestart time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New creates a supervisor that runs servers with run.
+func New(run RunFunc, logger *zap.Logger) *Supervisor {
+	return &Supervisor{
+		run:      run,
+		logger:   logger,
+		services: make(map[string]*service),
+	}
+}
+
+// Start starts a server and waits until it is serving or has failed.
+func (s *Supervisor) Start(ctx context.Context, spec *Spec) (*Status, error) {
+	s.mu.Lock()
+	svc, ok := s.services[spec.Server]
+	if ok && svc.active() {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("%s: %w", spec.Server, ErrAlreadyRunning)
+	}
+	// Starting already, so that a concurrent Start sees it as active.
+	svc = &service{spec: *spec, state: StateStarting}
+	s.services[spec.Server] = svc
+	s.mu.Unlock()
+
+	return s.launch(ctx, svc)
+}
+
+// Stop stops a server and waits for it to exit.
+func (s *Supervisor) Stop(name string) (*Status, error) {
+	s.mu.Lock()
+	svc, ok := s.services[name]
+	s.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", name, ErrNotFound)
+	}
+
+	s.halt(svc)
+	return s.Status(name)
+}
+
+// Restart stops a server if it is running and starts it again with the
+// same spec, including its restart policy, and a fresh restart history.
+func (s *Supervisor) Restart(ctx context.Context, name string) (*Status, error) {
+	s.mu.Lock()
+	svc, ok := s.services[name]
+	s.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", name, ErrNotFound)
+	}
+
+	s.halt(svc)
+
+	s.mu.Lock()
+	svc.restarts++
+	s.mu.Unlock()
+	return s.launch(ctx, svc)
+}
+
+// Status returns the status of one server.
+func (s *Supervisor) Status(name string) (*Status, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	svc, ok := s.services[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", name, ErrNotFound)
+	}
+	status := svc.status(name)
+	return &status, nil
+}
+
+// List returns the status of every server, sorted by name.
+func (s *Supervisor) List() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]Status, 0, len(s.services))
+	for name, svc := range s.services {
+		result = append(result, svc.status(name))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// StopAll stops every running server.
+func (s *Supervisor) StopAll() {
+	s.mu.Lock()
+	services := make([]*service, 0, len(s.services))
+	for _, svc := range s.services {
+		services = append(services, svc)
+	}
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, svc := range services {
+		wg.Add(1)
+		go func(svc *service) {
+			defer wg.Done()
+			s.halt(svc)
+		}(svc)
+	}
+	wg.Wait()
+}
+
+// launch starts svc and waits until its first instance is serving, has
+// exited, or ctx is done. Instances that exit are restarted according to
+// the spec's restart policy until svc is stopped.
+func (s *Supervisor) launch(ctx context.Context, svc *service) (*Status, error) {
+	ctx, cancelWait := context.WithTimeout(ctx, DefaultStartTimeout)
+	defer cancelWait()
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	up := make(chan struct{})
+	firstExit := make(chan struct{})
+	done := make(chan struct{})
+
+	s.mu.Lock()
+	name := svc.spec.Server
+	svc.history = launcher.NewRestartHistory(svc.spec.Restart)
+	svc.cancel = cancel
+	svc.done = done
+	s.mu.Unlock()
+
+	var upOnce, exitOnce sync.Once
+	go func() {
+		defer close(done)
+		s.supervise(runCtx, svc,
+			func() { upOnce.Do(func() { close(up) }) },
+			func() { exitOnce.Do(func() { close(firstExit) }) })
+	}()
+
+	select {
+	case <-up:
+	case <-firstExit:
+	case <-ctx.Done():
+		// Stop the instance rather than leave it running unreported.
+		s.halt(svc)
+		err := fmt.Errorf("%s did not start in time: %w", name, ctx.Err())
+		s.mu.Lock()
+		svc.state = StateFailed
+		svc.err = err
+		s.mu.Unlock()
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := svc.status(name)
+	select {
+	case <-up:
+		return &status, nil
+	default:
+		return &status, fmt.Errorf("%s failed to start: %s", name, status.Error)
+	}
+}
+
+// supervise runs instances of svc until ctx is cancelled or the restart
+// policy leaves it down. up is called when an instance is serving and
+// exited when one has exited. A server that never came up is not restarted,
+// so that mistakes such as an address in use are reported by Start.
+func (s *Supervisor) supervise(ctx context.Context, svc *service, up, exited func()) {
+	served := false
+	for {
+		s.mu.Lock()
+		spec := svc.spec
+		svc.state = StateStarting
+		svc.proc = nil
+		svc.started = time.Time{}
+		svc.nextRestart = time.Time{}
+		svc.err = nil
+		svc.exitCode = 0
+		s.mu.Unlock()
+
+		err := s.run(ctx, &spec, func(inst Instance) {
+			s.mu.Lock()
+			svc.proc = inst.Process
+			svc.state = StateRunning
+			svc.started = time.Now()
+			if inst.Addr != "" {
+				svc.spec.Addr = inst.Addr
+			}
+			s.mu.Unlock()
+			served = true
+			up()
+		})
+
+		delay, restart := s.exited(svc, ctx.Err() != nil, served, err)
+		exited()
+		if !restart {
+			return
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			s.mu.Lock()
+			svc.state = StateStopped
+			svc.nextRestart = time.Time{}
+			s.mu.Unlock()
+			return
+		}
+
+		s.mu.Lock()
+		svc.restarts++
+		s.mu.Unlock()
+	}
+}
+
+// exited records how an instance ended and decides whether to restart it
+// and after what delay.
+func (s *Supervisor) exited(svc *service, stopped, served bool, err error) (time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	svc.stopped = now
+	if svc.proc != nil {
+		info := svc.proc.Info()
+		svc.exitCode = info.ExitCode
+		if !info.StopTime.IsZero() {
+			svc.stopped = info.StopTime
+		}
+	}
+	if err == nil && !stopped {
+		err = errors.New("server exited")
+	}
+	svc.err = err
+
+	// Failing to start counts as a failure, as does a non-zero exit code or
+	// a signal (-1).
+	failed := svc.proc == nil || svc.exitCode != 0
+
+	var delay time.Duration
+	restart := false
+	switch {
+	case stopped:
+		svc.state = StateStopped
+		svc.err = nil
+	case !served:
+		svc.state = StateFailed
+	default:
+		if !svc.started.IsZero() && svc.history.Stable(now.Sub(svc.started)) {
+			svc.history.Reset()
+		}
+		var degraded bool
+		delay, restart, degraded = svc.history.Next(now, failed)
+		switch {
+		case restart:
+			svc.state = StateBackoff
+			svc.nextRestart = now.Add(delay)
+			if svc.proc != nil {
+				svc.proc.MarkRestart(launcher.StateBackoff)
+			}
+		case degraded:
+			svc.state = StateDegraded
+			if svc.proc != nil {
+				svc.proc.MarkRestart(launcher.StateDegraded)
+			}
+			svc.err = fmt.Errorf("crash loop: more than %d restarts within %s; last exit: %w",
+				svc.history.Limit(), svc.history.Window(), err)
+		case failed:
+			svc.state = StateFailed
+		default:
+			svc.state = StateStopped
+		}
+	}
+
+	fields := []zap.Field{
+		zap.String("server", svc.spec.Server),
+		zap.String("state", string(svc.state)),
+		zap.Int("exitCode", svc.exitCode),
+	}
+	if svc.err != nil {
+		fields = append(fields, zap.Error(svc.err))
+	}
+	if restart {
+		fields = append(fields, zap.Duration("restartIn", delay))
+	}
+	s.logger.Info("supervised server exited", fields...)
+
+	return delay, restart
+}
+
+// halt stops the running instance of svc, if any, and waits for it.
+func (s *Supervisor) halt(svc *service) {
+	s.mu.Lock()
+	cancel, done := svc.cancel, svc.done
+	if svc.active() {
+		svc.state = StateStopping
+	}
+	s.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+// active reports whether an instance is starting or running, or waiting to
+// be restarted. The caller holds the supervisor's lock.
+func (svc *service) active() bool {
+	switch svc.state {
+	case StateStarting, StateRunning, StateStopping, StateBackoff:
+		return true
+	}
+	return false
+}
+
+// status builds the service's Status. The caller holds the supervisor's
+// lock.
+func (svc *service) status(name string) Status {
+	status := Status{
+		Name:     name,
+		State:    svc.state,
+		ExitCode: svc.exitCode,
+		Restarts: svc.restarts,
+	}
+	if svc.err != nil {
+		status.Error = svc.err.Error()
+	}
+	if svc.active() && svc.state != StateBackoff {
+		if !svc.started.IsZero() {
+			started := svc.started
+			status.StartTime = &started
+		}
+		if svc.proc != nil {
+			info := svc.proc.Info()
+			status.PID = info.PID
+			switch info.State {
+			case launcher.StateReady, launcher.StateUnhealthy:
+				status.Health = info.State.String()
+			}
+			if info.HealthError != nil && status.Error == "" {
+				status.Error = info.HealthError.Error()
+			}
+		}
+	} else if !svc.stopped.IsZero() {
+		stopped := svc.stopped
+		status.StopTime = &stopped
+	}
+	if svc.state == StateBackoff {
+		next := svc.nextRestart
+		status.NextRestart = &next
+	}
+	if svc.spec.Addr != "" {
+		status.URL = "http://" + svc.spec.Addr + svc.spec.Path
+	}
+	return status
+}
@@ -0,0 +1,322 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/xenixo/mcp-adapter/internal/launcher"
+)
+
+// fakeRunner stands in for a real server. Each instance runs until it is
+// stopped or crash is called.
+type fakeRunner struct {
+	mu      sync.Mutex
+	starts  int
+	fail    error
+	crashes chan error
+}
+
+func newFakeRunner() *fakeRunner {
+	return &fakeRunner{crashes: make(chan error, 1)}
+}
+
+func (f *fakeRunner) run(ctx context.Context, spec *Spec, started func(Instance)) error {
+	f.mu.Lock()
+	f.starts++
+	fail := f.fail
+	f.mu.Unlock()
+	if fail != nil {
+		return fail
+	}
+
+	addr := spec.Addr
+	if strings.HasSuffix(addr, ":0") {
+		addr = strings.TrimSuffix(addr, ":0") + ":4321"
+	}
+	started(Instance{Addr: addr})
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-f.crashes:
+		return err
+	}
+}
+
+func (f *fakeRunner) startCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.starts
+}
+
+func waitForState(t *testing.T, s *Supervisor, name string, want State) *Status {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		status, err := s.Status(name)
+		if err != nil {
+			t.Fatalf("Status() error = %v", err)
+		}
+		if status.State == want {
+			return status
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("state = %s, want %s", status.State, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSupervisorLifecycle(t *testing.T) {
+	runner := newFakeRunner()
+	s := New(runner.run, zap.NewNop())
+	ctx := context.Background()
+
+	status, err := s.Start(ctx, &Spec{Server: "fs", Addr: "127.0.0.1:0", Path: "/mcp"})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if status.State != StateRunning || status.URL != "http://127.0.0.1:4321/mcp" {
+		t.Errorf("status = %+v, want running at the resolved address", status)
+	}
+
+	if _, err := s.Start(ctx, &Spec{Server: "fs"}); !errors.Is(err, ErrAlreadyRunning) {
+		t.Errorf("second Start() error = %v, want ErrAlreadyRunning", err)
+	}
+
+	status, err = s.Restart(ctx, "fs")
+	if err != nil {
+		t.Fatalf("Restart() error = %v", err)
+	}
+	if status.Restarts != 1 || status.URL != "http://127.0.0.1:4321/mcp" || runner.startCount() != 2 {
+		t.Errorf("after restart: status = %+v, starts = %d", status, runner.startCount())
+	}
+
+	status, err = s.Stop("fs")
+	if err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if status.State != StateStopped || status.Uptime() != 0 {
+		t.Errorf("after stop: status = %+v", status)
+	}
+
+	if _, err := s.Stop("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stop(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestSupervisorRecordsFailures(t *testing.T) {
+	runner := newFakeRunner()
+	s := New(runner.run, zap.NewNop())
+
+	if _, err := s.Start(context.Background(), &Spec{Server: "gh"}); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	runner.crashes <- errors.New("server exited: exit status 3")
+	status := waitForState(t, s, "gh", StateFailed)
+	if !strings.Contains(status.Error, "exit status 3") {
+		t.Errorf("status error = %q, want the exit reason", status.Error)
+	}
+
+	runner.mu.Lock()
+	runner.fail = errors.New("address already in use")
+	runner.mu.Unlock()
+	status, err := s.Start(context.Background(), &Spec{Server: "gh"})
+	if err == nil || status == nil || status.State != StateFailed {
+		t.Errorf("Start() = %+v, %v; want a failed status and an error", status, err)
+	}
+
+	if list := s.List(); len(list) != 1 || list[0].Name != "gh" {
+		t.Errorf("List() = %+v, want the failed server", list)
+	}
+}
+
+func TestSupervisorRestartsCrashedServers(t *testing.T) {
+	runner := newFakeRunner()
+	s := New(runner.run, zap.NewNop())
+
+	spec := &Spec{
+		Server: "flaky",
+		Restart: &launcher.RestartPolicy{
+			Mode:           launcher.RestartOnFailure,
+			MaxRestarts:    2,
+			InitialBackoff: 10 * time.Millisecond,
+		},
+	}
+	if _, err := s.Start(context.Background(), spec); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	for i := 1; i <= 2; i++ {
+		runner.crashes <- errors.New("server exited: signal: killed")
+		waitForStarts(t, runner, i+1)
+		status := waitForState(t, s, "flaky", StateRunning)
+		if status.Restarts != i {
+			t.Errorf("after crash %d: restarts = %d, want %d", i, status.Restarts, i)
+		}
+	}
+
+	runner.crashes <- errors.New("server exited: signal: killed")
+	status := waitForState(t, s, "flaky", StateDegraded)
+	if !strings.Contains(status.Error, "crash loop") || runner.startCount() != 3 {
+		t.Errorf("status = %+v, starts = %d; want degraded after 3 starts", status, runner.startCount())
+	}
+
+	// A manual restart gives the server a fresh budget.
+	status, err := s.Restart(context.Background(), "flaky")
+	if err != nil || status.State != StateRunning {
+		t.Fatalf("Restart() = %+v, %v; want running", status, err)
+	}
+	runner.crashes <- errors.New("server exited: signal: killed")
+	waitForStarts(t, runner, 5)
+	waitForState(t, s, "flaky", StateRunning)
+	s.StopAll()
+}
+
+func TestSupervisorStopDuringBackoff(t *testing.T) {
+	runner := newFakeRunner()
+	s := New(runner.run, zap.NewNop())
+
+	spec := &Spec{
+		Server:  "flaky",
+		Restart: &launcher.RestartPolicy{Mode: launcher.RestartAlways, InitialBackoff: time.Hour, MaxBackoff: time.Hour},
+	}
+	if _, err := s.Start(context.Background(), spec); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	runner.crashes <- nil
+	status := waitForState(t, s, "flaky", StateBackoff)
+	if status.NextRestart == nil || time.Until(*status.NextRestart) < 59*time.Minute || status.StopTime == nil {
+		t.Errorf("status = %+v, want a restart an hour away", status)
+	}
+
+	status, err := s.Stop("flaky")
+	if err != nil || status.State != StateStopped {
+		t.Errorf("Stop() = %+v, %v; want stopped", status, err)
+	}
+	if runner.startCount() != 1 {
+		t.Errorf("starts = %d, want 1", runner.startCount())
+	}
+}
+
+func TestSupervisorDoesNotRestartFailedStart(t *testing.T) {
+	runner := newFakeRunner()
+	runner.fail = errors.New("address already in use")
+	s := New(runner.run, zap.NewNop())
+
+	_, err := s.Start(context.Background(), &Spec{Server: "gh", Restart: launcher.DefaultRestartPolicy()})
+	if err == nil {
+		t.Fatal("Start() error = nil, want an error")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if status, _ := s.Status("gh"); status.State != StateFailed || runner.startCount() != 1 {
+		t.Errorf("status = %+v, starts = %d; want failed after one start", status, runner.startCount())
+	}
+}
+
+func TestSupervisorStopsServerThatDoesNotStart(t *testing.T) {
+	stopped := make(chan struct{})
+	hang := func(ctx context.Context, spec *Spec, started func(Instance)) error {
+		<-ctx.Done()
+		close(stopped)
+		return nil
+	}
+	s := New(hang, zap.NewNop())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := s.Start(ctx, &Spec{Server: "gh"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Start() error = %v, want a deadline error", err)
+	}
+
+	select {
+	case <-stopped:
+	default:
+		t.Fatal("server still running after Start gave up on it")
+	}
+	if status, _ := s.Status("gh"); status.State != StateFailed || status.Error == "" {
+		t.Errorf("status = %+v, want failed with an error", status)
+	}
+}
+
+func TestSupervisorStartsServerOnce(t *testing.T) {
+	runner := newFakeRunner()
+	s := New(runner.run, zap.NewNop())
+	defer s.StopAll()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.Start(context.Background(), &Spec{Server: "gh"})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	started := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			started++
+		case !errors.Is(err, ErrAlreadyRunning):
+			t.Errorf("Start() error = %v, want %v", err, ErrAlreadyRunning)
+		}
+	}
+	if started != 1 || runner.startCount() != 1 {
+		t.Errorf("%d Start calls succeeded and %d instances ran, want 1 each", started, runner.startCount())
+	}
+}
+
+func waitForStarts(t *testing.T, runner *fakeRunner, want int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for runner.startCount() < want {
+		if time.Now().After(deadline) {
+			t.Fatalf("starts = %d, want %d", runner.startCount(), want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSupervisorForgetsRestartsAfterStableRun(t *testing.T) {
+	runner := newFakeRunner()
+	s := New(runner.run, zap.NewNop())
+
+	// Stable once up for Window/MaxRestarts = 200ms.
+	spec := &Spec{
+		Server: "flaky",
+		Restart: &launcher.RestartPolicy{
+			Mode:           launcher.RestartOnFailure,
+			MaxRestarts:    2,
+			Window:         400 * time.Millisecond,
+			InitialBackoff: 10 * time.Millisecond,
+		},
+	}
+	if _, err := s.Start(context.Background(), spec); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	for i := 1; i <= 2; i++ {
+		runner.crashes <- errors.New("server exited: signal: killed")
+		waitForStarts(t, runner, i+1)
+		waitForState(t, s, "flaky", StateRunning)
+	}
+
+	// A third crash within the window would be a crash loop, but the
+	// server stayed up long enough for its earlier crashes to be forgotten.
+	time.Sleep(250 * time.Millisecond)
+	runner.crashes <- errors.New("server exited: signal: killed")
+	waitForStarts(t, runner, 4)
+	waitForState(t, s, "flaky", StateRunning)
+	s.StopAll()
+}